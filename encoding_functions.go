@@ -2,11 +2,30 @@ package sprout
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/ascii85"
 	"encoding/base32"
 	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
+	"github.com/spf13/cast"
 	"gopkg.in/yaml.v3"
 )
 
@@ -42,11 +61,8 @@ func (fh *FunctionHandler) Base64Encode(s string) string {
 //
 //	{{ "SGVsbG8gV29ybGQ=" | base64Decode }} // Output: "Hello World"
 func (fh *FunctionHandler) Base64Decode(s string) string {
-	bytes, err := base64.StdEncoding.DecodeString(s)
-	if err != nil {
-		return ""
-	}
-	return string(bytes)
+	result, _ := fh.MustBase64Decode(s)
+	return result
 }
 
 // Base32Encode encodes a string into its Base32 representation.
@@ -81,266 +97,3738 @@ func (fh *FunctionHandler) Base32Encode(s string) string {
 //
 //	{{ "JBSWY3DPEBLW64TMMQQQ====" | base32Decode }} // Output: "Hello World"
 func (fh *FunctionHandler) Base32Decode(s string) string {
-	bytes, err := base32.StdEncoding.DecodeString(s)
-	if err != nil {
-		return ""
-	}
-	return string(bytes)
+	result, _ := fh.MustBase32Decode(s)
+	return result
 }
 
-// FromJson converts a JSON string into a corresponding Go data structure.
+// MustBase64Decode decodes a Base64 encoded string back to its original
+// form, returning an error if the input is not valid Base64.
 //
 // Parameters:
 //
-//	v string - the JSON string to decode.
+//	s string - the Base64 encoded string to decode.
 //
 // Returns:
 //
-//	any - the decoded Go data structure, or nil if the decoding fails.
+//	string - the decoded string.
+//	error - error if the input is not valid Base64.
 //
 // Example:
 //
-//	result := fh.FromJson(`{"name":"John", "age":30}`)
-//	fmt.Printf("%v\n", result) // Output: map[name:John age:30]
-func (fh *FunctionHandler) FromJson(v string) any {
-	output, _ := fh.MustFromJson(v)
-	return output
+//	{{ "SGVsbG8gV29ybGQ=" | mustBase64Decode }} // Output: "Hello World", nil
+func (fh *FunctionHandler) MustBase64Decode(s string) (string, error) {
+	return decodeBinaryText("base64", s, base64.StdEncoding.DecodeString)
 }
 
-// ToJson converts a Go data structure into a JSON string.
+// MustBase32Decode decodes a Base32 encoded string back to its original
+// form, returning an error if the input is not valid Base32.
 //
 // Parameters:
 //
-//	v any - the Go data structure to encode.
+//	s string - the Base32 encoded string to decode.
 //
 // Returns:
 //
-//	string - the encoded JSON string.
+//	string - the decoded string.
+//	error - error if the input is not valid Base32.
 //
 // Example:
 //
-//	jsonStr := fh.ToJson(map[string]any{"name": "John", "age": 30})
-//	fmt.Println(jsonStr) // Output: {"age":30,"name":"John"}
-func (fh *FunctionHandler) ToJson(v any) string {
-	output, _ := fh.MustToJson(v)
-	return output
+//	{{ "JBSWY3DPEBLW64TMMQ======" | mustBase32Decode }} // Output: "Hello World", nil
+func (fh *FunctionHandler) MustBase32Decode(s string) (string, error) {
+	return decodeBinaryText("base32", s, base32.StdEncoding.DecodeString)
 }
 
-// ToPrettyJson converts a Go data structure into a pretty-printed JSON string.
+// Base64RawEncode encodes a string into its Base64 representation without
+// padding.
 //
 // Parameters:
 //
-//	v any - the Go data structure to encode.
+//	s string - the string to encode.
 //
 // Returns:
 //
-//	string - the pretty-printed JSON string.
+//	string - the unpadded Base64 encoded string.
 //
 // Example:
 //
-//	prettyJson := fh.ToPrettyJson(map[string]any{"name": "John", "age": 30})
-//	fmt.Println(prettyJson) // Output: {
-//	                        //   "age": 30,
-//	                        //   "name": "John"
-//	                        // }
-func (fh *FunctionHandler) ToPrettyJson(v any) string {
-	output, _ := fh.MustToPrettyJson(v)
-	return output
+//	{{ "Hello World" | base64RawEncode }} // Output: "SGVsbG8gV29ybGQ"
+func (fh *FunctionHandler) Base64RawEncode(s string) string {
+	return base64.RawStdEncoding.EncodeToString([]byte(s))
 }
 
-// ToRawJson converts a Go data structure into a JSON string without escaping HTML.
+// Base64RawDecode decodes an unpadded Base64 encoded string back to its
+// original form. Returns an empty string if the input is not valid.
 //
 // Parameters:
 //
-//	v any - the Go data structure to encode.
+//	s string - the unpadded Base64 encoded string to decode.
 //
 // Returns:
 //
-//	string - the raw JSON string.
+//	string - the decoded string, or an empty string if the decoding fails.
 //
 // Example:
 //
-//	rawJson := fh.ToRawJson(map[string]any{"content": "<div>Hello World!</div>"})
-//	fmt.Println(rawJson) // Output: {"content":"<div>Hello World!</div>"}
-func (fh *FunctionHandler) ToRawJson(v any) string {
-	output, _ := fh.MustToRawJson(v)
-	return output
+//	{{ "SGVsbG8gV29ybGQ" | base64RawDecode }} // Output: "Hello World"
+func (fh *FunctionHandler) Base64RawDecode(s string) string {
+	result, _ := fh.MustBase64RawDecode(s)
+	return result
 }
 
-// FromYAML deserializes a YAML string into a Go map.
+// MustBase64RawDecode decodes an unpadded Base64 encoded string back to its
+// original form, returning an error if the input is not valid.
 //
 // Parameters:
 //
-//	str string - the YAML string to deserialize.
+//	s string - the unpadded Base64 encoded string to decode.
 //
 // Returns:
 //
-//	any - a map representing the YAML data. Returns nil if deserialization fails.
+//	string - the decoded string.
+//	error - error if the input is not valid unpadded Base64.
 //
 // Example:
 //
-//	{{ "name: John Doe\nage: 30" | fromYAML }} // Output: map[name:John Doe age:30]
-func (fh *FunctionHandler) FromYAML(str string) any {
-	m := make(map[string]any)
+//	{{ "SGVsbG8gV29ybGQ" | mustBase64RawDecode }} // Output: "Hello World", nil
+func (fh *FunctionHandler) MustBase64RawDecode(s string) (string, error) {
+	return decodeBinaryText("base64", s, base64.RawStdEncoding.DecodeString)
+}
 
-	if err := yaml.Unmarshal([]byte(str), &m); err != nil {
-		return nil
-	}
+// Base64UrlEncode encodes a string into its padded URL-safe Base64
+// representation, using '-' and '_' instead of '+' and '/'.
+//
+// Parameters:
+//
+//	s string - the string to encode.
+//
+// Returns:
+//
+//	string - the URL-safe Base64 encoded string.
+//
+// Example:
+//
+//	{{ "a?b>c" | base64UrlEncode }} // Output: "YT9iPmM="
+func (fh *FunctionHandler) Base64UrlEncode(s string) string {
+	return base64.URLEncoding.EncodeToString([]byte(s))
+}
 
-	return m
+// Base64UrlDecode decodes a padded URL-safe Base64 encoded string back to
+// its original form. Returns an empty string if the input is not valid.
+//
+// Parameters:
+//
+//	s string - the URL-safe Base64 encoded string to decode.
+//
+// Returns:
+//
+//	string - the decoded string, or an empty string if the decoding fails.
+//
+// Example:
+//
+//	{{ "YT9iPmM=" | base64UrlDecode }} // Output: "a?b>c"
+func (fh *FunctionHandler) Base64UrlDecode(s string) string {
+	result, _ := fh.MustBase64UrlDecode(s)
+	return result
 }
 
-// ToYAML serializes a Go data structure to a YAML string.
+// MustBase64UrlDecode decodes a padded URL-safe Base64 encoded string back
+// to its original form, returning an error if the input is not valid.
 //
 // Parameters:
 //
-//	v any - the data structure to serialize.
+//	s string - the URL-safe Base64 encoded string to decode.
 //
 // Returns:
 //
-//	string - the YAML string representation of the data structure.
+//	string - the decoded string.
+//	error - error if the input is not valid URL-safe Base64.
 //
 // Example:
 //
-//	{{ {"name": "John Doe", "age": 30} | toYAML }} // Output: "name: John Doe\nage: 30\n"
-func (fh *FunctionHandler) ToYAML(v any) string {
-	result, _ := fh.MustToYAML(v)
+//	{{ "YT9iPmM=" | mustBase64UrlDecode }} // Output: "a?b>c", nil
+func (fh *FunctionHandler) MustBase64UrlDecode(s string) (string, error) {
+	return decodeBinaryText("base64url", s, base64.URLEncoding.DecodeString)
+}
+
+// Base64RawUrlEncode encodes a string into its URL-safe Base64
+// representation without padding, as used in JWTs.
+//
+// Parameters:
+//
+//	s string - the string to encode.
+//
+// Returns:
+//
+//	string - the unpadded URL-safe Base64 encoded string.
+//
+// Example:
+//
+//	{{ "a?b>c" | base64RawUrlEncode }} // Output: "YT9iPmM"
+func (fh *FunctionHandler) Base64RawUrlEncode(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// Base64RawUrlDecode decodes an unpadded URL-safe Base64 encoded string back
+// to its original form. Returns an empty string if the input is not valid.
+//
+// Parameters:
+//
+//	s string - the unpadded URL-safe Base64 encoded string to decode.
+//
+// Returns:
+//
+//	string - the decoded string, or an empty string if the decoding fails.
+//
+// Example:
+//
+//	{{ "YT9iPmM" | base64RawUrlDecode }} // Output: "a?b>c"
+func (fh *FunctionHandler) Base64RawUrlDecode(s string) string {
+	result, _ := fh.MustBase64RawUrlDecode(s)
 	return result
 }
 
-// MustFromJson decodes a JSON string into a Go data structure, returning an
-// error if decoding fails.
+// MustBase64RawUrlDecode decodes an unpadded URL-safe Base64 encoded string
+// back to its original form, returning an error if the input is not valid.
 //
 // Parameters:
 //
-//	v string - the JSON string to decode.
+//	s string - the unpadded URL-safe Base64 encoded string to decode.
 //
 // Returns:
 //
-//	any - the decoded Go data structure.
-//	error - error encountered during decoding, if any.
+//	string - the decoded string.
+//	error - error if the input is not valid unpadded URL-safe Base64.
 //
 // Example:
 //
-//	{{ `{"name":"John", "age":30}` | mustFromJson }} // Output: map[name:John age:30], nil
-func (fh *FunctionHandler) MustFromJson(v string) (any, error) {
-	var output any
-	err := json.Unmarshal([]byte(v), &output)
-	return output, err
+//	{{ "YT9iPmM" | mustBase64RawUrlDecode }} // Output: "a?b>c", nil
+func (fh *FunctionHandler) MustBase64RawUrlDecode(s string) (string, error) {
+	return decodeBinaryText("base64url", s, base64.RawURLEncoding.DecodeString)
 }
 
-// MustToJson encodes a Go data structure into a JSON string, returning an error
-// if encoding fails.
+// HexEncode encodes a string into its lowercase hexadecimal representation.
 //
 // Parameters:
 //
-//	v any - the Go data structure to encode.
+//	s string - the string to encode.
 //
 // Returns:
 //
-//	string - the JSON-encoded string.
-//	error - error encountered during encoding, if any.
+//	string - the hexadecimal encoded string.
 //
 // Example:
 //
-//	{{ {"name": "John", "age": 30} | mustToJson }} // Output: "{"age":30,"name":"John"}", nil
-func (fh *FunctionHandler) MustToJson(v any) (string, error) {
-	output, err := json.Marshal(v)
-	if err != nil {
-		return "", err
-	}
-	return string(output), nil
+//	{{ "Hello" | hexEncode }} // Output: "48656c6c6f"
+func (fh *FunctionHandler) HexEncode(s string) string {
+	return hex.EncodeToString([]byte(s))
 }
 
-// MustToPrettyJson encodes a Go data structure into a pretty-printed JSON
-// string, returning an error if encoding fails.
+// HexDecode decodes a hexadecimal string, in lower or upper case, back to its
+// original form. Returns an empty string if the input is not valid.
 //
 // Parameters:
 //
-//	v any - the Go data structure to encode.
+//	s string - the hexadecimal string to decode.
 //
 // Returns:
 //
-//	string - the pretty-printed JSON string.
-//	error - error encountered during encoding, if any.
+//	string - the decoded string, or an empty string if the decoding fails.
 //
 // Example:
 //
-//	{{ {"name": "John", "age": 30} | mustToPrettyJson }} // Output: "{\n  \"age\": 30,\n  \"name\": \"John\"\n}", nil
-func (fh *FunctionHandler) MustToPrettyJson(v any) (string, error) {
-	output, err := json.MarshalIndent(v, "", "  ")
-	if err != nil {
-		return "", err
+//	{{ "48656C6C6F" | hexDecode }} // Output: "Hello"
+func (fh *FunctionHandler) HexDecode(s string) string {
+	result, _ := fh.MustHexDecode(s)
+	return result
+}
+
+// MustHexDecode decodes a hexadecimal string back to its original form,
+// returning an error if the input is not valid hexadecimal.
+//
+// Parameters:
+//
+//	s string - the hexadecimal string to decode.
+//
+// Returns:
+//
+//	string - the decoded string.
+//	error - error if the input is not valid hexadecimal.
+//
+// Example:
+//
+//	{{ "48656c6c6f" | mustHexDecode }} // Output: "Hello", nil
+func (fh *FunctionHandler) MustHexDecode(s string) (string, error) {
+	return decodeBinaryText("hex", s, hex.DecodeString)
+}
+
+// Base58Encode encodes a string into its Base58 representation, using the
+// Bitcoin alphabet. Leading zero bytes are encoded as '1'.
+//
+// Parameters:
+//
+//	s string - the string to encode.
+//
+// Returns:
+//
+//	string - the Base58 encoded string.
+//
+// Example:
+//
+//	{{ "Hello World" | base58Encode }} // Output: "JxF12TrwUP45BMd"
+func (fh *FunctionHandler) Base58Encode(s string) string {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == 0 {
+		zeros++
 	}
-	return string(output), nil
+
+	// Each byte takes at most log(256)/log(58) ≈ 1.37 Base58 digits.
+	digits := make([]byte, 0, len(s)*138/100+1)
+	for i := zeros; i < len(s); i++ {
+		carry := int(s[i])
+		for j := range digits {
+			carry += int(digits[j]) << 8
+			digits[j] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+
+	result := make([]byte, zeros, zeros+len(digits))
+	for i := range result {
+		result[i] = base58Alphabet[0]
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		result = append(result, base58Alphabet[digits[i]])
+	}
+	return string(result)
 }
 
-// MustToRawJson encodes a Go data structure into a JSON string without escaping
-// HTML, returning an error if encoding fails.
+// Base58Decode decodes a Base58 string, using the Bitcoin alphabet, back to
+// its original form. Returns an empty string if the input is not valid.
 //
 // Parameters:
 //
-//	v any - the Go data structure to encode.
+//	s string - the Base58 encoded string to decode.
 //
 // Returns:
 //
-//	string - the raw JSON string.
-//	error - error encountered during encoding, if any.
+//	string - the decoded string, or an empty string if the decoding fails.
 //
 // Example:
 //
-//	{{ {"content": "<div>Hello World!</div>"} | mustToRawJson }} // Output: "{\"content\":\"<div>Hello World!</div>\"}", nil
-func (fh *FunctionHandler) MustToRawJson(v any) (string, error) {
-	buf := new(bytes.Buffer)
-	enc := json.NewEncoder(buf)
-	enc.SetEscapeHTML(false)
-	err := enc.Encode(&v)
-	if err != nil {
-		return "", err
+//	{{ "JxF12TrwUP45BMd" | base58Decode }} // Output: "Hello World"
+func (fh *FunctionHandler) Base58Decode(s string) string {
+	result, _ := fh.MustBase58Decode(s)
+	return result
+}
+
+// MustBase58Decode decodes a Base58 string, using the Bitcoin alphabet, back
+// to its original form, returning an error if the input contains characters
+// outside of the alphabet.
+//
+// Parameters:
+//
+//	s string - the Base58 encoded string to decode.
+//
+// Returns:
+//
+//	string - the decoded string.
+//	error - error if the input is not valid Base58.
+//
+// Example:
+//
+//	{{ "JxF12TrwUP45BMd" | mustBase58Decode }} // Output: "Hello World", nil
+func (fh *FunctionHandler) MustBase58Decode(s string) (string, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
 	}
-	return strings.TrimSuffix(buf.String(), "\n"), nil
+
+	bytes := make([]byte, 0, len(s)*733/1000+1)
+	for i := zeros; i < len(s); i++ {
+		digit := strings.IndexByte(base58Alphabet, s[i])
+		if digit < 0 {
+			r, _ := utf8.DecodeRuneInString(s[i:])
+			return "", fmt.Errorf("unable to decode base58: invalid character %q at offset %d", r, i)
+		}
+		carry := digit
+		for j := range bytes {
+			carry += int(bytes[j]) * 58
+			bytes[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			bytes = append(bytes, byte(carry))
+			carry >>= 8
+		}
+	}
+
+	result := make([]byte, zeros, zeros+len(bytes))
+	for i := len(bytes) - 1; i >= 0; i-- {
+		result = append(result, bytes[i])
+	}
+	return string(result), nil
 }
 
-// MustFromYaml deserializes a YAML string into a Go data structure, returning
-// the result along with any error that occurs.
+// Ascii85Encode encodes a string into its Ascii85 representation, as used by
+// PostScript and PDF, without the "<~" and "~>" delimiters.
 //
 // Parameters:
 //
-//	v string - the YAML string to deserialize.
+//	s string - the string to encode.
 //
 // Returns:
 //
-//	any - the Go data structure representing the deserialized YAML content.
-//	error - an error if the YAML content cannot be deserialized.
+//	string - the Ascii85 encoded string.
 //
 // Example:
 //
-//	{{ "name: John Doe\nage: 30" | mustFromYaml }} // Output: map[name:John Doe age:30], nil
-func (fh *FunctionHandler) MustFromYAML(v string) (any, error) {
-	var output any
-	err := yaml.Unmarshal([]byte(v), &output)
-	return output, err
+//	{{ "Hello" | ascii85Encode }} // Output: "87cURDZ"
+func (fh *FunctionHandler) Ascii85Encode(s string) string {
+	dst := make([]byte, ascii85.MaxEncodedLen(len(s)))
+	return string(dst[:ascii85.Encode(dst, []byte(s))])
 }
 
-// MustToYAML serializes a Go data structure to a YAML string and returns any error that occurs during the serialization.
+// Ascii85Decode decodes an Ascii85 string back to its original form. The
+// "<~" and "~>" delimiters and whitespace are ignored. Returns an empty
+// string if the input is not valid.
 //
 // Parameters:
 //
-//	v any - the data structure to serialize.
+//	s string - the Ascii85 encoded string to decode.
 //
 // Returns:
 //
-//	string - the YAML string representation of the data structure.
-//	error - error if the serialization fails.
+//	string - the decoded string, or an empty string if the decoding fails.
 //
 // Example:
 //
-//	{{ {"name": "John Doe", "age": 30} | mustToYAML }} // Output: "name: John Doe\nage: 30\n", nil
-func (fh *FunctionHandler) MustToYAML(v any) (string, error) {
-	data, err := yaml.Marshal(v)
-	if err != nil {
-		return "", err
+//	{{ "<~87cURDZ~>" | ascii85Decode }} // Output: "Hello"
+func (fh *FunctionHandler) Ascii85Decode(s string) string {
+	result, _ := fh.MustAscii85Decode(s)
+	return result
+}
+
+// MustAscii85Decode decodes an Ascii85 string back to its original form,
+// returning an error if the input is not valid Ascii85.
+//
+// Parameters:
+//
+//	s string - the Ascii85 encoded string to decode.
+//
+// Returns:
+//
+//	string - the decoded string.
+//	error - error if the input is not valid Ascii85.
+//
+// Example:
+//
+//	{{ "87cURDZ" | mustAscii85Decode }} // Output: "Hello", nil
+func (fh *FunctionHandler) MustAscii85Decode(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if trimmed, ok := strings.CutPrefix(s, "<~"); ok {
+		s = strings.TrimSuffix(trimmed, "~>")
 	}
+	return decodeBinaryText("ascii85", s, func(s string) ([]byte, error) {
+		return io.ReadAll(ascii85.NewDecoder(strings.NewReader(s)))
+	})
+}
 
-	return strings.TrimSuffix(string(data), "\n"), nil
+// PercentEncode percent-encodes every byte of a string except the unreserved
+// characters of RFC 3986 (letters, digits, '-', '.', '_' and '~'). Unlike
+// urlQueryEscape, spaces are encoded as "%20". Use percentDecode, the same
+// function as urlPathUnescape, to decode the result.
+//
+// Parameters:
+//
+//	s string - the string to encode.
+//
+// Returns:
+//
+//	string - the percent-encoded string.
+//
+// Example:
+//
+//	{{ "a b/c?d" | percentEncode }} // Output: "a%20b%2Fc%3Fd"
+func (fh *FunctionHandler) PercentEncode(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			builder.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&builder, "%%%02X", c)
+	}
+	return builder.String()
+}
+
+// GzipCompress compresses a string with gzip at the default compression
+// level. Combine it with base64Encode to embed the result in text.
+//
+// Parameters:
+//
+//	s string - the string to compress.
+//
+// Returns:
+//
+//	string - the gzip compressed bytes.
+//
+// Example:
+//
+//	{{ "Hello World" | gzipCompress | base64Encode }} // Output: "H4sIAAAAAAAA/wALAPT/SGVsbG8gV29ybGQDAFaxF0oLAAAA"
+func (fh *FunctionHandler) GzipCompress(s string) string {
+	result, _ := fh.MustGzipCompressWith(gzip.DefaultCompression, s)
+	return result
+}
+
+// GzipCompressWith compresses a string with gzip at the given compression
+// level, from 1 (best speed) to 9 (best compression). Level 0 stores the
+// data uncompressed and -1 selects the default level.
+//
+// Parameters:
+//
+//	level any - the compression level.
+//	s string - the string to compress.
+//
+// Returns:
+//
+//	string - the gzip compressed bytes, or an empty string if the level is invalid.
+//
+// Example:
+//
+//	{{ .Payload | gzipCompressWith 9 | base64Encode }}
+func (fh *FunctionHandler) GzipCompressWith(level any, s string) string {
+	result, _ := fh.MustGzipCompressWith(level, s)
+	return result
+}
+
+// MustGzipCompressWith compresses a string with gzip at the given
+// compression level, returning an error if the level is invalid.
+//
+// Parameters:
+//
+//	level any - the compression level, from -2 to 9.
+//	s string - the string to compress.
+//
+// Returns:
+//
+//	string - the gzip compressed bytes.
+//	error - error if the level is invalid.
+//
+// Example:
+//
+//	{{ .Payload | mustGzipCompressWith 1 | base64Encode }}
+func (fh *FunctionHandler) MustGzipCompressWith(level any, s string) (string, error) {
+	return compress("gzip", level, s, func(w io.Writer, level int) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	})
+}
+
+// GzipDecompress decompresses gzip compressed bytes. Returns an empty string
+// if the data is invalid or exceeds the handler's decompression limit.
+//
+// Parameters:
+//
+//	s string - the gzip compressed bytes.
+//
+// Returns:
+//
+//	string - the decompressed string, or an empty string on error.
+//
+// Example:
+//
+//	{{ "H4sIAAAAAAAA/wALAPT/SGVsbG8gV29ybGQDAFaxF0oLAAAA" | base64Decode | gzipDecompress }} // Output: "Hello World"
+func (fh *FunctionHandler) GzipDecompress(s string) string {
+	result, _ := fh.MustGzipDecompress(s)
+	return result
+}
+
+// MustGzipDecompress decompresses gzip compressed bytes, returning an error
+// if the data is invalid or would exceed the handler's decompression limit
+// (see WithMaxDecompressedSize).
+//
+// Parameters:
+//
+//	s string - the gzip compressed bytes.
+//
+// Returns:
+//
+//	string - the decompressed string.
+//	error - error if the data is invalid or too large once decompressed.
+//
+// Example:
+//
+//	{{ .Payload | base64Decode | mustGzipDecompress }}
+func (fh *FunctionHandler) MustGzipDecompress(s string) (string, error) {
+	return fh.decompress("gzip", s, func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	})
+}
+
+// ZlibCompress compresses a string with zlib at the default compression
+// level. Combine it with base64Encode to embed the result in text.
+//
+// Parameters:
+//
+//	s string - the string to compress.
+//
+// Returns:
+//
+//	string - the zlib compressed bytes.
+//
+// Example:
+//
+//	{{ "Hello World" | zlibCompress | base64Encode }} // Output: "eJwACwD0/0hlbGxvIFdvcmxkAwAYCwQd"
+func (fh *FunctionHandler) ZlibCompress(s string) string {
+	result, _ := fh.MustZlibCompressWith(zlib.DefaultCompression, s)
+	return result
+}
+
+// ZlibCompressWith compresses a string with zlib at the given compression
+// level, from 1 (best speed) to 9 (best compression). Level 0 stores the
+// data uncompressed and -1 selects the default level.
+//
+// Parameters:
+//
+//	level any - the compression level.
+//	s string - the string to compress.
+//
+// Returns:
+//
+//	string - the zlib compressed bytes, or an empty string if the level is invalid.
+//
+// Example:
+//
+//	{{ .Payload | zlibCompressWith 9 | base64Encode }}
+func (fh *FunctionHandler) ZlibCompressWith(level any, s string) string {
+	result, _ := fh.MustZlibCompressWith(level, s)
+	return result
+}
+
+// MustZlibCompressWith compresses a string with zlib at the given
+// compression level, returning an error if the level is invalid.
+//
+// Parameters:
+//
+//	level any - the compression level, from -2 to 9.
+//	s string - the string to compress.
+//
+// Returns:
+//
+//	string - the zlib compressed bytes.
+//	error - error if the level is invalid.
+//
+// Example:
+//
+//	{{ .Payload | mustZlibCompressWith 1 | base64Encode }}
+func (fh *FunctionHandler) MustZlibCompressWith(level any, s string) (string, error) {
+	return compress("zlib", level, s, func(w io.Writer, level int) (io.WriteCloser, error) {
+		return zlib.NewWriterLevel(w, level)
+	})
+}
+
+// ZlibDecompress decompresses zlib compressed bytes. Returns an empty string
+// if the data is invalid or exceeds the handler's decompression limit.
+//
+// Parameters:
+//
+//	s string - the zlib compressed bytes.
+//
+// Returns:
+//
+//	string - the decompressed string, or an empty string on error.
+//
+// Example:
+//
+//	{{ "eJwACwD0/0hlbGxvIFdvcmxkAwAYCwQd" | base64Decode | zlibDecompress }} // Output: "Hello World"
+func (fh *FunctionHandler) ZlibDecompress(s string) string {
+	result, _ := fh.MustZlibDecompress(s)
+	return result
+}
+
+// MustZlibDecompress decompresses zlib compressed bytes, returning an error
+// if the data is invalid or would exceed the handler's decompression limit
+// (see WithMaxDecompressedSize).
+//
+// Parameters:
+//
+//	s string - the zlib compressed bytes.
+//
+// Returns:
+//
+//	string - the decompressed string.
+//	error - error if the data is invalid or too large once decompressed.
+//
+// Example:
+//
+//	{{ .Payload | base64Decode | mustZlibDecompress }}
+func (fh *FunctionHandler) MustZlibDecompress(s string) (string, error) {
+	return fh.decompress("zlib", s, func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	})
+}
+
+// FromJson converts a JSON string into a corresponding Go data structure.
+//
+// Parameters:
+//
+//	v string - the JSON string to decode.
+//
+// Returns:
+//
+//	any - the decoded Go data structure, or nil if the decoding fails.
+//
+// Example:
+//
+//	result := fh.FromJson(`{"name":"John", "age":30}`)
+//	fmt.Printf("%v\n", result) // Output: map[name:John age:30]
+func (fh *FunctionHandler) FromJson(v string) any {
+	output, _ := fh.MustFromJson(v)
+	return output
+}
+
+// ToJson converts a Go data structure into a JSON string.
+//
+// Parameters:
+//
+//	v any - the Go data structure to encode.
+//
+// Returns:
+//
+//	string - the encoded JSON string.
+//
+// Example:
+//
+//	jsonStr := fh.ToJson(map[string]any{"name": "John", "age": 30})
+//	fmt.Println(jsonStr) // Output: {"age":30,"name":"John"}
+func (fh *FunctionHandler) ToJson(v any) string {
+	output, _ := fh.MustToJson(v)
+	return output
+}
+
+// ToPrettyJson converts a Go data structure into a pretty-printed JSON string.
+//
+// Parameters:
+//
+//	v any - the Go data structure to encode.
+//
+// Returns:
+//
+//	string - the pretty-printed JSON string.
+//
+// Example:
+//
+//	prettyJson := fh.ToPrettyJson(map[string]any{"name": "John", "age": 30})
+//	fmt.Println(prettyJson) // Output: {
+//	                        //   "age": 30,
+//	                        //   "name": "John"
+//	                        // }
+func (fh *FunctionHandler) ToPrettyJson(v any) string {
+	output, _ := fh.MustToPrettyJson(v)
+	return output
+}
+
+// ToRawJson converts a Go data structure into a JSON string without escaping HTML.
+//
+// Parameters:
+//
+//	v any - the Go data structure to encode.
+//
+// Returns:
+//
+//	string - the raw JSON string.
+//
+// Example:
+//
+//	rawJson := fh.ToRawJson(map[string]any{"content": "<div>Hello World!</div>"})
+//	fmt.Println(rawJson) // Output: {"content":"<div>Hello World!</div>"}
+func (fh *FunctionHandler) ToRawJson(v any) string {
+	output, _ := fh.MustToRawJson(v)
+	return output
+}
+
+// FromYAML deserializes a YAML string into a Go map.
+//
+// Parameters:
+//
+//	str string - the YAML string to deserialize.
+//
+// Returns:
+//
+//	any - a map representing the YAML data. Returns nil if deserialization fails.
+//
+// Example:
+//
+//	{{ "name: John Doe\nage: 30" | fromYAML }} // Output: map[name:John Doe age:30]
+func (fh *FunctionHandler) FromYAML(str string) any {
+	m := make(map[string]any)
+
+	if err := yaml.Unmarshal([]byte(str), &m); err != nil {
+		return nil
+	}
+
+	return m
+}
+
+// ToYAML serializes a Go data structure to a YAML string.
+//
+// Parameters:
+//
+//	v any - the data structure to serialize.
+//
+// Returns:
+//
+//	string - the YAML string representation of the data structure.
+//
+// Example:
+//
+//	{{ {"name": "John Doe", "age": 30} | toYAML }} // Output: "name: John Doe\nage: 30\n"
+func (fh *FunctionHandler) ToYAML(v any) string {
+	result, _ := fh.MustToYAML(v)
+	return result
+}
+
+// FromTOML deserializes a TOML document into a Go map. Tables become maps,
+// arrays become lists, integers become int64 and date-times become time.Time
+// values.
+//
+// Parameters:
+//
+//	str string - the TOML document to deserialize.
+//
+// Returns:
+//
+//	any - a map representing the TOML document. Returns nil if deserialization fails.
+//
+// Example:
+//
+//	{{ "name = \"John Doe\"\n[owner]\nage = 30" | fromToml }} // Output: map[name:John Doe owner:map[age:30]]
+func (fh *FunctionHandler) FromTOML(str string) any {
+	result, _ := fh.MustFromTOML(str)
+	return result
+}
+
+// ToTOML serializes a map to a TOML document. Keys are sorted, nested maps are
+// written as tables and lists of maps as arrays of tables.
+//
+// Parameters:
+//
+//	v any - the map to serialize.
+//
+// Returns:
+//
+//	string - the TOML document.
+//
+// Example:
+//
+//	{{ dict "name" "John Doe" "owner" (dict "age" 30) | toToml }} // Output: "name = \"John Doe\"\n\n[owner]\nage = 30"
+func (fh *FunctionHandler) ToTOML(v any) string {
+	result, _ := fh.MustToTOML(v)
+	return result
+}
+
+// FromCsv parses CSV text into a list of rows, each row being a list of
+// fields. All the rows must have the same number of fields.
+//
+// Parameters:
+//
+//	str string - the CSV text to parse.
+//
+// Returns:
+//
+//	[][]string - the rows of the CSV text. Returns an empty list if parsing fails.
+//
+// Example:
+//
+//	{{ "name,age\nAlice,30" | fromCsv }} // Output: [[name age] [Alice 30]]
+func (fh *FunctionHandler) FromCsv(str string) [][]string {
+	result, _ := fh.MustFromCsv(str)
+	return result
+}
+
+// FromTsv parses tab-separated text into a list of rows, each row being a
+// list of fields.
+//
+// Parameters:
+//
+//	str string - the TSV text to parse.
+//
+// Returns:
+//
+//	[][]string - the rows of the TSV text. Returns an empty list if parsing fails.
+//
+// Example:
+//
+//	{{ "name\tage\nAlice\t30" | fromTsv }} // Output: [[name age] [Alice 30]]
+func (fh *FunctionHandler) FromTsv(str string) [][]string {
+	result, _ := fh.MustFromTsv(str)
+	return result
+}
+
+// FromCsvWith parses CSV text with custom options, given as a dict or a
+// CsvOptions. The dict keys are "delimiter", "comment", "lazyQuotes", "trim"
+// and "header". With "header", the first row names the columns and the other
+// rows are returned as a list of dicts.
+//
+// Parameters:
+//
+//	options any - the parsing options.
+//	str string - the CSV text to parse.
+//
+// Returns:
+//
+//	any - the rows, as lists of fields or as dicts. Returns nil if parsing fails.
+//
+// Example:
+//
+//	{{ "name;age\nAlice;30" | fromCsvWith (dict "delimiter" ";" "header" true) }} // Output: [map[age:30 name:Alice]]
+func (fh *FunctionHandler) FromCsvWith(options any, str string) any {
+	result, _ := fh.MustFromCsvWith(options, str)
+	return result
+}
+
+// ToCsv serializes a list of rows into CSV text, quoting the fields when
+// needed. Rows may be lists of fields, or dicts written under a header row
+// of their keys in alphabetical order.
+//
+// Parameters:
+//
+//	rows any - the list of rows to serialize.
+//
+// Returns:
+//
+//	string - the CSV text.
+//
+// Example:
+//
+//	{{ list (list "name" "note") (list "Alice" "likes \"tea\", coffee") | toCsv }} // Output: "name,note\nAlice,\"likes \"\"tea\"\", coffee\""
+func (fh *FunctionHandler) ToCsv(rows any) string {
+	result, _ := fh.MustToCsv(rows)
+	return result
+}
+
+// ToTsv serializes a list of rows into tab-separated text.
+//
+// Parameters:
+//
+//	rows any - the list of rows to serialize.
+//
+// Returns:
+//
+//	string - the TSV text.
+//
+// Example:
+//
+//	{{ list (dict "name" "Alice" "age" 30) | toTsv }} // Output: "age\tname\n30\tAlice"
+func (fh *FunctionHandler) ToTsv(rows any) string {
+	result, _ := fh.MustToTsv(rows)
+	return result
+}
+
+// ToCsvWith serializes a list of rows into CSV text with custom options,
+// given as a dict or a CsvOptions. The dict keys are "delimiter", "header"
+// and "columns", the list of the dict keys to write, in order.
+//
+// Parameters:
+//
+//	options any - the serialization options.
+//	rows any - the list of rows to serialize.
+//
+// Returns:
+//
+//	string - the CSV text.
+//
+// Example:
+//
+//	{{ $users | toCsvWith (dict "columns" (list "name" "email")) }} // Output: "name,email\nAlice,alice@example.com"
+func (fh *FunctionHandler) ToCsvWith(options any, rows any) string {
+	result, _ := fh.MustToCsvWith(options, rows)
+	return result
+}
+
+// FromXML deserializes an XML document into a dict holding its root element.
+// Elements with neither attributes nor child elements become strings. Other
+// elements become dicts where attributes are prefixed by "@", the text is
+// stored under "#text" and repeated child elements are grouped into lists.
+// A child element appearing once is not wrapped in a list; use fromXmlWith and
+// its "forceList" option for elements that may be repeated.
+//
+// Parameters:
+//
+//	str string - the XML document to deserialize.
+//
+// Returns:
+//
+//	any - a map representing the XML document. Returns nil if deserialization fails.
+//
+// Example:
+//
+//	{{ `<server id="1"><host>alpha</host><port>80</port><port>443</port></server>` | fromXml }} // Output: map[server:map[@id:1 host:alpha port:[80 443]]]
+func (fh *FunctionHandler) FromXML(str string) any {
+	result, _ := fh.MustFromXML(str)
+	return result
+}
+
+// FromXMLWith deserializes an XML document like fromXml with custom options,
+// given as a dict or an XmlOptions. The dict key is "forceList", the list of
+// the names of the child elements always grouped into lists, even when they
+// appear once, "*" standing for every element. Templates can then range over
+// them whatever their number.
+//
+// Parameters:
+//
+//	options any - the parsing options.
+//	str string - the XML document to deserialize.
+//
+// Returns:
+//
+//	any - a map representing the XML document. Returns nil if deserialization fails.
+//
+// Example:
+//
+//	{{ `<server><port a="1">80</port></server>` | fromXmlWith (dict "forceList" (list "port")) }} // Output: map[server:map[port:[map[#text:80 @a:1]]]]
+func (fh *FunctionHandler) FromXMLWith(options any, str string) any {
+	result, _ := fh.MustFromXMLWith(options, str)
+	return result
+}
+
+// ToXML serializes a dict holding a single root element into an indented XML
+// document with an XML declaration, following the conventions of fromXml.
+// Keys are written in alphabetical order.
+//
+// Parameters:
+//
+//	v any - the dict to serialize.
+//
+// Returns:
+//
+//	string - the XML document.
+//
+// Example:
+//
+//	{{ dict "server" (dict "@id" 1 "host" "alpha") | toXml }} // Output: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<server id=\"1\">\n  <host>alpha</host>\n</server>"
+func (fh *FunctionHandler) ToXML(v any) string {
+	result, _ := fh.MustToXML(v)
+	return result
+}
+
+// ToXMLWith serializes a dict into an XML document with custom options, given
+// as a dict or an XmlOptions. The dict keys are "indent", the string used to
+// indent nested elements, "declaration", whether to write the XML
+// declaration, and "root", the name of an element wrapping the dict.
+//
+// Parameters:
+//
+//	options any - the serialization options.
+//	v any - the dict to serialize.
+//
+// Returns:
+//
+//	string - the XML document.
+//
+// Example:
+//
+//	{{ dict "host" "alpha" | toXmlWith (dict "root" "server" "indent" "" "declaration" false) }} // Output: "<server><host>alpha</host></server>"
+func (fh *FunctionHandler) ToXMLWith(options any, v any) string {
+	result, _ := fh.MustToXMLWith(options, v)
+	return result
+}
+
+// FromDotenv parses a dotenv file into a dict of variables. Lines may start
+// with "export", values may be single-quoted, taken literally, or
+// double-quoted, with escape sequences. Both may span several lines. Unquoted
+// values end at a " #" comment. Variables are not expanded.
+//
+// Parameters:
+//
+//	str string - the dotenv content to parse.
+//
+// Returns:
+//
+//	map[string]any - the variables. Returns an empty dict if parsing fails.
+//
+// Example:
+//
+//	{{ "export HOST=localhost # dev\nGREETING=\"hello\\nworld\"" | fromDotenv }} // Output: map[GREETING:hello\nworld HOST:localhost]
+func (fh *FunctionHandler) FromDotenv(str string) map[string]any {
+	result, _ := fh.MustFromDotenv(str)
+	return result
+}
+
+// ToDotenv serializes a dict of variables into a dotenv file, sorted by name.
+// Values are double-quoted and escaped when they contain characters other
+// than letters, digits and "_./:@%+,-".
+//
+// Parameters:
+//
+//	v any - the dict of variables to serialize.
+//
+// Returns:
+//
+//	string - the dotenv content.
+//
+// Example:
+//
+//	{{ dict "PORT" 8080 "MOTD" "it's $HOME" | toDotenv }} // Output: "MOTD=\"it's \\$HOME\"\nPORT=8080"
+func (fh *FunctionHandler) ToDotenv(v any) string {
+	result, _ := fh.MustToDotenv(v)
+	return result
+}
+
+// FromINI parses an INI file into a dict where each section is a nested dict.
+// Keys defined before the first section are stored at the top level. Lines
+// starting with ";" or "#" are comments, and values wrapped in double quotes
+// are unquoted.
+//
+// Parameters:
+//
+//	str string - the INI content to parse.
+//
+// Returns:
+//
+//	map[string]any - the sections and their keys. Returns an empty dict if parsing fails.
+//
+// Example:
+//
+//	{{ "name = app\n[database]\nhost = localhost\nport: 5432" | fromIni }} // Output: map[database:map[host:localhost port:5432] name:app]
+func (fh *FunctionHandler) FromINI(str string) map[string]any {
+	result, _ := fh.MustFromINI(str)
+	return result
+}
+
+// ToINI serializes a dict into an INI file. Nested dicts become sections and
+// other values top-level keys, all sorted by name. Values are quoted when
+// they would not be read back as is.
+//
+// Parameters:
+//
+//	v any - the dict to serialize.
+//
+// Returns:
+//
+//	string - the INI content.
+//
+// Example:
+//
+//	{{ dict "name" "app" "database" (dict "host" "localhost") | toIni }} // Output: "name = app\n\n[database]\nhost = localhost"
+func (fh *FunctionHandler) ToINI(v any) string {
+	result, _ := fh.MustToINI(v)
+	return result
+}
+
+// FromProperties parses a Java properties file into a flat dict. It supports
+// the "=", ":" and whitespace separators, "#" and "!" comments, escape
+// sequences including "\uXXXX", and lines continued by a backslash. Later
+// definitions of a key override earlier ones.
+//
+// Parameters:
+//
+//	str string - the properties content to parse.
+//
+// Returns:
+//
+//	map[string]any - the properties. Returns an empty dict if parsing fails.
+//
+// Example:
+//
+//	{{ "app.name = My App\napp.greeting = caf\\u00e9" | fromProperties }} // Output: map[app.greeting:café app.name:My App]
+func (fh *FunctionHandler) FromProperties(str string) map[string]any {
+	result, _ := fh.MustFromProperties(str)
+	return result
+}
+
+// ToProperties serializes a dict into a Java properties file sorted by key.
+// Nested dicts are flattened into dotted keys, and characters outside of
+// printable ASCII are written as "\uXXXX" escapes.
+//
+// Parameters:
+//
+//	v any - the dict to serialize.
+//
+// Returns:
+//
+//	string - the properties content.
+//
+// Example:
+//
+//	{{ dict "app" (dict "name" "My App" "port" 8080) | toProperties }} // Output: "app.name=My App\napp.port=8080"
+func (fh *FunctionHandler) ToProperties(v any) string {
+	result, _ := fh.MustToProperties(v)
+	return result
+}
+
+// MustFromJson decodes a JSON string into a Go data structure, returning an
+// error if decoding fails.
+//
+// Parameters:
+//
+//	v string - the JSON string to decode.
+//
+// Returns:
+//
+//	any - the decoded Go data structure.
+//	error - error encountered during decoding, if any.
+//
+// Example:
+//
+//	{{ `{"name":"John", "age":30}` | mustFromJson }} // Output: map[name:John age:30], nil
+func (fh *FunctionHandler) MustFromJson(v string) (any, error) {
+	var output any
+	err := json.Unmarshal([]byte(v), &output)
+	return output, err
+}
+
+// MustToJson encodes a Go data structure into a JSON string, returning an error
+// if encoding fails.
+//
+// Parameters:
+//
+//	v any - the Go data structure to encode.
+//
+// Returns:
+//
+//	string - the JSON-encoded string.
+//	error - error encountered during encoding, if any.
+//
+// Example:
+//
+//	{{ {"name": "John", "age": 30} | mustToJson }} // Output: "{"age":30,"name":"John"}", nil
+func (fh *FunctionHandler) MustToJson(v any) (string, error) {
+	output, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// MustToPrettyJson encodes a Go data structure into a pretty-printed JSON
+// string, returning an error if encoding fails.
+//
+// Parameters:
+//
+//	v any - the Go data structure to encode.
+//
+// Returns:
+//
+//	string - the pretty-printed JSON string.
+//	error - error encountered during encoding, if any.
+//
+// Example:
+//
+//	{{ {"name": "John", "age": 30} | mustToPrettyJson }} // Output: "{\n  \"age\": 30,\n  \"name\": \"John\"\n}", nil
+func (fh *FunctionHandler) MustToPrettyJson(v any) (string, error) {
+	output, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// MustToRawJson encodes a Go data structure into a JSON string without escaping
+// HTML, returning an error if encoding fails.
+//
+// Parameters:
+//
+//	v any - the Go data structure to encode.
+//
+// Returns:
+//
+//	string - the raw JSON string.
+//	error - error encountered during encoding, if any.
+//
+// Example:
+//
+//	{{ {"content": "<div>Hello World!</div>"} | mustToRawJson }} // Output: "{\"content\":\"<div>Hello World!</div>\"}", nil
+func (fh *FunctionHandler) MustToRawJson(v any) (string, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(&v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// MustFromYaml deserializes a YAML string into a Go data structure, returning
+// the result along with any error that occurs.
+//
+// Parameters:
+//
+//	v string - the YAML string to deserialize.
+//
+// Returns:
+//
+//	any - the Go data structure representing the deserialized YAML content.
+//	error - an error if the YAML content cannot be deserialized.
+//
+// Example:
+//
+//	{{ "name: John Doe\nage: 30" | mustFromYaml }} // Output: map[name:John Doe age:30], nil
+func (fh *FunctionHandler) MustFromYAML(v string) (any, error) {
+	var output any
+	err := yaml.Unmarshal([]byte(v), &output)
+	return output, err
+}
+
+// MustToYAML serializes a Go data structure to a YAML string and returns any error that occurs during the serialization.
+//
+// Parameters:
+//
+//	v any - the data structure to serialize.
+//
+// Returns:
+//
+//	string - the YAML string representation of the data structure.
+//	error - error if the serialization fails.
+//
+// Example:
+//
+//	{{ {"name": "John Doe", "age": 30} | mustToYAML }} // Output: "name: John Doe\nage: 30\n", nil
+func (fh *FunctionHandler) MustToYAML(v any) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// MustFromTOML deserializes a TOML document into a Go map, returning an error
+// if the document is invalid. Local date-times, dates and times are decoded
+// into time.Time values that toToml writes back without an offset.
+//
+// Parameters:
+//
+//	v string - the TOML document to deserialize.
+//
+// Returns:
+//
+//	any - a map representing the TOML document.
+//	error - error if the document is malformed or defines a key twice.
+//
+// Example:
+//
+//	{{ "[[servers]]\nhost = \"alpha\"\n[[servers]]\nhost = \"beta\"" | mustFromToml }} // Output: map[servers:[map[host:alpha] map[host:beta]]], nil
+func (fh *FunctionHandler) MustFromTOML(v string) (any, error) {
+	result, err := decodeToml(v)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MustToTOML serializes a map to a TOML document, returning an error if a
+// value cannot be represented in TOML. Nil values are left out.
+//
+// Parameters:
+//
+//	v any - the map to serialize.
+//
+// Returns:
+//
+//	string - the TOML document.
+//	error - error if 'v' is not a map or contains values TOML cannot represent.
+//
+// Example:
+//
+//	{{ dict "servers" (list (dict "host" "alpha") (dict "host" "beta")) | mustToToml }} // Output: "[[servers]]\nhost = \"alpha\"\n\n[[servers]]\nhost = \"beta\"", nil
+func (fh *FunctionHandler) MustToTOML(v any) (string, error) {
+	return encodeToml(v)
+}
+
+// MustFromCsv parses CSV text into a list of rows, returning an error if the
+// text is malformed.
+//
+// Parameters:
+//
+//	str string - the CSV text to parse.
+//
+// Returns:
+//
+//	[][]string - the rows of the CSV text.
+//	error - error if the text is malformed or rows have different lengths.
+//
+// Example:
+//
+//	{{ "a,\"b\nc\"" | mustFromCsv }} // Output: [[a b\nc]], nil
+func (fh *FunctionHandler) MustFromCsv(str string) ([][]string, error) {
+	return readCsv(str, defaultCsvReadOptions)
+}
+
+// MustFromTsv parses tab-separated text into a list of rows, returning an
+// error if the text is malformed.
+//
+// Parameters:
+//
+//	str string - the TSV text to parse.
+//
+// Returns:
+//
+//	[][]string - the rows of the TSV text.
+//	error - error if the text is malformed or rows have different lengths.
+//
+// Example:
+//
+//	{{ "a\tb" | mustFromTsv }} // Output: [[a b]], nil
+func (fh *FunctionHandler) MustFromTsv(str string) ([][]string, error) {
+	options := defaultCsvReadOptions
+	options.Delimiter = "\t"
+	return readCsv(str, options)
+}
+
+// MustFromCsvWith parses CSV text with custom options, returning an error if
+// the options are invalid or the text is malformed.
+//
+// Parameters:
+//
+//	options any - the parsing options.
+//	str string - the CSV text to parse.
+//
+// Returns:
+//
+//	any - the rows, as lists of fields or as dicts.
+//	error - error if the options are invalid or the text is malformed.
+//
+// Example:
+//
+//	{{ "# users\n name , age\n Alice , 30" | mustFromCsvWith (dict "comment" "#" "trim" true) }} // Output: [[name age] [Alice 30]], nil
+func (fh *FunctionHandler) MustFromCsvWith(options any, str string) (any, error) {
+	csvOptions, err := fh.parseCsvOptions(options, defaultCsvReadOptions)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := readCsv(str, csvOptions)
+	if err != nil {
+		return nil, err
+	}
+	if !csvOptions.Header {
+		return rows, nil
+	}
+
+	dicts := []map[string]any{}
+	if len(rows) == 0 {
+		return dicts, nil
+	}
+	header := rows[0]
+	seen := make(map[string]struct{}, len(header))
+	for _, column := range header {
+		if _, ok := seen[column]; ok {
+			return nil, fmt.Errorf("duplicate CSV column: %q", column)
+		}
+		seen[column] = struct{}{}
+	}
+	for _, row := range rows[1:] {
+		dict := make(map[string]any, len(header))
+		for i, column := range header {
+			dict[column] = row[i]
+		}
+		dicts = append(dicts, dict)
+	}
+	return dicts, nil
+}
+
+// MustToCsv serializes a list of rows into CSV text, returning an error if a
+// row is neither a list nor a dict.
+//
+// Parameters:
+//
+//	rows any - the list of rows to serialize.
+//
+// Returns:
+//
+//	string - the CSV text.
+//	error - error if the rows cannot be serialized.
+//
+// Example:
+//
+//	{{ list (list 1 2) (list 3 4) | mustToCsv }} // Output: "1,2\n3,4", nil
+func (fh *FunctionHandler) MustToCsv(rows any) (string, error) {
+	return fh.writeCsv(rows, defaultCsvWriteOptions)
+}
+
+// MustToTsv serializes a list of rows into tab-separated text, returning an
+// error if a row is neither a list nor a dict.
+//
+// Parameters:
+//
+//	rows any - the list of rows to serialize.
+//
+// Returns:
+//
+//	string - the TSV text.
+//	error - error if the rows cannot be serialized.
+//
+// Example:
+//
+//	{{ list (list "a b" "c") | mustToTsv }} // Output: "a b\tc", nil
+func (fh *FunctionHandler) MustToTsv(rows any) (string, error) {
+	options := defaultCsvWriteOptions
+	options.Delimiter = "\t"
+	return fh.writeCsv(rows, options)
+}
+
+// MustToCsvWith serializes a list of rows into CSV text with custom options,
+// returning an error if the options are invalid or the rows cannot be
+// serialized.
+//
+// Parameters:
+//
+//	options any - the serialization options.
+//	rows any - the list of rows to serialize.
+//
+// Returns:
+//
+//	string - the CSV text.
+//	error - error if the options are invalid or the rows cannot be serialized.
+//
+// Example:
+//
+//	{{ list (dict "a" 1 "b" 2) | mustToCsvWith (dict "columns" (list "b" "a") "header" false) }} // Output: "2,1", nil
+func (fh *FunctionHandler) MustToCsvWith(options any, rows any) (string, error) {
+	csvOptions, err := fh.parseCsvOptions(options, defaultCsvWriteOptions)
+	if err != nil {
+		return "", err
+	}
+	return fh.writeCsv(rows, csvOptions)
+}
+
+// MustFromXML deserializes an XML document into a dict holding its root
+// element, returning an error if the document is malformed.
+//
+// Parameters:
+//
+//	v string - the XML document to deserialize.
+//
+// Returns:
+//
+//	any - a map representing the XML document.
+//	error - error if the document is malformed or has no single root element.
+//
+// Example:
+//
+//	{{ `<a x="1">text</a>` | mustFromXml }} // Output: map[a:map[#text:text @x:1]], nil
+func (fh *FunctionHandler) MustFromXML(v string) (any, error) {
+	result, err := decodeXml(v, defaultXmlReadOptions)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MustFromXMLWith deserializes an XML document with custom options, returning
+// an error if the options are invalid or the document is malformed.
+//
+// Parameters:
+//
+//	options any - the parsing options.
+//	str string - the XML document to deserialize.
+//
+// Returns:
+//
+//	any - a map representing the XML document.
+//	error - error if the options are invalid or the document is malformed.
+//
+// Example:
+//
+//	{{ "<a><b>1</b></a>" | mustFromXmlWith (dict "forceList" (list "*")) }} // Output: map[a:map[b:[1]]], nil
+func (fh *FunctionHandler) MustFromXMLWith(options any, str string) (any, error) {
+	xmlOptions, err := fh.parseXmlOptions(options, defaultXmlReadOptions)
+	if err != nil {
+		return nil, err
+	}
+	result, err := decodeXml(str, xmlOptions)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MustToXML serializes a dict holding a single root element into an XML
+// document, returning an error if the dict cannot be represented in XML.
+//
+// Parameters:
+//
+//	v any - the dict to serialize.
+//
+// Returns:
+//
+//	string - the XML document.
+//	error - error if 'v' has no single root element, its root is a list, or it holds invalid names or characters.
+//
+// Example:
+//
+//	{{ dict "note" "a < b" | mustToXml }} // Output: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<note>a &lt; b</note>", nil
+func (fh *FunctionHandler) MustToXML(v any) (string, error) {
+	return fh.encodeXml(v, defaultXmlOptions)
+}
+
+// MustToXMLWith serializes a dict into an XML document with custom options,
+// returning an error if the options are invalid or the dict cannot be
+// represented in XML.
+//
+// Parameters:
+//
+//	options any - the serialization options.
+//	v any - the dict to serialize.
+//
+// Returns:
+//
+//	string - the XML document.
+//	error - error if the options are invalid or the dict cannot be serialized.
+//
+// Example:
+//
+//	{{ dict "a" "1" | mustToXmlWith (dict "indent" "\t") }} // Output: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<a>1</a>", nil
+func (fh *FunctionHandler) MustToXMLWith(options any, v any) (string, error) {
+	xmlOptions, err := fh.parseXmlOptions(options, defaultXmlOptions)
+	if err != nil {
+		return "", err
+	}
+	return fh.encodeXml(v, xmlOptions)
+}
+
+// MustFromDotenv parses a dotenv file into a dict of variables, returning an
+// error if a line is malformed.
+//
+// Parameters:
+//
+//	str string - the dotenv content to parse.
+//
+// Returns:
+//
+//	map[string]any - the variables.
+//	error - error if a name is invalid or a quoted value is unterminated.
+//
+// Example:
+//
+//	{{ "A='literal \\n'" | mustFromDotenv }} // Output: map[A:literal \n], nil
+func (fh *FunctionHandler) MustFromDotenv(str string) (map[string]any, error) {
+	result, err := decodeDotenv(str)
+	if err != nil {
+		return map[string]any{}, err
+	}
+	return result, nil
+}
+
+// MustToDotenv serializes a dict of variables into a dotenv file, returning
+// an error if a name is invalid or a value is not a scalar.
+//
+// Parameters:
+//
+//	v any - the dict of variables to serialize.
+//
+// Returns:
+//
+//	string - the dotenv content.
+//	error - error if the variables cannot be serialized.
+//
+// Example:
+//
+//	{{ dict "A" "two words" | mustToDotenv }} // Output: "A=\"two words\"", nil
+func (fh *FunctionHandler) MustToDotenv(v any) (string, error) {
+	dict, ok := asDict(v)
+	if !ok {
+		return "", fmt.Errorf("cannot use %T as dotenv variables", v)
+	}
+
+	lines := make([]string, 0, len(dict))
+	for _, key := range sortedKeys(dict) {
+		if !dotenvKeyRegex.MatchString(key) {
+			return "", fmt.Errorf("invalid dotenv variable name: %q", key)
+		}
+		value, err := fh.scalarString(dict[key])
+		if err != nil {
+			return "", fmt.Errorf("invalid dotenv variable %q: %w", key, err)
+		}
+		lines = append(lines, key+"="+quoteDotenvValue(value))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// MustFromINI parses an INI file into a dict of sections, returning an error
+// if a line is malformed or a key or section is defined twice.
+//
+// Parameters:
+//
+//	str string - the INI content to parse.
+//
+// Returns:
+//
+//	map[string]any - the sections and their keys.
+//	error - error if the content is malformed.
+//
+// Example:
+//
+//	{{ "[a]\nx = \" padded \"" | mustFromIni }} // Output: map[a:map[x: padded ]], nil
+func (fh *FunctionHandler) MustFromINI(str string) (map[string]any, error) {
+	result, err := decodeIni(str)
+	if err != nil {
+		return map[string]any{}, err
+	}
+	return result, nil
+}
+
+// MustToINI serializes a dict into an INI file, returning an error if a
+// section holds nested dicts or lists.
+//
+// Parameters:
+//
+//	v any - the dict to serialize.
+//
+// Returns:
+//
+//	string - the INI content.
+//	error - error if the dict cannot be represented in INI.
+//
+// Example:
+//
+//	{{ dict "a" (dict "x" " padded ") | mustToIni }} // Output: "[a]\nx = \" padded \"", nil
+func (fh *FunctionHandler) MustToINI(v any) (string, error) {
+	dict, ok := asDict(v)
+	if !ok {
+		return "", fmt.Errorf("cannot use %T as INI content", v)
+	}
+
+	var builder strings.Builder
+	var sections []string
+	for _, key := range sortedKeys(dict) {
+		if _, isSection := asDict(dict[key]); isSection {
+			sections = append(sections, key)
+			continue
+		}
+		if err := fh.writeIniEntry(&builder, key, dict[key]); err != nil {
+			return "", err
+		}
+	}
+
+	for _, name := range sections {
+		if strings.ContainsAny(name, "[]\r\n") || strings.TrimSpace(name) != name || name == "" {
+			return "", fmt.Errorf("invalid INI section name: %q", name)
+		}
+		builder.WriteString("\n[" + name + "]\n")
+		section, _ := asDict(dict[name])
+		for _, key := range sortedKeys(section) {
+			if err := fh.writeIniEntry(&builder, key, section[key]); err != nil {
+				return "", fmt.Errorf("invalid INI section %q: %w", name, err)
+			}
+		}
+	}
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// MustFromProperties parses a Java properties file into a flat dict,
+// returning an error if an escape sequence is invalid.
+//
+// Parameters:
+//
+//	str string - the properties content to parse.
+//
+// Returns:
+//
+//	map[string]any - the properties.
+//	error - error if the content contains an invalid "\u" escape.
+//
+// Example:
+//
+//	{{ "fruits = apple, \\\n         banana" | mustFromProperties }} // Output: map[fruits:apple, banana], nil
+func (fh *FunctionHandler) MustFromProperties(str string) (map[string]any, error) {
+	result, err := decodeProperties(str)
+	if err != nil {
+		return map[string]any{}, err
+	}
+	return result, nil
+}
+
+// MustToProperties serializes a dict into a Java properties file, returning
+// an error if a value is a list or another non-scalar value.
+//
+// Parameters:
+//
+//	v any - the dict to serialize.
+//
+// Returns:
+//
+//	string - the properties content.
+//	error - error if the dict cannot be represented as properties.
+//
+// Example:
+//
+//	{{ dict "key with spaces" "é" | mustToProperties }} // Output: "key\\ with\\ spaces=\\u00E9", nil
+func (fh *FunctionHandler) MustToProperties(v any) (string, error) {
+	dict, ok := asDict(v)
+	if !ok {
+		return "", fmt.Errorf("cannot use %T as properties", v)
+	}
+
+	properties := map[string]string{}
+	if err := fh.flattenProperties(properties, "", dict); err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, escapeProperty(key, true)+"="+escapeProperty(properties[key], false))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Locations marking the TOML local date-times, dates and times decoded by
+// fromToml, so that toToml writes them back without an offset.
+var (
+	tomlLocalDateTime = time.FixedZone("datetime-local", 0)
+	tomlLocalDate     = time.FixedZone("date-local", 0)
+	tomlLocalTime     = time.FixedZone("time-local", 0)
+)
+
+var (
+	tomlBareKeyRegex  = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	tomlDateTimeRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(?:[Tt ](\d{2}:\d{2}:\d{2}(?:\.\d+)?)([Zz]|[+-]\d{2}:\d{2})?)?`)
+	tomlTimeRegex     = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(?:\.\d+)?`)
+	tomlIntegerRegex  = regexp.MustCompile(`^(?:0x[0-9A-Fa-f](?:_?[0-9A-Fa-f])*|0o[0-7](?:_?[0-7])*|0b[01](?:_?[01])*|[+-]?(?:0|[1-9](?:_?[0-9])*))`)
+	tomlFloatRegex    = regexp.MustCompile(`^(?:[+-]?(?:0|[1-9](?:_?[0-9])*)(?:\.[0-9](?:_?[0-9])*)?(?:[eE][+-]?[0-9](?:_?[0-9])*)?|[+-]?(?:inf|nan))`)
+)
+
+// tomlTableKind records how a table of a TOML document was defined, to reject
+// documents defining a table twice.
+type tomlTableKind int
+
+const (
+	// tomlImplicit tables are created by the headers of their sub-tables.
+	tomlImplicit tomlTableKind = iota + 1
+	// tomlExplicit tables are defined by a [table] header.
+	tomlExplicit
+	// tomlDotted tables are created by dotted keys.
+	tomlDotted
+	// tomlInline tables are defined by an inline table and cannot be extended.
+	tomlInline
+	// tomlArray arrays are defined by [[array]] headers.
+	tomlArray
+)
+
+// tomlParser decodes a TOML document.
+type tomlParser struct {
+	input []rune
+	pos   int
+	line  int
+	root  map[string]any
+	kinds map[string]tomlTableKind
+}
+
+// decodeToml decodes the TOML document 'str' into nested maps.
+func decodeToml(str string) (map[string]any, error) {
+	p := &tomlParser{
+		input: []rune(str),
+		line:  1,
+		root:  map[string]any{},
+		kinds: map[string]tomlTableKind{},
+	}
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("toml: line %d: %w", p.line, err)
+	}
+	return p.root, nil
+}
+
+func (p *tomlParser) parse() error {
+	current, currentPath := p.root, ""
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+
+		switch p.peek() {
+		case '[':
+			table, path, err := p.parseHeader()
+			if err != nil {
+				return err
+			}
+			current, currentPath = table, path
+		default:
+			if err := p.parseKeyValue(current, currentPath); err != nil {
+				return err
+			}
+		}
+
+		if err := p.expectLineEnd(); err != nil {
+			return err
+		}
+	}
+}
+
+// parseHeader parses a [table] or [[array]] header and returns the table it
+// opens.
+func (p *tomlParser) parseHeader() (map[string]any, string, error) {
+	p.pos++
+	array := p.consume('[')
+
+	p.skipSpaces()
+	keys, err := p.parseKey()
+	if err != nil {
+		return nil, "", err
+	}
+	p.skipSpaces()
+	if !p.consume(']') || (array && !p.consume(']')) {
+		return nil, "", errors.New("unterminated table header")
+	}
+
+	table, path := p.root, ""
+	for _, key := range keys[:len(keys)-1] {
+		table, path, err = p.descend(table, path, key)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	key := keys[len(keys)-1]
+	path = tomlPath(path, key)
+	existing, exists := table[key]
+
+	if array {
+		if !exists {
+			element := map[string]any{}
+			table[key] = []any{element}
+			p.kinds[path] = tomlArray
+			return element, tomlPath(path, "0"), nil
+		}
+		list, ok := existing.([]any)
+		if !ok || p.kinds[path] != tomlArray {
+			return nil, "", fmt.Errorf("key %q is already defined", key)
+		}
+		element := map[string]any{}
+		table[key] = append(list, element)
+		return element, tomlPath(path, strconv.Itoa(len(list))), nil
+	}
+
+	if !exists {
+		child := map[string]any{}
+		table[key] = child
+		p.kinds[path] = tomlExplicit
+		return child, path, nil
+	}
+	child, ok := existing.(map[string]any)
+	if !ok {
+		return nil, "", fmt.Errorf("key %q is already defined", key)
+	}
+	if p.kinds[path] != tomlImplicit {
+		return nil, "", fmt.Errorf("table %q is already defined", key)
+	}
+	p.kinds[path] = tomlExplicit
+	return child, path, nil
+}
+
+// descend returns the sub-table 'key' of 'table' on the path of a header,
+// creating it if needed. Arrays of tables lead to their last element.
+func (p *tomlParser) descend(table map[string]any, path string, key string) (map[string]any, string, error) {
+	path = tomlPath(path, key)
+	switch value := table[key].(type) {
+	case nil:
+		child := map[string]any{}
+		table[key] = child
+		p.kinds[path] = tomlImplicit
+		return child, path, nil
+	case map[string]any:
+		if p.kinds[path] == tomlInline {
+			return nil, "", fmt.Errorf("inline table %q cannot be extended", key)
+		}
+		return value, path, nil
+	case []any:
+		if p.kinds[path] == tomlArray {
+			return value[len(value)-1].(map[string]any), tomlPath(path, strconv.Itoa(len(value)-1)), nil
+		}
+	}
+	return nil, "", fmt.Errorf("key %q is already defined", key)
+}
+
+// parseKeyValue parses a key/value pair and stores it into 'table'.
+func (p *tomlParser) parseKeyValue(table map[string]any, path string) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	if !p.consume('=') {
+		return errors.New("expected '=' after key")
+	}
+	p.skipSpaces()
+
+	for _, key := range keys[:len(keys)-1] {
+		path = tomlPath(path, key)
+		switch value := table[key].(type) {
+		case nil:
+			child := map[string]any{}
+			table[key] = child
+			p.kinds[path] = tomlDotted
+			table = child
+		case map[string]any:
+			if p.kinds[path] != tomlDotted {
+				return fmt.Errorf("table %q is already defined", key)
+			}
+			table = value
+		default:
+			return fmt.Errorf("key %q is already defined", key)
+		}
+	}
+
+	key := keys[len(keys)-1]
+	if _, exists := table[key]; exists {
+		return fmt.Errorf("key %q is already defined", key)
+	}
+	value, err := p.parseValue(tomlPath(path, key))
+	if err != nil {
+		return err
+	}
+	table[key] = value
+	return nil
+}
+
+// parseKey parses a bare, quoted or dotted key.
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		var key string
+		switch p.peek() {
+		case '"':
+			value, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = value
+		case '\'':
+			value, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = value
+		default:
+			start := p.pos
+			for !p.eof() && isTomlBareKeyRune(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, fmt.Errorf("invalid key character %q", p.peek())
+			}
+			key = string(p.input[start:p.pos])
+		}
+		keys = append(keys, key)
+
+		p.skipSpaces()
+		if !p.consume('.') {
+			return keys, nil
+		}
+		p.skipSpaces()
+	}
+}
+
+// parseValue parses a value, 'path' being the location it is stored at.
+func (p *tomlParser) parseValue(path string) (any, error) {
+	if p.eof() {
+		return nil, errors.New("missing value")
+	}
+
+	switch r := p.peek(); {
+	case r == '"':
+		if p.hasPrefix(`"""`) {
+			return p.parseMultilineString('"')
+		}
+		return p.parseBasicString()
+	case r == '\'':
+		if p.hasPrefix(`'''`) {
+			return p.parseMultilineString('\'')
+		}
+		return p.parseLiteralString()
+	case r == '[':
+		return p.parseArray(path)
+	case r == '{':
+		return p.parseInlineTable(path)
+	case p.hasPrefix("true"):
+		p.pos += 4
+		return true, p.expectValueEnd()
+	case p.hasPrefix("false"):
+		p.pos += 5
+		return false, p.expectValueEnd()
+	}
+
+	rest := p.scalarToken()
+	if match := tomlDateTimeRegex.FindStringSubmatch(rest); match != nil {
+		p.pos += len([]rune(match[0]))
+		value, err := parseTomlDateTime(match[1], match[2], match[3])
+		if err != nil {
+			return nil, err
+		}
+		return value, p.expectValueEnd()
+	}
+	if match := tomlTimeRegex.FindString(rest); match != "" {
+		p.pos += len(match)
+		value, err := time.ParseInLocation("15:04:05.999999999", match, tomlLocalTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q", match)
+		}
+		return value, p.expectValueEnd()
+	}
+
+	integer := tomlIntegerRegex.FindString(rest)
+	float := tomlFloatRegex.FindString(rest)
+	if len(float) > len(integer) {
+		p.pos += len(float)
+		value, err := parseTomlFloat(float)
+		if err != nil {
+			return nil, err
+		}
+		return value, p.expectValueEnd()
+	}
+	if integer != "" {
+		p.pos += len(integer)
+		value, err := parseTomlInteger(integer)
+		if err != nil {
+			return nil, err
+		}
+		return value, p.expectValueEnd()
+	}
+	return nil, fmt.Errorf("invalid value starting with %q", p.peek())
+}
+
+// scalarToken returns the text of the date, time or number starting at the
+// current position, up to the next delimiter, so that it can be matched
+// without copying the rest of the document.
+func (p *tomlParser) scalarToken() string {
+	end := p.scanScalar(p.pos)
+	// A space may separate the date and the time of a datetime.
+	if end-p.pos == len("0000-00-00") && end+1 < len(p.input) && p.input[end] == ' ' &&
+		p.input[end+1] >= '0' && p.input[end+1] <= '9' {
+		end = p.scanScalar(end + 1)
+	}
+	return string(p.input[p.pos:end])
+}
+
+// scanScalar returns the position of the first delimiter of a scalar value
+// found from 'pos'.
+func (p *tomlParser) scanScalar(pos int) int {
+	for pos < len(p.input) && !strings.ContainsRune(" \t\r\n,]}#", p.input[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// parseArray parses an array, which may span several lines.
+func (p *tomlParser) parseArray(path string) ([]any, error) {
+	p.pos++
+	array := []any{}
+	for {
+		p.skipBlank()
+		if p.consume(']') {
+			return array, nil
+		}
+		value, err := p.parseValue(tomlPath(path, strconv.Itoa(len(array))))
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+
+		p.skipBlank()
+		if p.consume(']') {
+			return array, nil
+		}
+		if !p.consume(',') {
+			return nil, errors.New("expected ',' or ']' in array")
+		}
+	}
+}
+
+// parseInlineTable parses an inline table, which must fit on one line.
+func (p *tomlParser) parseInlineTable(path string) (map[string]any, error) {
+	p.pos++
+	table := map[string]any{}
+	p.kinds[path] = tomlInline
+
+	p.skipSpaces()
+	if p.consume('}') {
+		return table, nil
+	}
+	for {
+		p.skipSpaces()
+		if err := p.parseKeyValue(table, path); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.consume('}') {
+			return table, nil
+		}
+		if !p.consume(',') {
+			return nil, errors.New("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// parseBasicString parses a single-line string delimited by double quotes.
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++
+	var builder strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", errors.New("unterminated string")
+		}
+		r := p.input[p.pos]
+		p.pos++
+		switch {
+		case r == '"':
+			return builder.String(), nil
+		case r == '\\':
+			if err := p.parseEscape(&builder); err != nil {
+				return "", err
+			}
+		case isTomlControl(r):
+			return "", fmt.Errorf("control character %U in string", r)
+		default:
+			builder.WriteRune(r)
+		}
+	}
+}
+
+// parseLiteralString parses a single-line string delimited by single quotes.
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	start := p.pos
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", errors.New("unterminated string")
+		}
+		r := p.input[p.pos]
+		p.pos++
+		if r == '\'' {
+			return string(p.input[start : p.pos-1]), nil
+		}
+		if isTomlControl(r) {
+			return "", fmt.Errorf("control character %U in string", r)
+		}
+	}
+}
+
+// parseMultilineString parses a string delimited by three 'quote' runes. A
+// newline right after the opening delimiter is trimmed, and basic strings
+// support line ending backslashes.
+func (p *tomlParser) parseMultilineString(quote rune) (string, error) {
+	p.pos += 3
+	if p.consume('\n') || p.hasPrefix("\r\n") && p.consume('\r') && p.consume('\n') {
+		p.line++
+	}
+
+	var builder strings.Builder
+	for {
+		if p.eof() {
+			return "", errors.New("unterminated multi-line string")
+		}
+		r := p.input[p.pos]
+		switch {
+		case r == quote && p.hasPrefix(strings.Repeat(string(quote), 3)):
+			count := 3
+			for count < 5 && p.pos+count < len(p.input) && p.input[p.pos+count] == quote {
+				count++
+			}
+			builder.WriteString(strings.Repeat(string(quote), count-3))
+			p.pos += count
+			return builder.String(), nil
+		case r == '\\' && quote == '"':
+			p.pos++
+			if p.trimLineEnding() {
+				continue
+			}
+			if err := p.parseEscape(&builder); err != nil {
+				return "", err
+			}
+		case r == '\n':
+			p.line++
+			p.pos++
+			builder.WriteRune(r)
+		case r == '\r' && p.hasPrefix("\r\n"):
+			p.pos++
+		case isTomlControl(r):
+			return "", fmt.Errorf("control character %U in string", r)
+		default:
+			p.pos++
+			builder.WriteRune(r)
+		}
+	}
+}
+
+// trimLineEnding skips the whitespace and newlines following a line ending
+// backslash, reporting whether the backslash ended the line.
+func (p *tomlParser) trimLineEnding() bool {
+	pos := p.pos
+	for pos < len(p.input) && (p.input[pos] == ' ' || p.input[pos] == '\t') {
+		pos++
+	}
+	if pos < len(p.input) && p.input[pos] == '\r' {
+		pos++
+	}
+	if pos >= len(p.input) || p.input[pos] != '\n' {
+		return false
+	}
+
+	p.pos = pos
+	for !p.eof() {
+		switch p.peek() {
+		case '\n':
+			p.line++
+		case ' ', '\t', '\r':
+		default:
+			return true
+		}
+		p.pos++
+	}
+	return true
+}
+
+// parseEscape decodes the escape sequence following a backslash.
+func (p *tomlParser) parseEscape(builder *strings.Builder) error {
+	if p.eof() {
+		return errors.New("unterminated escape sequence")
+	}
+	r := p.input[p.pos]
+	p.pos++
+
+	switch r {
+	case 'b':
+		builder.WriteByte('\b')
+	case 't':
+		builder.WriteByte('\t')
+	case 'n':
+		builder.WriteByte('\n')
+	case 'f':
+		builder.WriteByte('\f')
+	case 'r':
+		builder.WriteByte('\r')
+	case '"':
+		builder.WriteByte('"')
+	case '\\':
+		builder.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if r == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.input) {
+			return errors.New("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(p.input[p.pos:p.pos+size]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return errors.New("invalid unicode escape")
+		}
+		p.pos += size
+		builder.WriteRune(rune(code))
+	default:
+		return fmt.Errorf("invalid escape sequence \\%c", r)
+	}
+	return nil
+}
+
+// expectValueEnd checks that a scalar value is followed by a delimiter.
+func (p *tomlParser) expectValueEnd() error {
+	if p.eof() {
+		return nil
+	}
+	switch p.peek() {
+	case ' ', '\t', '\r', '\n', ',', ']', '}', '#':
+		return nil
+	}
+	return fmt.Errorf("unexpected character %q after value", p.peek())
+}
+
+// expectLineEnd checks that nothing but a comment follows a key/value pair or
+// a header on its line.
+func (p *tomlParser) expectLineEnd() error {
+	p.skipSpaces()
+	p.skipComment()
+	if p.eof() || p.consume('\n') || p.hasPrefix("\r\n") && p.consume('\r') && p.consume('\n') {
+		p.line++
+		return nil
+	}
+	return fmt.Errorf("unexpected character %q at end of line", p.peek())
+}
+
+// skipBlank skips whitespace, newlines and comments.
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.line++
+			p.pos++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+// skipSpaces skips spaces and tabs.
+func (p *tomlParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment skips a comment up to the end of its line.
+func (p *tomlParser) skipComment() {
+	if p.eof() || p.peek() != '#' {
+		return
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *tomlParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *tomlParser) consume(r rune) bool {
+	if p.peek() == r && !p.eof() {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) hasPrefix(prefix string) bool {
+	pos := p.pos
+	for _, r := range prefix {
+		if pos >= len(p.input) || p.input[pos] != r {
+			return false
+		}
+		pos++
+	}
+	return true
+}
+
+// tomlPath appends 'key' to the path of a table.
+func tomlPath(path string, key string) string {
+	return path + "\x00" + key
+}
+
+func isTomlBareKeyRune(r rune) bool {
+	return r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+}
+
+func isTomlControl(r rune) bool {
+	return r < 0x20 && r != '\t' || r == 0x7f
+}
+
+// parseTomlInteger parses a decimal, hexadecimal, octal or binary integer.
+func parseTomlInteger(str string) (int64, error) {
+	digits := strings.ReplaceAll(str, "_", "")
+	base := 10
+	if len(digits) > 2 && digits[0] == '0' {
+		switch digits[1] {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+		if base != 10 {
+			digits = digits[2:]
+		}
+	}
+
+	value, err := strconv.ParseInt(digits, base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer %q", str)
+	}
+	return value, nil
+}
+
+// parseTomlFloat parses a float, including inf and nan.
+func parseTomlFloat(str string) (float64, error) {
+	switch strings.TrimLeft(str, "+-") {
+	case "inf":
+		if strings.HasPrefix(str, "-") {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(str, "_", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid float %q", str)
+	}
+	return value, nil
+}
+
+// parseTomlDateTime parses an offset date-time, a local date-time or a local
+// date from its date, time and offset parts.
+func parseTomlDateTime(date, clock, offset string) (time.Time, error) {
+	var value time.Time
+	var err error
+	switch {
+	case clock == "":
+		value, err = time.ParseInLocation(time.DateOnly, date, tomlLocalDate)
+	case offset == "":
+		value, err = time.ParseInLocation("2006-01-02T15:04:05.999999999", date+"T"+clock, tomlLocalDateTime)
+	default:
+		value, err = time.Parse(time.RFC3339Nano, date+"T"+clock+strings.ToUpper(offset))
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date-time %q", strings.TrimSpace(date+" "+clock+offset))
+	}
+	return value, nil
+}
+
+// tomlEncoder encodes nested maps into a TOML document.
+type tomlEncoder struct {
+	builder strings.Builder
+}
+
+// encodeToml encodes 'v', which must be a map with string keys, as a TOML
+// document.
+func encodeToml(v any) (string, error) {
+	table, ok := tomlTable(reflect.ValueOf(v))
+	if !ok {
+		return "", fmt.Errorf("toml: cannot encode %T as a document, a map is required", v)
+	}
+
+	encoder := &tomlEncoder{}
+	if err := encoder.encodeTable(nil, table, false); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(encoder.builder.String()), nil
+}
+
+// encodeTable writes the key/value pairs of a table then its sub-tables and
+// arrays of tables. The header of the table is written when it has key/value
+// pairs, no sub-tables, or when 'force' is set for array elements.
+func (e *tomlEncoder) encodeTable(path []string, table reflect.Value, force bool) error {
+	keys := tomlSortedKeys(table)
+
+	var values, tables, arrays []string
+	for _, key := range keys {
+		value := tomlIndirect(table.MapIndex(reflect.ValueOf(key).Convert(table.Type().Key())))
+		switch {
+		case !value.IsValid():
+			continue
+		case tomlIsTable(value):
+			tables = append(tables, key)
+		case tomlIsArrayOfTables(value):
+			arrays = append(arrays, key)
+		default:
+			values = append(values, key)
+		}
+	}
+
+	if len(path) > 0 && !force && (len(values) > 0 || len(tables)+len(arrays) == 0) {
+		e.builder.WriteString("\n[" + tomlKeyPath(path) + "]\n")
+	}
+	for _, key := range values {
+		value := tomlIndirect(table.MapIndex(reflect.ValueOf(key).Convert(table.Type().Key())))
+		e.builder.WriteString(tomlKey(key) + " = ")
+		if err := e.encodeValue(append(path, key), value); err != nil {
+			return err
+		}
+		e.builder.WriteByte('\n')
+	}
+	for _, key := range tables {
+		value := tomlIndirect(table.MapIndex(reflect.ValueOf(key).Convert(table.Type().Key())))
+		if err := e.encodeTable(append(path[:len(path):len(path)], key), value, false); err != nil {
+			return err
+		}
+	}
+	for _, key := range arrays {
+		value := tomlIndirect(table.MapIndex(reflect.ValueOf(key).Convert(table.Type().Key())))
+		elementPath := append(path[:len(path):len(path)], key)
+		for i := 0; i < value.Len(); i++ {
+			e.builder.WriteString("\n[[" + tomlKeyPath(elementPath) + "]]\n")
+			if err := e.encodeTable(elementPath, tomlIndirect(value.Index(i)), true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encodeValue writes a value on a single line, using inline tables for the
+// maps nested in arrays.
+func (e *tomlEncoder) encodeValue(path []string, value reflect.Value) error {
+	if t, ok := value.Interface().(time.Time); ok {
+		e.builder.WriteString(formatTomlTime(t))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		e.builder.WriteString(quoteTomlString(value.String()))
+	case reflect.Bool:
+		e.builder.WriteString(strconv.FormatBool(value.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.builder.WriteString(strconv.FormatInt(value.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if value.Uint() > math.MaxInt64 {
+			return fmt.Errorf("toml: integer %d at %q overflows int64", value.Uint(), tomlKeyPath(path))
+		}
+		e.builder.WriteString(strconv.FormatUint(value.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		e.builder.WriteString(formatTomlFloat(value.Float()))
+	case reflect.Slice, reflect.Array:
+		e.builder.WriteByte('[')
+		for i := 0; i < value.Len(); i++ {
+			if i > 0 {
+				e.builder.WriteString(", ")
+			}
+			element := tomlIndirect(value.Index(i))
+			if !element.IsValid() {
+				return fmt.Errorf("toml: cannot encode nil in array %q", tomlKeyPath(path))
+			}
+			if err := e.encodeValue(append(path, strconv.Itoa(i)), element); err != nil {
+				return err
+			}
+		}
+		e.builder.WriteByte(']')
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("toml: cannot encode %s at %q, keys must be strings", value.Type(), tomlKeyPath(path))
+		}
+		e.builder.WriteByte('{')
+		written := 0
+		for _, key := range tomlSortedKeys(value) {
+			element := tomlIndirect(value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key())))
+			if !element.IsValid() {
+				continue
+			}
+			if written > 0 {
+				e.builder.WriteByte(',')
+			}
+			e.builder.WriteString(" " + tomlKey(key) + " = ")
+			if err := e.encodeValue(append(path, key), element); err != nil {
+				return err
+			}
+			written++
+		}
+		if written > 0 {
+			e.builder.WriteByte(' ')
+		}
+		e.builder.WriteByte('}')
+	default:
+		return fmt.Errorf("toml: cannot encode %s at %q", value.Type(), tomlKeyPath(path))
+	}
+	return nil
+}
+
+// tomlIndirect unwraps interfaces and pointers, returning an invalid value
+// for nil.
+func tomlIndirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// tomlTable returns 'value' if it is a map with string keys.
+func tomlTable(value reflect.Value) (reflect.Value, bool) {
+	value = tomlIndirect(value)
+	return value, tomlIsTable(value)
+}
+
+func tomlIsTable(value reflect.Value) bool {
+	return value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String
+}
+
+// tomlIsArrayOfTables reports whether 'value' is a non-empty list of maps,
+// written as an array of tables.
+func tomlIsArrayOfTables(value reflect.Value) bool {
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array || value.Len() == 0 {
+		return false
+	}
+	for i := 0; i < value.Len(); i++ {
+		if !tomlIsTable(tomlIndirect(value.Index(i))) {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlSortedKeys(table reflect.Value) []string {
+	keys := make([]string, 0, table.Len())
+	for _, key := range table.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// tomlKey writes a key bare when possible, quoted otherwise.
+func tomlKey(key string) string {
+	if tomlBareKeyRegex.MatchString(key) {
+		return key
+	}
+	return quoteTomlString(key)
+}
+
+func tomlKeyPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+	return strings.Join(keys, ".")
+}
+
+// quoteTomlString writes a basic string, escaping quotes, backslashes and
+// control characters.
+func quoteTomlString(str string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for _, r := range str {
+		switch r {
+		case '"':
+			builder.WriteString(`\"`)
+		case '\\':
+			builder.WriteString(`\\`)
+		case '\b':
+			builder.WriteString(`\b`)
+		case '\t':
+			builder.WriteString(`\t`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\f':
+			builder.WriteString(`\f`)
+		case '\r':
+			builder.WriteString(`\r`)
+		default:
+			if isTomlControl(r) {
+				fmt.Fprintf(&builder, `\u%04X`, r)
+			} else {
+				builder.WriteRune(r)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// formatTomlFloat writes a float so that it is read back as a float.
+func formatTomlFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	str := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".e") {
+		str += ".0"
+	}
+	return str
+}
+
+// formatTomlTime writes a time as a local time, local date or local
+// date-time when decoded as such, and as an offset date-time otherwise.
+func formatTomlTime(t time.Time) string {
+	switch t.Location() {
+	case tomlLocalDate:
+		return t.Format(time.DateOnly)
+	case tomlLocalTime:
+		return t.Format("15:04:05.999999999")
+	case tomlLocalDateTime:
+		return t.Format("2006-01-02T15:04:05.999999999")
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// CsvOptions configures the parsing and serialization of CSV text.
+type CsvOptions struct {
+	Delimiter  string   // Single character separating the fields.
+	Comment    string   // Single character starting comment lines, none if empty.
+	LazyQuotes bool     // Whether to accept quotes in unquoted fields and non-doubled quotes in quoted fields.
+	Trim       bool     // Whether to trim the whitespace around fields when parsing.
+	Header     bool     // Whether the first row names the columns.
+	Columns    []string // Dict keys written as columns and their order, all the keys if empty.
+}
+
+// defaultCsvReadOptions are the options used by the fromCsv function, and the
+// base options overridden by the dict given to fromCsvWith.
+var defaultCsvReadOptions = CsvOptions{Delimiter: ","}
+
+// defaultCsvWriteOptions are the options used by the toCsv function, and the
+// base options overridden by the dict given to toCsvWith.
+var defaultCsvWriteOptions = CsvOptions{Delimiter: ",", Header: true}
+
+// parseCsvOptions reads CSV options from a CsvOptions or from a dict
+// overriding 'base'.
+func (fh *FunctionHandler) parseCsvOptions(options any, base CsvOptions) (CsvOptions, error) {
+	switch o := options.(type) {
+	case CsvOptions:
+		return o, nil
+	case map[string]any:
+		csvOptions := base
+		for key, value := range o {
+			switch key {
+			case "delimiter":
+				csvOptions.Delimiter = fh.ToString(value)
+			case "comment":
+				csvOptions.Comment = fh.ToString(value)
+			case "lazyQuotes":
+				lazyQuotes, err := cast.ToBoolE(value)
+				if err != nil {
+					return CsvOptions{}, fmt.Errorf("invalid CSV lazyQuotes: %w", err)
+				}
+				csvOptions.LazyQuotes = lazyQuotes
+			case "trim":
+				trim, err := cast.ToBoolE(value)
+				if err != nil {
+					return CsvOptions{}, fmt.Errorf("invalid CSV trim: %w", err)
+				}
+				csvOptions.Trim = trim
+			case "header":
+				header, err := cast.ToBoolE(value)
+				if err != nil {
+					return CsvOptions{}, fmt.Errorf("invalid CSV header: %w", err)
+				}
+				csvOptions.Header = header
+			case "columns":
+				columns, err := cast.ToStringSliceE(value)
+				if err != nil {
+					return CsvOptions{}, fmt.Errorf("invalid CSV columns: %w", err)
+				}
+				csvOptions.Columns = columns
+			default:
+				return CsvOptions{}, fmt.Errorf("unknown CSV option: %s", key)
+			}
+		}
+		return csvOptions, nil
+	default:
+		return CsvOptions{}, fmt.Errorf("cannot use %T as CSV options", options)
+	}
+}
+
+// csvRune returns the single character of a delimiter or comment option.
+func csvRune(name string, value string) (rune, error) {
+	r, size := utf8.DecodeRuneInString(value)
+	if size == 0 || size != len(value) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("invalid CSV %s: %q", name, value)
+	}
+	return r, nil
+}
+
+// readCsv parses CSV text into a list of rows.
+func readCsv(str string, options CsvOptions) ([][]string, error) {
+	reader := csv.NewReader(strings.NewReader(str))
+	delimiter, err := csvRune("delimiter", options.Delimiter)
+	if err != nil {
+		return [][]string{}, err
+	}
+	reader.Comma = delimiter
+	if options.Comment != "" {
+		comment, err := csvRune("comment", options.Comment)
+		if err != nil {
+			return [][]string{}, err
+		}
+		reader.Comment = comment
+	}
+	reader.LazyQuotes = options.LazyQuotes
+	reader.TrimLeadingSpace = options.Trim
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return [][]string{}, err
+	}
+	if rows == nil {
+		rows = [][]string{}
+	}
+	if options.Trim {
+		for _, row := range rows {
+			for i, field := range row {
+				row[i] = strings.TrimSpace(field)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// writeCsv serializes a list of rows, all lists or all dicts, into CSV text.
+func (fh *FunctionHandler) writeCsv(rows any, options CsvOptions) (string, error) {
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return "", fmt.Errorf("cannot use %T as CSV rows", rows)
+	}
+	delimiter, err := csvRune("delimiter", options.Delimiter)
+	if err != nil {
+		return "", err
+	}
+
+	records := make([][]string, 0, value.Len()+1)
+	dicts := make([]map[string]any, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		switch row := value.Index(i).Interface().(type) {
+		case map[string]any:
+			dicts = append(dicts, row)
+		case []string:
+			records = append(records, row)
+		default:
+			fields := reflect.ValueOf(row)
+			if fields.Kind() != reflect.Slice && fields.Kind() != reflect.Array {
+				return "", fmt.Errorf("cannot use %T as a CSV row", row)
+			}
+			record := make([]string, fields.Len())
+			for j := range record {
+				if field := fields.Index(j).Interface(); field != nil {
+					record[j] = fh.ToString(field)
+				}
+			}
+			records = append(records, record)
+		}
+	}
+	if len(dicts) > 0 && len(records) > 0 {
+		return "", errors.New("CSV rows must be all lists or all dicts")
+	}
+
+	columns := options.Columns
+	if len(dicts) > 0 && len(columns) == 0 {
+		columns = fh.csvColumns(dicts)
+	}
+	if len(dicts) > 0 {
+		for _, dict := range dicts {
+			record := make([]string, len(columns))
+			for j, column := range columns {
+				if field, ok := dict[column]; ok && field != nil {
+					record[j] = fh.ToString(field)
+				}
+			}
+			records = append(records, record)
+		}
+	}
+	if options.Header && len(columns) > 0 {
+		records = append([][]string{columns}, records...)
+	}
+
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+	writer.Comma = delimiter
+	if err := writer.WriteAll(records); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
+
+// csvColumns returns the keys of all the dicts in alphabetical order.
+func (fh *FunctionHandler) csvColumns(dicts []map[string]any) []string {
+	seen := map[string]struct{}{}
+	columns := []string{}
+	for _, dict := range dicts {
+		for key := range dict {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				columns = append(columns, key)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// xmlAttributePrefix marks the dict keys holding the attributes of an
+// element, and xmlTextKey the key holding its text.
+const (
+	xmlAttributePrefix = "@"
+	xmlTextKey         = "#text"
+	xmlDeclaration     = `<?xml version="1.0" encoding="UTF-8"?>`
+)
+
+var xmlNameRegex = regexp.MustCompile(`^[\p{L}_:][\p{L}\p{N}\p{Mn}._:\-\x{B7}]*$`)
+
+// XmlOptions configures the parsing and serialization of XML documents.
+type XmlOptions struct {
+	Indent      string   // String indenting nested elements, elements are written on one line if empty.
+	Declaration bool     // Whether to start the document with an XML declaration.
+	Root        string   // Name of an element wrapping the serialized dict, none if empty.
+	ForceList   []string // Names of the child elements always parsed as lists, "*" for every element.
+}
+
+// defaultXmlReadOptions are the options used by the fromXml function, and the
+// base options overridden by the dict given to fromXmlWith.
+var defaultXmlReadOptions = XmlOptions{}
+
+// defaultXmlOptions are the options used by the toXml function, and the base
+// options overridden by the dict given to toXmlWith.
+var defaultXmlOptions = XmlOptions{Indent: "  ", Declaration: true}
+
+// parseXmlOptions reads XML options from an XmlOptions or from a dict
+// overriding 'base'.
+func (fh *FunctionHandler) parseXmlOptions(options any, base XmlOptions) (XmlOptions, error) {
+	switch o := options.(type) {
+	case XmlOptions:
+		return o, nil
+	case map[string]any:
+		xmlOptions := base
+		for key, value := range o {
+			switch key {
+			case "indent":
+				xmlOptions.Indent = fh.ToString(value)
+			case "declaration":
+				declaration, err := cast.ToBoolE(value)
+				if err != nil {
+					return XmlOptions{}, fmt.Errorf("invalid XML declaration: %w", err)
+				}
+				xmlOptions.Declaration = declaration
+			case "root":
+				xmlOptions.Root = fh.ToString(value)
+			case "forceList":
+				forceList, err := cast.ToStringSliceE(value)
+				if err != nil {
+					return XmlOptions{}, fmt.Errorf("invalid XML forceList: %w", err)
+				}
+				xmlOptions.ForceList = forceList
+			default:
+				return XmlOptions{}, fmt.Errorf("unknown XML option: %s", key)
+			}
+		}
+		return xmlOptions, nil
+	default:
+		return XmlOptions{}, fmt.Errorf("cannot use %T as XML options", options)
+	}
+}
+
+// xmlElement is an element being decoded.
+type xmlElement struct {
+	name  string
+	value map[string]any
+	text  strings.Builder
+}
+
+// decodeXml decodes an XML document into a dict holding its root element.
+func decodeXml(str string, options XmlOptions) (map[string]any, error) {
+	decoder := xml.NewDecoder(strings.NewReader(str))
+	var stack []*xmlElement
+	var root map[string]any
+
+	forceList := func(name string) bool {
+		return slices.Contains(options.ForceList, name) || slices.Contains(options.ForceList, "*")
+	}
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && root != nil {
+				return nil, errors.New("XML document has several root elements")
+			}
+			element := &xmlElement{name: xmlName(t.Name), value: map[string]any{}}
+			for _, attr := range t.Attr {
+				element.value[xmlAttributePrefix+xmlName(attr.Name)] = attr.Value
+			}
+			stack = append(stack, element)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].name != xmlName(t.Name) {
+				return nil, fmt.Errorf("unexpected end element </%s>", xmlName(t.Name))
+			}
+			element := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			var value any = element.value
+			text := strings.TrimSpace(element.text.String())
+			if len(element.value) == 0 {
+				value = text
+			} else if text != "" {
+				element.value[xmlTextKey] = text
+			}
+
+			if len(stack) == 0 {
+				root = map[string]any{element.name: value}
+				continue
+			}
+			parent := stack[len(stack)-1]
+			switch existing := parent.value[element.name].(type) {
+			case nil:
+				if forceList(element.name) {
+					value = []any{value}
+				}
+				parent.value[element.name] = value
+			case []any:
+				parent.value[element.name] = append(existing, value)
+			default:
+				parent.value[element.name] = []any{existing, value}
+			}
+		case xml.CharData:
+			if len(stack) == 0 {
+				if len(bytes.TrimSpace(t)) > 0 {
+					return nil, errors.New("XML text outside of the root element")
+				}
+				continue
+			}
+			stack[len(stack)-1].text.Write(t)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unclosed element <%s>", stack[len(stack)-1].name)
+	}
+	if root == nil {
+		return nil, errors.New("XML document has no root element")
+	}
+	return root, nil
+}
+
+// xmlName writes a name with its namespace prefix.
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// encodeXml serializes a dict holding a single root element into an XML
+// document.
+func (fh *FunctionHandler) encodeXml(v any, options XmlOptions) (string, error) {
+	if options.Root != "" {
+		v = map[string]any{options.Root: v}
+	}
+	dict, ok := asDict(v)
+	if !ok || len(dict) != 1 {
+		return "", fmt.Errorf("cannot use %T as XML document, a dict with a single root element is required", v)
+	}
+
+	var builder strings.Builder
+	if options.Declaration {
+		builder.WriteString(xmlDeclaration)
+	}
+	for name, value := range dict {
+		// A list would give zero or several root elements.
+		if isXmlList(value) {
+			return "", fmt.Errorf("cannot use %T as XML root element %q, a document has a single root element", value, name)
+		}
+		if err := fh.encodeXmlElement(&builder, name, value, options.Indent, 0); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimPrefix(builder.String(), "\n"), nil
+}
+
+// isXmlList reports whether a value is encoded as repeated elements, byte
+// slices being encoded as text.
+func isXmlList(value any) bool {
+	list := reflect.ValueOf(value)
+	return list.Kind() == reflect.Slice && list.Type().Elem().Kind() != reflect.Uint8 || list.Kind() == reflect.Array
+}
+
+// encodeXmlElement writes the element 'name' holding 'value', a dict, a
+// scalar, or a list of repeated elements, at the nesting 'depth'.
+func (fh *FunctionHandler) encodeXmlElement(builder *strings.Builder, name string, value any, indent string, depth int) error {
+	if !xmlNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid XML element name: %q", name)
+	}
+
+	if isXmlList(value) {
+		list := reflect.ValueOf(value)
+		for i := 0; i < list.Len(); i++ {
+			if err := fh.encodeXmlElement(builder, name, list.Index(i).Interface(), indent, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	prefix := ""
+	if indent != "" {
+		prefix = "\n" + strings.Repeat(indent, depth)
+	}
+	builder.WriteString(prefix + "<" + name)
+
+	dict, isDict := asDict(value)
+	if !isDict {
+		text := ""
+		if value != nil {
+			text = fh.ToString(value)
+		}
+		if text == "" {
+			builder.WriteString("/>")
+			return nil
+		}
+		builder.WriteString(">")
+		if err := writeXmlText(builder, text); err != nil {
+			return fmt.Errorf("invalid text in XML element %q: %w", name, err)
+		}
+		builder.WriteString("</" + name + ">")
+		return nil
+	}
+
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var children []string
+	for _, key := range keys {
+		if !strings.HasPrefix(key, xmlAttributePrefix) {
+			if key != xmlTextKey {
+				children = append(children, key)
+			}
+			continue
+		}
+		attribute := strings.TrimPrefix(key, xmlAttributePrefix)
+		if !xmlNameRegex.MatchString(attribute) {
+			return fmt.Errorf("invalid XML attribute name: %q", attribute)
+		}
+		attrValue := fh.ToString(dict[key])
+		if err := checkXmlChars(attrValue); err != nil {
+			return fmt.Errorf("invalid value of XML attribute %q: %w", attribute, err)
+		}
+		builder.WriteString(" " + attribute + `="`)
+		_ = xml.EscapeText(builder, []byte(attrValue))
+		builder.WriteString(`"`)
+	}
+
+	text := ""
+	if value, ok := dict[xmlTextKey]; ok && value != nil {
+		text = fh.ToString(value)
+	}
+	if text == "" && len(children) == 0 {
+		builder.WriteString("/>")
+		return nil
+	}
+
+	builder.WriteString(">")
+	if err := writeXmlText(builder, text); err != nil {
+		return fmt.Errorf("invalid text in XML element %q: %w", name, err)
+	}
+	for _, child := range children {
+		if err := fh.encodeXmlElement(builder, child, dict[child], indent, depth+1); err != nil {
+			return err
+		}
+	}
+	if len(children) > 0 {
+		builder.WriteString(prefix)
+	}
+	builder.WriteString("</" + name + ">")
+	return nil
+}
+
+// asDict converts maps with string keys to a dict.
+func asDict(value any) (map[string]any, bool) {
+	if dict, ok := value.(map[string]any); ok {
+		return dict, true
+	}
+	mapValue := reflect.ValueOf(value)
+	if mapValue.Kind() != reflect.Map || mapValue.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	dict := make(map[string]any, mapValue.Len())
+	for _, key := range mapValue.MapKeys() {
+		dict[key.String()] = mapValue.MapIndex(key).Interface()
+	}
+	return dict, true
+}
+
+// writeXmlText writes escaped text, keeping newlines and tabs readable.
+func writeXmlText(builder *strings.Builder, text string) error {
+	if err := checkXmlChars(text); err != nil {
+		return err
+	}
+	for _, r := range text {
+		switch r {
+		case '&':
+			builder.WriteString("&amp;")
+		case '<':
+			builder.WriteString("&lt;")
+		case '>':
+			builder.WriteString("&gt;")
+		case '\r':
+			builder.WriteString("&#xD;")
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return nil
+}
+
+// checkXmlChars reports characters that cannot appear in an XML document.
+func checkXmlChars(str string) error {
+	if !utf8.ValidString(str) {
+		return errors.New("invalid UTF-8")
+	}
+	for _, r := range str {
+		valid := r == '\t' || r == '\n' || r == '\r' ||
+			r >= 0x20 && r <= 0xD7FF || r >= 0xE000 && r <= 0xFFFD || r >= 0x10000 && r <= 0x10FFFF
+		if !valid {
+			return fmt.Errorf("character %U is not allowed in XML", r)
+		}
+	}
+	return nil
+}
+
+var (
+	dotenvKeyRegex       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	dotenvBareValueRegex = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,-]*$`)
+)
+
+// sortedKeys returns the keys of a dict in alphabetical order.
+func sortedKeys(dict map[string]any) []string {
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// scalarString converts a scalar value to a string, nil being empty, and
+// rejects dicts and lists.
+func (fh *FunctionHandler) scalarString(value any) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		if _, isBytes := value.([]byte); !isBytes {
+			return "", fmt.Errorf("cannot use %T as a value", value)
+		}
+	}
+	return fh.ToString(value), nil
+}
+
+// decodeDotenv parses the variables of a dotenv file.
+func decodeDotenv(str string) (map[string]any, error) {
+	result := map[string]any{}
+	lines := strings.Split(strings.ReplaceAll(str, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		number := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			line = strings.TrimSpace(rest)
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found {
+			return nil, fmt.Errorf("dotenv: line %d: missing '=' after %q", number, key)
+		}
+		if !dotenvKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("dotenv: line %d: invalid variable name %q", number, key)
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			if index := strings.Index(value, " #"); index >= 0 {
+				value = value[:index]
+			} else if index := strings.Index(value, "\t#"); index >= 0 {
+				value = value[:index]
+			}
+			result[key] = strings.TrimSpace(value)
+			continue
+		}
+
+		// Quoted values may span several lines, up to the closing quote.
+		quote := value[0]
+		content := value[1:]
+		for {
+			end := dotenvClosingQuote(content, quote)
+			if end >= 0 {
+				rest := strings.TrimSpace(content[end+1:])
+				if rest != "" && !strings.HasPrefix(rest, "#") {
+					return nil, fmt.Errorf("dotenv: line %d: unexpected characters after quoted value", i+1)
+				}
+				content = content[:end]
+				break
+			}
+			if i+1 >= len(lines) {
+				return nil, fmt.Errorf("dotenv: line %d: unterminated quoted value", number)
+			}
+			i++
+			content += "\n" + lines[i]
+		}
+
+		if quote == '"' {
+			content = unescapeDotenv(content)
+		}
+		result[key] = content
+	}
+	return result, nil
+}
+
+// dotenvClosingQuote returns the index of the quote closing a value, skipping
+// escaped double quotes, or -1.
+func dotenvClosingQuote(content string, quote byte) int {
+	for i := 0; i < len(content); i++ {
+		switch {
+		case content[i] == '\\' && quote == '"':
+			i++
+		case content[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeDotenv decodes the escape sequences of a double-quoted value.
+func unescapeDotenv(str string) string {
+	var builder strings.Builder
+	for i := 0; i < len(str); i++ {
+		if str[i] != '\\' || i+1 == len(str) {
+			builder.WriteByte(str[i])
+			continue
+		}
+		i++
+		switch str[i] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 't':
+			builder.WriteByte('\t')
+		case '"', '\\', '$', '\'', '`':
+			builder.WriteByte(str[i])
+		default:
+			builder.WriteByte('\\')
+			builder.WriteByte(str[i])
+		}
+	}
+	return builder.String()
+}
+
+// quoteDotenvValue writes a value bare when possible, and double-quoted with
+// escapes otherwise, so that shells and dotenv parsers do not expand it.
+func quoteDotenvValue(value string) string {
+	if dotenvBareValueRegex.MatchString(value) {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// decodeIni parses the sections and keys of an INI file.
+func decodeIni(str string) (map[string]any, error) {
+	result := map[string]any{}
+	section := result
+	sectionName := ""
+
+	for i, line := range strings.Split(strings.ReplaceAll(str, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("ini: line %d: unterminated section header", i+1)
+			}
+			sectionName = strings.TrimSpace(line[1 : len(line)-1])
+			if sectionName == "" {
+				return nil, fmt.Errorf("ini: line %d: empty section name", i+1)
+			}
+			if _, exists := result[sectionName]; exists {
+				return nil, fmt.Errorf("ini: line %d: section %q is already defined", i+1, sectionName)
+			}
+			section = map[string]any{}
+			result[sectionName] = section
+			continue
+		}
+
+		separator := strings.IndexAny(line, "=:")
+		if separator <= 0 {
+			return nil, fmt.Errorf("ini: line %d: expected key = value", i+1)
+		}
+		key := strings.TrimSpace(line[:separator])
+		if _, exists := section[key]; exists {
+			if sectionName == "" {
+				return nil, fmt.Errorf("ini: line %d: key %q is already defined", i+1, key)
+			}
+			return nil, fmt.Errorf("ini: line %d: key %q is already defined in section %q", i+1, key, sectionName)
+		}
+
+		value := strings.TrimSpace(line[separator+1:])
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("ini: line %d: invalid quoted value %s", i+1, value)
+			}
+			value = unquoted
+		}
+		section[key] = value
+	}
+	return result, nil
+}
+
+// writeIniEntry writes a key and its value, quoted when it would not be read
+// back as is.
+func (fh *FunctionHandler) writeIniEntry(builder *strings.Builder, key string, value any) error {
+	if key == "" || strings.ContainsAny(key, "=:[]\r\n") || strings.TrimSpace(key) != key || key[0] == ';' || key[0] == '#' {
+		return fmt.Errorf("invalid INI key: %q", key)
+	}
+	str, err := fh.scalarString(value)
+	if err != nil {
+		return fmt.Errorf("invalid INI key %q: %w", key, err)
+	}
+
+	if strings.TrimSpace(str) != str || strings.ContainsAny(str, "\"\\\r\n\t") {
+		str = strconv.Quote(str)
+	}
+	builder.WriteString(key + " = " + str + "\n")
+	return nil
+}
+
+// decodeProperties parses a Java properties file.
+func decodeProperties(str string) (map[string]any, error) {
+	result := map[string]any{}
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(str, "\r\n", "\n"), "\r", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		number := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// A line ending with an odd number of backslashes continues on the next
+		// line, whose leading whitespace is ignored.
+		for propertiesContinues(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if propertiesContinues(line) {
+			line = line[:len(line)-1]
+		}
+
+		keyEnd := len(line)
+		for j := 0; j < len(line); j++ {
+			if line[j] == '\\' {
+				j++
+				continue
+			}
+			if strings.IndexByte("=: \t\f", line[j]) >= 0 {
+				keyEnd = j
+				break
+			}
+		}
+
+		rest := strings.TrimLeft(line[keyEnd:], " \t\f")
+		if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+			rest = strings.TrimLeft(rest[1:], " \t\f")
+		}
+
+		key, err := unescapeProperty(line[:keyEnd])
+		if err != nil {
+			return nil, fmt.Errorf("properties: line %d: %w", number, err)
+		}
+		value, err := unescapeProperty(rest)
+		if err != nil {
+			return nil, fmt.Errorf("properties: line %d: %w", number, err)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// propertiesContinues reports whether a line ends with an odd number of
+// backslashes.
+func propertiesContinues(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// unescapeProperty decodes the escape sequences of a key or a value,
+// combining "\u" escapes of UTF-16 surrogate pairs.
+func unescapeProperty(str string) (string, error) {
+	var units []uint16
+	var builder strings.Builder
+	flush := func() {
+		builder.WriteString(string(utf16.Decode(units)))
+		units = units[:0]
+	}
+
+	for i := 0; i < len(str); i++ {
+		if str[i] != '\\' || i+1 == len(str) {
+			flush()
+			r, size := utf8.DecodeRuneInString(str[i:])
+			builder.WriteRune(r)
+			i += size - 1
+			continue
+		}
+
+		i++
+		if str[i] == 'u' {
+			if i+5 > len(str) {
+				return "", errors.New(`invalid \u escape`)
+			}
+			code, err := strconv.ParseUint(str[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf(`invalid \u escape: \u%s`, str[i+1:i+5])
+			}
+			units = append(units, uint16(code))
+			i += 4
+			continue
+		}
+
+		flush()
+		switch str[i] {
+		case 't':
+			builder.WriteByte('\t')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'f':
+			builder.WriteByte('\f')
+		default:
+			r, size := utf8.DecodeRuneInString(str[i:])
+			builder.WriteRune(r)
+			i += size - 1
+		}
+	}
+	flush()
+	return builder.String(), nil
+}
+
+// escapeProperty escapes a key or a value of a properties file. Keys have
+// their separators and comment characters escaped, values only their leading
+// space.
+func escapeProperty(str string, key bool) string {
+	var builder strings.Builder
+	for i, r := range str {
+		switch {
+		case r == '\\':
+			builder.WriteString(`\\`)
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\r':
+			builder.WriteString(`\r`)
+		case r == '\f':
+			builder.WriteString(`\f`)
+		case r == ' ' && (key || i == 0):
+			builder.WriteString(`\ `)
+		case key && strings.ContainsRune("=:#!", r):
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case !key && i == 0 && (r == '#' || r == '!'):
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&builder, `\u%04X`, unit)
+			}
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// flattenProperties stores the values of a dict under dotted keys prefixed
+// by 'prefix'.
+func (fh *FunctionHandler) flattenProperties(properties map[string]string, prefix string, dict map[string]any) error {
+	for key, value := range dict {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := asDict(value); ok {
+			if err := fh.flattenProperties(properties, key, nested); err != nil {
+				return err
+			}
+			continue
+		}
+		str, err := fh.scalarString(value)
+		if err != nil {
+			return fmt.Errorf("invalid property %q: %w", key, err)
+		}
+		if _, exists := properties[key]; exists {
+			return fmt.Errorf("property %q is defined twice", key)
+		}
+		properties[key] = str
+	}
+	return nil
+}
+
+// base58Alphabet is the Bitcoin Base58 alphabet, which leaves out the
+// characters '0', 'O', 'I' and 'l' that look alike.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// decodeBinaryText decodes a string with 'decode', wrapping its error with
+// the name of the encoding.
+func decodeBinaryText(encoding string, s string, decode func(string) ([]byte, error)) (string, error) {
+	bytes, err := decode(s)
+	if err != nil {
+		return "", fmt.Errorf("unable to decode %s: %w", encoding, err)
+	}
+	return string(bytes), nil
+}
+
+// compress compresses a string with the writer returned by 'newWriter' at
+// the given level.
+func compress(format string, level any, s string, newWriter func(io.Writer, int) (io.WriteCloser, error)) (string, error) {
+	lvl, err := cast.ToIntE(level)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s compression level %v: %w", format, level, err)
+	}
+
+	var buffer bytes.Buffer
+	writer, err := newWriter(&buffer, lvl)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s compression level %d", format, lvl)
+	}
+	if _, err := writer.Write([]byte(s)); err != nil {
+		return "", fmt.Errorf("unable to compress %s: %w", format, err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("unable to compress %s: %w", format, err)
+	}
+	return buffer.String(), nil
+}
+
+// decompress decompresses a string with the reader returned by 'newReader',
+// stopping once the handler's decompression limit is exceeded.
+func (fh *FunctionHandler) decompress(format string, s string, newReader func(io.Reader) (io.ReadCloser, error)) (string, error) {
+	reader, err := newReader(strings.NewReader(s))
+	if err != nil {
+		return "", fmt.Errorf("unable to decompress %s: %w", format, err)
+	}
+	defer reader.Close()
+
+	limit := fh.decompressionLimit()
+	data, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return "", fmt.Errorf("unable to decompress %s: %w", format, err)
+	}
+	if int64(len(data)) > limit {
+		return "", fmt.Errorf("unable to decompress %s: data exceeds the limit of %d bytes", format, limit)
+	}
+	return string(data), nil
 }
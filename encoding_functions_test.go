@@ -1,6 +1,14 @@
 package sprout
 
-import "testing"
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
 
 func TestBase64Encode(t *testing.T) {
 	tests := testCases{
@@ -44,6 +52,258 @@ func TestBase32Decode(t *testing.T) {
 	runTestCases(t, tests)
 }
 
+func TestBase64RawEncode(t *testing.T) {
+	tests := testCases{
+		{"TestWithoutInput", `{{ "" | base64RawEncode }}`, "", nil},
+		{"TestHelloWorldInput", `{{ "Hello World" | base64RawEncode }}`, "SGVsbG8gV29ybGQ", nil},
+		{"TestRoundTrip", `{{ "\xff\xfe" | base64RawEncode | base64RawDecode }}`, "\xff\xfe", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestBase64RawDecode(t *testing.T) {
+	tests := testCases{
+		{"TestHelloWorldInput", `{{ "SGVsbG8gV29ybGQ" | base64RawDecode }}`, "Hello World", nil},
+		{"TestPaddedInput", `{{ "SGVsbG8gV29ybGQ=" | base64RawDecode }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestBase64UrlEncode(t *testing.T) {
+	tests := testCases{
+		{"TestWithoutInput", `{{ "" | base64UrlEncode }}`, "", nil},
+		{"TestUrlSafeAlphabet", `{{ "a?b>c" | base64UrlEncode }}`, "YT9iPmM=", nil},
+		{"TestBinaryInput", `{{ "\xfb\xff" | base64UrlEncode }}`, "-_8=", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestBase64UrlDecode(t *testing.T) {
+	tests := testCases{
+		{"TestUrlSafeAlphabet", `{{ "YT9iPmM=" | base64UrlDecode }}`, "a?b>c", nil},
+		{"TestStdAlphabet", `{{ "+/8=" | base64UrlDecode }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestBase64RawUrlEncode(t *testing.T) {
+	tests := testCases{
+		{"TestWithoutInput", `{{ "" | base64RawUrlEncode }}`, "", nil},
+		{"TestUnpadded", `{{ "\xfb\xff" | base64RawUrlEncode }}`, "-_8", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestBase64RawUrlDecode(t *testing.T) {
+	tests := testCases{
+		{"TestUnpadded", `{{ "YT9iPmM" | base64RawUrlDecode }}`, "a?b>c", nil},
+		{"TestInvalidInput", `{{ "YT9iPmM=" | base64RawUrlDecode }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestHexEncode(t *testing.T) {
+	tests := testCases{
+		{"TestWithoutInput", `{{ "" | hexEncode }}`, "", nil},
+		{"TestHelloInput", `{{ "Hello" | hexEncode }}`, "48656c6c6f", nil},
+		{"TestBinaryInput", `{{ "\x00\xff" | hexEncode }}`, "00ff", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestHexDecode(t *testing.T) {
+	tests := testCases{
+		{"TestLowerCase", `{{ "48656c6c6f" | hexDecode }}`, "Hello", nil},
+		{"TestUpperCase", `{{ "48656C6C6F" | hexDecode }}`, "Hello", nil},
+		{"TestInvalidInput", `{{ "486" | hexDecode }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestBase58Encode(t *testing.T) {
+	tests := testCases{
+		{"TestWithoutInput", `{{ "" | base58Encode }}`, "", nil},
+		{"TestHelloWorldInput", `{{ "Hello World" | base58Encode }}`, "JxF12TrwUP45BMd", nil},
+		{"TestLeadingZeros", `{{ "\x00\x00\x01" | base58Encode }}`, "112", nil},
+		{"TestOnlyZeros", `{{ "\x00\x00" | base58Encode }}`, "11", nil},
+		{"TestBitcoinAddress", `{{ .V | hexDecode | base58Encode }}`, "1BoatSLRHtKNngkdXEeobR76b53LETtpyT", map[string]any{"V": "007680adec8eabcabac676be9e83854ade0bd22cdb0bb960de"}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestBase58Decode(t *testing.T) {
+	tests := testCases{
+		{"TestHelloWorldInput", `{{ "JxF12TrwUP45BMd" | base58Decode }}`, "Hello World", nil},
+		{"TestLeadingZeros", `{{ "112" | base58Decode | hexEncode }}`, "000001", nil},
+		{"TestBitcoinAddress", `{{ "1BoatSLRHtKNngkdXEeobR76b53LETtpyT" | base58Decode | hexEncode }}`, "007680adec8eabcabac676be9e83854ade0bd22cdb0bb960de", nil},
+		{"TestInvalidInput", `{{ "0OIl" | base58Decode }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestAscii85Encode(t *testing.T) {
+	tests := testCases{
+		{"TestWithoutInput", `{{ "" | ascii85Encode }}`, "", nil},
+		{"TestHelloInput", `{{ "Hello" | ascii85Encode }}`, "87cURDZ", nil},
+		{"TestZeroGroup", `{{ "\x00\x00\x00\x00" | ascii85Encode }}`, "z", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestAscii85Decode(t *testing.T) {
+	tests := testCases{
+		{"TestHelloInput", `{{ "87cURDZ" | ascii85Decode }}`, "Hello", nil},
+		{"TestDelimitersAndSpaces", `{{ "<~87cU\nRDZ~>" | ascii85Decode }}`, "Hello", nil},
+		{"TestInvalidInput", `{{ "87cU{" | ascii85Decode }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestPercentEncode(t *testing.T) {
+	tests := testCases{
+		{"TestWithoutInput", `{{ "" | percentEncode }}`, "", nil},
+		{"TestReserved", `{{ "a b/c?d=e&f+g" | percentEncode }}`, "a%20b%2Fc%3Fd%3De%26f%2Bg", nil},
+		{"TestUnreserved", `{{ "AZaz09-._~" | percentEncode }}`, "AZaz09-._~", nil},
+		{"TestUnicode", `{{ "caf\u00e9" | percentEncode }}`, "caf%C3%A9", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestPercentDecode(t *testing.T) {
+	tests := testCases{
+		{"TestSpaces", `{{ "a%20b+c" | percentDecode }}`, "a b+c", nil},
+		{"TestLowerCase", `{{ "caf%c3%a9" | percentDecode }}`, "caf\u00e9", nil},
+		{"TestRoundTrip", `{{ "x/y z?\u00e9" | percentEncode | percentDecode }}`, "x/y z?\u00e9", nil},
+		{"TestInvalidInput", `{{ "100%" | percentDecode }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustBinaryTextDecode(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestBase64Valid", `{{ "SGVsbG8=" | mustBase64Decode }}`, "Hello", nil}, ""},
+		{testCase{"TestBase64Invalid", `{{ "SGVsbG8" | mustBase64Decode }}`, "", nil}, "unable to decode base64: illegal base64 data at input byte 4"},
+		{testCase{"TestBase32Invalid", `{{ "JBSWY3DP!" | mustBase32Decode }}`, "", nil}, "unable to decode base32: illegal base32 data at input byte 8"},
+		{testCase{"TestBase64RawInvalid", `{{ "SGVsbG8=" | mustBase64RawDecode }}`, "", nil}, "unable to decode base64: illegal base64 data at input byte 7"},
+		{testCase{"TestBase64UrlInvalid", `{{ "+/8=" | mustBase64UrlDecode }}`, "", nil}, "unable to decode base64url: illegal base64 data at input byte 0"},
+		{testCase{"TestBase64RawUrlInvalid", `{{ "-_8=" | mustBase64RawUrlDecode }}`, "", nil}, "unable to decode base64url: illegal base64 data at input byte 3"},
+		{testCase{"TestHexOddLength", `{{ "486" | mustHexDecode }}`, "", nil}, "unable to decode hex: encoding/hex: odd length hex string"},
+		{testCase{"TestHexInvalid", `{{ "4g" | mustHexDecode }}`, "", nil}, "unable to decode hex: encoding/hex: invalid byte: U+0067 'g'"},
+		{testCase{"TestBase58Valid", `{{ "JxF12TrwUP45BMd" | mustBase58Decode }}`, "Hello World", nil}, ""},
+		{testCase{"TestBase58Invalid", `{{ "Jx0F" | mustBase58Decode }}`, "", nil}, "unable to decode base58: invalid character '0' at offset 2"},
+		{testCase{"TestAscii85Invalid", `{{ "87cU{" | mustAscii85Decode }}`, "", nil}, "unable to decode ascii85: illegal ascii85 data at input byte 4"},
+		{testCase{"TestPercentTruncated", `{{ "100%2" | mustPercentDecode }}`, "", nil}, `invalid URL escape "%2"`},
+		{testCase{"TestPercentInvalid", `{{ "a%zzb" | mustPercentDecode }}`, "", nil}, `invalid URL escape "%zz"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestGzipCompress(t *testing.T) {
+	tests := testCases{
+		{"TestHelloWorldInput", `{{ "Hello World" | gzipCompress | base64Encode }}`, "H4sIAAAAAAAA/wALAPT/SGVsbG8gV29ybGQDAFaxF0oLAAAA", nil},
+		{"TestRoundTrip", `{{ .V | gzipCompress | gzipDecompress | eq .V }}`, "true", map[string]any{"V": strings.Repeat("payload ", 1000)}},
+		{"TestSmallerOutput", `{{ lt (.V | gzipCompress | len) 100 }}`, "true", map[string]any{"V": strings.Repeat("payload ", 1000)}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestGzipCompressWith(t *testing.T) {
+	tests := testCases{
+		{"TestBestCompression", `{{ .V | gzipCompressWith 9 | gzipDecompress | eq .V }}`, "true", map[string]any{"V": strings.Repeat("payload ", 1000)}},
+		{"TestNoCompression", `{{ lt (.V | gzipCompressWith 0 | len) 8000 }}`, "false", map[string]any{"V": strings.Repeat("payload ", 1000)}},
+		{"TestStringLevel", `{{ "a" | gzipCompressWith "1" | gzipDecompress }}`, "a", nil},
+		{"TestInvalidLevel", `{{ "a" | gzipCompressWith 10 }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestGzipDecompress(t *testing.T) {
+	tests := testCases{
+		{"TestBase64Input", `{{ "H4sIAAAAAAAA/wALAPT/SGVsbG8gV29ybGQDAFaxF0oLAAAA" | base64Decode | gzipDecompress }}`, "Hello World", nil},
+		{"TestInvalidInput", `{{ "not gzip" | gzipDecompress }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestZlibCompress(t *testing.T) {
+	tests := testCases{
+		{"TestHelloWorldInput", `{{ "Hello World" | zlibCompress | base64Encode }}`, "eJwACwD0/0hlbGxvIFdvcmxkAwAYCwQd", nil},
+		{"TestRoundTrip", `{{ .V | zlibCompress | zlibDecompress | eq .V }}`, "true", map[string]any{"V": strings.Repeat("payload ", 1000)}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestZlibCompressWith(t *testing.T) {
+	tests := testCases{
+		{"TestBestSpeed", `{{ .V | zlibCompressWith 1 | zlibDecompress | eq .V }}`, "true", map[string]any{"V": strings.Repeat("payload ", 1000)}},
+		{"TestInvalidLevel", `{{ "a" | zlibCompressWith -3 }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestZlibDecompress(t *testing.T) {
+	tests := testCases{
+		{"TestBase64Input", `{{ "eJwACwD0/0hlbGxvIFdvcmxkAwAYCwQd" | base64Decode | zlibDecompress }}`, "Hello World", nil},
+		{"TestGzipInput", `{{ "Hello" | gzipCompress | zlibDecompress }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustCompression(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestGzipValid", `{{ "a" | mustGzipCompressWith 5 | mustGzipDecompress }}`, "a", nil}, ""},
+		{testCase{"TestGzipInvalidLevel", `{{ "a" | mustGzipCompressWith 10 }}`, "", nil}, "invalid gzip compression level 10"},
+		{testCase{"TestGzipNonNumericLevel", `{{ "a" | mustGzipCompressWith "best" }}`, "", nil}, "invalid gzip compression level best"},
+		{testCase{"TestGzipInvalidData", `{{ "this is not gzip" | mustGzipDecompress }}`, "", nil}, "unable to decompress gzip: gzip: invalid header"},
+		{testCase{"TestGzipTruncated", `{{ "Hello World" | mustGzipCompressWith 9 | trunc 15 | mustGzipDecompress }}`, "", nil}, "unable to decompress gzip: unexpected EOF"},
+		{testCase{"TestZlibValid", `{{ "a" | mustZlibCompressWith -1 | mustZlibDecompress }}`, "a", nil}, ""},
+		{testCase{"TestZlibInvalidLevel", `{{ "a" | mustZlibCompressWith 10 }}`, "", nil}, "invalid zlib compression level 10"},
+		{testCase{"TestZlibInvalidData", `{{ "not zlib" | mustZlibDecompress }}`, "", nil}, "unable to decompress zlib: zlib: invalid header"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestDecompressionLimit(t *testing.T) {
+	handler := NewFunctionHandler(WithMaxDecompressedSize(1024))
+	data := map[string]any{"V": strings.Repeat("a", 1025), "W": strings.Repeat("a", 1024)}
+
+	tmplResponse, err := runTemplate(t, handler, `{{ .W | gzipCompress | mustGzipDecompress | len }}`, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "1024", tmplResponse)
+
+	_, err = runTemplate(t, handler, `{{ .V | gzipCompress | mustGzipDecompress }}`, data)
+	assert.ErrorContains(t, err, "unable to decompress gzip: data exceeds the limit of 1024 bytes")
+
+	_, err = runTemplate(t, handler, `{{ .V | zlibCompress | mustZlibDecompress }}`, data)
+	assert.ErrorContains(t, err, "unable to decompress zlib: data exceeds the limit of 1024 bytes")
+
+	tmplResponse, err = runTemplate(t, handler, `{{ .V | gzipCompress | gzipDecompress }}`, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "", tmplResponse)
+}
+
 func TestFromJson(t *testing.T) {
 	tests := testCases{
 		{"TestEmptyInput", `{{ "" | fromJson }}`, "<no value>", nil},
@@ -160,3 +420,522 @@ func TestMustToYAML(t *testing.T) {
 
 	runMustTestCases(t, tests)
 }
+
+func TestFromTOML(t *testing.T) {
+	tests := testCases{
+		{"TestEmptyInput", `{{ "" | fromToml }}`, "map[]", nil},
+		{"TestVariableInput", `{{ .V | fromToml }}`, "map[bar:map[baz:1] foo:55]", map[string]any{"V": "foo = 55\n[bar]\nbaz = 1\n"}},
+		{"TestAccessField", `{{ (.V | fromToml).owner.name }}`, "Tom", map[string]any{"V": "[owner]\nname = \"Tom\""}},
+		{"TestDottedKeys", `{{ .V | fromToml }}`, "map[fruit:map[apple:map[color:red texture:map[smooth:true]]]]", map[string]any{"V": "[fruit]\napple.color = \"red\"\n[fruit.apple.texture]\nsmooth = true"}},
+		{"TestArrayOfTables", `{{ range (.V | fromToml).products }}{{ .name }};{{ end }}`, "Hammer;Nail;", map[string]any{"V": "[[products]]\nname = \"Hammer\"\n\n[[products]]\nname = \"Nail\""}},
+		{"TestNestedArrayOfTables", `{{ .V | fromToml }}`, "map[fruits:[map[name:apple varieties:[map[name:red delicious] map[name:granny smith]]]]]", map[string]any{"V": "[[fruits]]\nname = \"apple\"\n[[fruits.varieties]]\nname = \"red delicious\"\n[[fruits.varieties]]\nname = \"granny smith\""}},
+		{"TestInlineTable", `{{ (.V | fromToml).point.y }}`, "2", map[string]any{"V": "point = { x = 1, y = 2 }"}},
+		{"TestArray", `{{ .V | fromToml }}`, "map[a:[1 two [3.5]]]", map[string]any{"V": "a = [\n  1, # one\n  \"two\",\n  [3.5],\n]"}},
+		{"TestIntegers", `{{ .V | fromToml }}`, "map[bin:13 dec:1000000 hex:3735928559 neg:-17 oct:493]", map[string]any{"V": "dec = 1_000_000\nneg = -17\nhex = 0xDEAD_BEEF\noct = 0o755\nbin = 0b1101"}},
+		{"TestFloats", `{{ .V | fromToml }}`, "map[a:3.14 b:5e+22 c:-0.01 d:+Inf e:NaN]", map[string]any{"V": "a = 3.14\nb = 5e+22\nc = -1e-2\nd = inf\ne = nan"}},
+		{"TestStrings", `{{ .V | fromToml | toJson }}`, `{"basic":"tab\tquote\" é","literal":"C:\\Users","ml":"Roses are red\nViolets are blue","trimmed":"The quick brown fox."}`, map[string]any{"V": "basic = \"tab\\tquote\\\" \\u00E9\"\nliteral = 'C:\\Users'\nml = \"\"\"\nRoses are red\nViolets are blue\"\"\"\ntrimmed = \"\"\"\\\n  The quick \\\n  brown fox.\"\"\""}},
+		{"TestOffsetDateTime", `{{ (.V | fromToml).dob.UTC.Format "2006-01-02T15:04:05Z07:00" }}`, "1979-05-27T15:32:00Z", map[string]any{"V": "dob = 1979-05-27T07:32:00-08:00"}},
+		{"TestLocalDate", `{{ (.V | fromToml).day.Format "Jan 2, 2006" }}`, "May 27, 1979", map[string]any{"V": "day = 1979-05-27"}},
+		{"TestInvalidInput", `{{ .V | fromToml }}`, "<no value>", map[string]any{"V": "foo = "}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestToTOML(t *testing.T) {
+	tests := testCases{
+		{"TestEmptyInput", `{{ dict | toToml }}`, "", nil},
+		{"TestVariableInput", `{{ .V | toToml }}`, "bar = \"baz\"\nfoo = 55", map[string]any{"V": map[string]any{"foo": 55, "bar": "baz"}}},
+		{"TestTables", `{{ .V | toToml }}`, "name = \"app\"\n\n[database]\nport = 5432\n\n[database.pool]\nsize = 10\n\n[servers.alpha]\nip = \"10.0.0.1\"", map[string]any{"V": map[string]any{
+			"name":     "app",
+			"database": map[string]any{"port": 5432, "pool": map[string]any{"size": 10}},
+			"servers":  map[string]any{"alpha": map[string]any{"ip": "10.0.0.1"}},
+		}}},
+		{"TestArrayOfTables", `{{ .V | toToml }}`, "[[products]]\nname = \"Hammer\"\n\n[[products]]\nname = \"Nail\"", map[string]any{"V": map[string]any{"products": []any{map[string]any{"name": "Hammer"}, map[string]any{"name": "Nail"}}}}},
+		{"TestInlineValues", `{{ .V | toToml }}`, "list = [1, \"two\", { x = 3 }]\n\"my key\" = \"a\\nb\"", map[string]any{"V": map[string]any{"list": []any{1, "two", map[string]any{"x": 3}}, "my key": "a\nb"}}},
+		{"TestFloats", `{{ .V | toToml }}`, "a = 1.0\nb = 0.5\nc = inf", map[string]any{"V": map[string]any{"a": 1.0, "b": 0.5, "c": math.Inf(1)}}},
+		{"TestNilSkipped", `{{ .V | toToml }}`, "a = 1", map[string]any{"V": map[string]any{"a": 1, "b": nil}}},
+		{"TestEmptyTable", `{{ .V | toToml }}`, "[a]", map[string]any{"V": map[string]any{"a": map[string]any{}}}},
+		{"TestTime", `{{ .V | toToml }}`, "at = 2024-03-01T10:00:00Z", map[string]any{"V": map[string]any{"at": time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}}},
+		{"TestInvalidInput", `{{ "str" | toToml }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestTOMLRoundTrip(t *testing.T) {
+	document := "title = \"TOML Example\"\n\n" +
+		"[database]\nenabled = true\nports = [8000, 8001]\nratio = 0.75\n\n" +
+		"[database.temp_targets]\ncase = 72.0\ncpu = 79.5\n\n" +
+		"[misc]\nday = 1979-05-27\nlocal = 1979-05-27T07:32:00\nnoon = 12:00:00.5\nstamp = 1979-05-27T07:32:00-08:00\n\n" +
+		"[[products]]\nname = \"Hammer\"\n\n" +
+		"[[products]]\n\n" +
+		"[[products]]\nname = \"Nail\"\n\n" +
+		"[products.dimensions]\nlength = 2"
+
+	tests := testCases{
+		{"TestRoundTrip", `{{ .V | fromToml | toToml }}`, document, map[string]any{"V": document}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestFromTOMLLargeDocument(t *testing.T) {
+	var document strings.Builder
+	for i := 0; i < 40000; i++ {
+		fmt.Fprintf(&document, "k%d = %d\n", i, i)
+	}
+	document.WriteString("[last]\nstamp = 1979-05-27 07:32:00Z # space separated\nvalues = [1.5, 07:32:00, 1979-05-27]\n")
+
+	start := time.Now()
+	result, err := NewFunctionHandler().MustFromTOML(document.String())
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	if assert.NoError(t, err) {
+		dict := result.(map[string]any)
+		assert.Len(t, dict, 40001)
+		assert.Equal(t, int64(39999), dict["k39999"])
+		last := dict["last"].(map[string]any)
+		assert.Equal(t, time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC), last["stamp"])
+		assert.Len(t, last["values"], 3)
+	}
+}
+
+func TestMustFromTOML(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ .V | mustFromToml }}`, "map[servers:[map[host:alpha] map[host:beta]]]", map[string]any{"V": "[[servers]]\nhost = \"alpha\"\n[[servers]]\nhost = \"beta\""}}, ""},
+		{testCase{"TestDuplicateKey", `{{ .V | mustFromToml }}`, "", map[string]any{"V": "a = 1\na = 2"}}, `toml: line 2: key "a" is already defined`},
+		{testCase{"TestDuplicateTable", `{{ .V | mustFromToml }}`, "", map[string]any{"V": "[a]\nb = 1\n\n[a]"}}, `toml: line 4: table "a" is already defined`},
+		{testCase{"TestDottedRedefinition", `{{ .V | mustFromToml }}`, "", map[string]any{"V": "[fruit]\napple.color = \"red\"\n[fruit.apple]"}}, `table "apple" is already defined`},
+		{testCase{"TestInlineExtended", `{{ .V | mustFromToml }}`, "", map[string]any{"V": "a = { x = 1 }\n[a.b]"}}, `inline table "a" cannot be extended`},
+		{testCase{"TestStaticArrayExtended", `{{ .V | mustFromToml }}`, "", map[string]any{"V": "a = []\n[[a]]"}}, `key "a" is already defined`},
+		{testCase{"TestLeadingZero", `{{ "a = 01" | mustFromToml }}`, "", nil}, "unexpected character '1' after value"},
+		{testCase{"TestIntegerOverflow", `{{ "a = 9223372036854775808" | mustFromToml }}`, "", nil}, `invalid integer "9223372036854775808"`},
+		{testCase{"TestInvalidDate", `{{ "a = 1979-13-01" | mustFromToml }}`, "", nil}, `invalid date-time "1979-13-01"`},
+		{testCase{"TestInvalidEscape", `{{ .V | mustFromToml }}`, "", map[string]any{"V": `a = "\q"`}}, `invalid escape sequence \q`},
+		{testCase{"TestUnterminatedString", `{{ .V | mustFromToml }}`, "", map[string]any{"V": `a = "abc`}}, "unterminated string"},
+		{testCase{"TestTrailingComma", `{{ .V | mustFromToml }}`, "", map[string]any{"V": "a = { x = 1, }"}}, "invalid key character '}'"},
+		{testCase{"TestTwoPairs", `{{ .V | mustFromToml }}`, "", map[string]any{"V": "a = 1 b = 2"}}, "unexpected character 'b' at end of line"},
+		{testCase{"TestMissingValue", `{{ .V | mustFromToml }}`, "", map[string]any{"V": "\n\na ="}}, "toml: line 3: missing value"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustToTOML(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ dict "servers" (list (dict "host" "alpha") (dict "host" "beta")) | mustToToml }}`, "[[servers]]\nhost = \"alpha\"\n\n[[servers]]\nhost = \"beta\"", nil}, ""},
+		{testCase{"TestTypedMap", `{{ .V | mustToToml }}`, "a = \"1\"\nb = \"2\"", map[string]any{"V": map[string]string{"b": "2", "a": "1"}}}, ""},
+		{testCase{"TestNotAMap", `{{ list 1 2 | mustToToml }}`, "", nil}, "toml: cannot encode []interface {} as a document, a map is required"},
+		{testCase{"TestNilInArray", `{{ .V | mustToToml }}`, "", map[string]any{"V": map[string]any{"a": []any{1, nil}}}}, `toml: cannot encode nil in array "a"`},
+		{testCase{"TestUnsupported", `{{ .V | mustToToml }}`, "", map[string]any{"V": map[string]any{"c": make(chan int)}}}, `toml: cannot encode chan int at "c"`},
+		{testCase{"TestOverflow", `{{ .V | mustToToml }}`, "", map[string]any{"V": map[string]any{"n": uint64(math.MaxUint64)}}}, "overflows int64"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestFromCsv(t *testing.T) {
+	tests := testCases{
+		{"TestEmptyInput", `{{ "" | fromCsv }}`, "[]", nil},
+		{"TestRows", `{{ "name,age\nAlice,30\nBob,25" | fromCsv }}`, "[[name age] [Alice 30] [Bob 25]]", nil},
+		{"TestQuoted", `{{ index (.V | fromCsv) 0 1 }}`, "b, \"c\"\nd", map[string]any{"V": "a,\"b, \"\"c\"\"\nd\""}},
+		{"TestCRLF", `{{ "a,b\r\nc,d\r\n" | fromCsv }}`, "[[a b] [c d]]", nil},
+		{"TestComposeWithSlices", `{{ range .V | fromCsv | rest }}{{ first . }};{{ end }}`, "Alice;Bob;", map[string]any{"V": "name,age\nAlice,30\nBob,25"}},
+		{"TestInvalidInput", `{{ "a,b\nc" | fromCsv }}`, "[]", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestFromTsv(t *testing.T) {
+	tests := testCases{
+		{"TestRows", `{{ "name\tage\nAlice\t30" | fromTsv }}`, "[[name age] [Alice 30]]", nil},
+		{"TestCommas", `{{ index ("a,b\tc" | fromTsv) 0 0 }}`, "a,b", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestFromCsvWith(t *testing.T) {
+	tests := testCases{
+		{"TestDelimiter", `{{ "a;b\nc;d" | fromCsvWith (dict "delimiter" ";") }}`, "[[a b] [c d]]", nil},
+		{"TestHeader", `{{ "name,age\nAlice,30\nBob,25" | fromCsvWith (dict "header" true) }}`, "[map[age:30 name:Alice] map[age:25 name:Bob]]", nil},
+		{"TestHeaderOnly", `{{ "name,age" | fromCsvWith (dict "header" true) }}`, "[]", nil},
+		{"TestComment", `{{ "# users\na,b\n# end" | fromCsvWith (dict "comment" "#") }}`, "[[a b]]", nil},
+		{"TestTrim", `{{ " a ,  b\t\n c,d " | fromCsvWith (dict "trim" true) | toJson }}`, `[["a","b"],["c","d"]]`, nil},
+		{"TestLazyQuotes", `{{ .V | fromCsvWith (dict "lazyQuotes" true) }}`, `[[a "b" c]]`, map[string]any{"V": `a "b" c`}},
+		{"TestStrictQuotes", `{{ .V | fromCsvWith (dict) }}`, "<no value>", map[string]any{"V": `a "b" c`}},
+		{"TestStruct", `{{ "a|b" | fromCsvWith .O }}`, "[[a b]]", map[string]any{"O": CsvOptions{Delimiter: "|"}}},
+		{"TestPluck", `{{ "name,age\nAlice,30\nBob,25" | fromCsvWith (dict "header" true) | pluck "name" }}`, "[Alice Bob]", nil},
+		{"TestSortAlpha", `{{ "name\nCarol\nAlice\nBob" | fromCsvWith (dict "header" true) | pluck "name" | sortAlpha }}`, "[Alice Bob Carol]", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestToCsv(t *testing.T) {
+	tests := testCases{
+		{"TestEmptyInput", `{{ list | toCsv }}`, "", nil},
+		{"TestLists", `{{ list (list "name" "age") (list "Alice" 30) | toCsv }}`, "name,age\nAlice,30", nil},
+		{"TestQuoting", `{{ list (list "a,b" "say \"hi\"" "line\nbreak" " lead") | toCsv }}`, "\"a,b\",\"say \"\"hi\"\"\",\"line\nbreak\",\" lead\"", nil},
+		{"TestDicts", `{{ list (dict "name" "Alice" "age" 30) (dict "name" "Bob" "city" "Paris") | toCsv }}`, "age,city,name\n30,,Alice\n,Paris,Bob", nil},
+		{"TestNilFields", `{{ .V | toCsv }}`, "a,,c", map[string]any{"V": []any{[]any{"a", nil, "c"}}}},
+		{"TestStringRows", `{{ .V | toCsv }}`, "a,b", map[string]any{"V": [][]string{{"a", "b"}}}},
+		{"TestRoundTrip", `{{ .V | fromCsv | toCsv }}`, "a,\"b,c\"\n\"d\"\"e\",f", map[string]any{"V": "a,\"b,c\"\n\"d\"\"e\",f"}},
+		{"TestInvalidInput", `{{ "abc" | toCsv }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestToTsv(t *testing.T) {
+	tests := testCases{
+		{"TestDicts", `{{ list (dict "name" "Alice" "age" 30) | toTsv }}`, "age\tname\n30\tAlice", nil},
+		{"TestLists", `{{ list (list "a b" "c,d") | toTsv }}`, "a b\tc,d", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestToCsvWith(t *testing.T) {
+	tests := testCases{
+		{"TestColumns", `{{ .V | toCsvWith (dict "columns" (list "name" "email")) }}`, "name,email\nAlice,alice@example.com\nBob,", map[string]any{"V": []map[string]any{
+			{"name": "Alice", "email": "alice@example.com", "age": 30},
+			{"name": "Bob"},
+		}}},
+		{"TestNoHeader", `{{ list (dict "a" 1 "b" 2) | toCsvWith (dict "columns" (list "b" "a") "header" false) }}`, "2,1", nil},
+		{"TestDelimiter", `{{ list (list "a" "b;c") | toCsvWith (dict "delimiter" ";") }}`, "a;\"b;c\"", nil},
+		{"TestListsWithColumns", `{{ list (list 1 2) | toCsvWith (dict "columns" (list "x" "y")) }}`, "x,y\n1,2", nil},
+		{"TestFromCsvRoundTrip", `{{ .V | fromCsvWith (dict "header" true) | toCsvWith (dict "columns" (list "name" "age")) }}`, "name,age\nAlice,30", map[string]any{"V": "name,age\nAlice,30"}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustFromCsv(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ .V | mustFromCsv }}`, "[[a b\nc]]", map[string]any{"V": "a,\"b\nc\""}}, ""},
+		{testCase{"TestFieldCount", `{{ "a,b\nc" | mustFromCsv }}`, "", nil}, "wrong number of fields"},
+		{testCase{"TestBareQuote", `{{ .V | mustFromCsv }}`, "", map[string]any{"V": `a "b" c`}}, `bare " in non-quoted-field`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustFromTsv(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ "a\tb" | mustFromTsv }}`, "[[a b]]", nil}, ""},
+		{testCase{"TestFieldCount", `{{ "a\tb\nc" | mustFromTsv }}`, "", nil}, "wrong number of fields"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustFromCsvWith(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ "# users\n name , age\n Alice , 30" | mustFromCsvWith (dict "comment" "#" "trim" true) }}`, "[[name age] [Alice 30]]", nil}, ""},
+		{testCase{"TestUnknownOption", `{{ "a" | mustFromCsvWith (dict "quote" "'") }}`, "", nil}, "unknown CSV option: quote"},
+		{testCase{"TestInvalidDelimiter", `{{ "a" | mustFromCsvWith (dict "delimiter" "::") }}`, "", nil}, `invalid CSV delimiter: "::"`},
+		{testCase{"TestQuoteDelimiter", `{{ "a" | mustFromCsvWith (dict "delimiter" "\"") }}`, "", nil}, `invalid CSV delimiter: "\""`},
+		{testCase{"TestInvalidComment", `{{ "a" | mustFromCsvWith (dict "comment" "\n") }}`, "", nil}, `invalid CSV comment: "\n"`},
+		{testCase{"TestInvalidHeader", `{{ "a" | mustFromCsvWith (dict "header" "maybe") }}`, "", nil}, "invalid CSV header"},
+		{testCase{"TestDuplicateColumn", `{{ "a,a\n1,2" | mustFromCsvWith (dict "header" true) }}`, "", nil}, `duplicate CSV column: "a"`},
+		{testCase{"TestInvalidOptions", `{{ "a" | mustFromCsvWith "," }}`, "", nil}, "cannot use string as CSV options"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustToCsv(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ list (list 1 2) (list 3 4) | mustToCsv }}`, "1,2\n3,4", nil}, ""},
+		{testCase{"TestNotAList", `{{ "abc" | mustToCsv }}`, "", nil}, "cannot use string as CSV rows"},
+		{testCase{"TestInvalidRow", `{{ list 1 | mustToCsv }}`, "", nil}, "cannot use int as a CSV row"},
+		{testCase{"TestMixedRows", `{{ list (list 1) (dict "a" 1) | mustToCsv }}`, "", nil}, "CSV rows must be all lists or all dicts"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustToTsv(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ list (list "a b" "c") | mustToTsv }}`, "a b\tc", nil}, ""},
+		{testCase{"TestInvalidRow", `{{ list "a" | mustToTsv }}`, "", nil}, "cannot use string as a CSV row"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustToCsvWith(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ list (dict "a" 1 "b" 2) | mustToCsvWith (dict "columns" (list "b" "a") "header" false) }}`, "2,1", nil}, ""},
+		{testCase{"TestInvalidColumns", `{{ list (dict "a" 1) | mustToCsvWith (dict "columns" (dict "a" 1)) }}`, "", nil}, "invalid CSV columns"},
+		{testCase{"TestInvalidDelimiter", `{{ list (list 1) | mustToCsvWith (dict "delimiter" "") }}`, "", nil}, `invalid CSV delimiter: ""`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestFromXML(t *testing.T) {
+	tests := testCases{
+		{"TestLeaf", `{{ "<a>text</a>" | fromXml }}`, "map[a:text]", nil},
+		{"TestEmptyElement", `{{ "<a/>" | fromXml }}`, "map[a:]", nil},
+		{"TestAttributes", `{{ .V | fromXml }}`, "map[server:map[@id:1 host:alpha port:[80 443]]]", map[string]any{"V": `<server id="1"><host>alpha</host><port>80</port><port>443</port></server>`}},
+		{"TestTextWithAttributes", `{{ .V | fromXml }}`, "map[a:map[#text:text @x:1]]", map[string]any{"V": `<a x="1">text</a>`}},
+		{"TestRepeatedDicts", `{{ range (.V | fromXml).servers.server }}{{ index . "@name" }}={{ .port }};{{ end }}`, "alpha=80;beta=443;", map[string]any{"V": "<servers>\n  <server name=\"alpha\"><port>80</port></server>\n  <server name=\"beta\"><port>443</port></server>\n</servers>"}},
+		{"TestNamespaces", `{{ .V | fromXml }}`, "map[project:map[@xmlns:http://maven.apache.org/POM/4.0.0 @xmlns:xsi:http://www.w3.org/2001/XMLSchema-instance @xsi:schemaLocation:x version:1]]", map[string]any{"V": `<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="x"><version>1</version></project>`}},
+		{"TestEntitiesAndCData", `{{ (.V | fromXml).a }}`, "x < y & <b>z</b>", map[string]any{"V": `<a>x &lt; y &amp; <![CDATA[<b>z</b>]]></a>`}},
+		{"TestDeclarationAndComments", `{{ .V | fromXml }}`, "map[a:1]", map[string]any{"V": "<?xml version=\"1.0\"?>\n<!-- comment -->\n<a>1</a>\n"}},
+		{"TestInvalidInput", `{{ "<a>" | fromXml }}`, "<no value>", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestFromXMLWith(t *testing.T) {
+	tests := testCases{
+		{"TestSingleElement", `{{ range (.V | fromXmlWith (dict "forceList" (list "port"))).s.port }}[{{ index . "#text" }}]{{ end }}`, "[80]", map[string]any{"V": `<s><port a="1">80</port></s>`}},
+		{"TestRepeatedElement", `{{ .V | fromXmlWith (dict "forceList" (list "port")) }}`, "map[s:map[host:alpha port:[80 443]]]", map[string]any{"V": `<s><host>alpha</host><port>80</port><port>443</port></s>`}},
+		{"TestEveryElement", `{{ .V | fromXmlWith (dict "forceList" (list "*")) }}`, "map[s:map[host:[alpha] port:[map[#text:80 @a:1]]]]", map[string]any{"V": `<s><host>alpha</host><port a="1">80</port></s>`}},
+		{"TestRootNotList", `{{ "<a>1</a>" | fromXmlWith (dict "forceList" (list "a")) }}`, "map[a:1]", nil},
+		{"TestStruct", `{{ "<a><b>1</b></a>" | fromXmlWith .O }}`, "map[a:map[b:[1]]]", map[string]any{"O": XmlOptions{ForceList: []string{"b"}}}},
+		{"TestDefault", `{{ "<a><b>1</b></a>" | fromXmlWith dict }}`, "map[a:map[b:1]]", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestToXML(t *testing.T) {
+	tests := testCases{
+		{"TestSimple", `{{ dict "server" (dict "@id" 1 "host" "alpha") | toXml }}`, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<server id=\"1\">\n  <host>alpha</host>\n</server>", nil},
+		{"TestRepeated", `{{ .V | toXml }}`, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<servers>\n  <server name=\"alpha\">\n    <port>80</port>\n    <port>8080</port>\n  </server>\n  <server name=\"beta\"/>\n</servers>", map[string]any{"V": map[string]any{
+			"servers": map[string]any{"server": []any{
+				map[string]any{"@name": "alpha", "port": []int{80, 8080}},
+				map[string]any{"@name": "beta"},
+			}},
+		}}},
+		{"TestTextWithAttributes", `{{ dict "a" (dict "@x" "1" "#text" "hi") | toXml }}`, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<a x=\"1\">hi</a>", nil},
+		{"TestEscaping", `{{ dict "a" (dict "@q" "\"<&>'" "#text" "x < y & z > w") | toXml }}`, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<a q=\"&#34;&lt;&amp;&gt;&#39;\">x &lt; y &amp; z &gt; w</a>", nil},
+		{"TestEmptyValues", `{{ dict "a" (dict "b" "" "c" nil) | toXml }}`, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<a>\n  <b/>\n  <c/>\n</a>", nil},
+		{"TestTypedMap", `{{ .V | toXml }}`, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<a>\n  <b>1</b>\n</a>", map[string]any{"V": map[string]any{"a": map[string]string{"b": "1"}}}},
+		{"TestRoundTrip", `{{ .V | fromXml | toXmlWith (dict "indent" "" "declaration" false) }}`, `<project xmlns="urn:x" xmlns:xsi="urn:y" xsi:schemaLocation="z"><dependencies><dependency><artifactId>a</artifactId></dependency><dependency><artifactId>b &amp; c</artifactId></dependency></dependencies></project>`, map[string]any{"V": `<project xmlns="urn:x" xmlns:xsi="urn:y" xsi:schemaLocation="z"><dependencies><dependency><artifactId>a</artifactId></dependency><dependency><artifactId>b &amp; c</artifactId></dependency></dependencies></project>`}},
+		{"TestInvalidInput", `{{ dict "a" 1 "b" 2 | toXml }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestToXMLWith(t *testing.T) {
+	tests := testCases{
+		{"TestRoot", `{{ dict "host" "alpha" | toXmlWith (dict "root" "server" "indent" "" "declaration" false) }}`, "<server><host>alpha</host></server>", nil},
+		{"TestIndent", `{{ dict "a" (dict "b" "1") | toXmlWith (dict "indent" "\t" "declaration" false) }}`, "<a>\n\t<b>1</b>\n</a>", nil},
+		{"TestRootList", `{{ list 1 2 | toXmlWith (dict "root" "n" "declaration" false) }}`, "", nil},
+		{"TestRootWrappingList", `{{ dict "n" (list 1 2) | toXmlWith (dict "root" "items" "declaration" false) }}`, "<items>\n  <n>1</n>\n  <n>2</n>\n</items>", nil},
+		{"TestStruct", `{{ dict "a" "1" | toXmlWith .O }}`, "<a>1</a>", map[string]any{"O": XmlOptions{}}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustFromXML(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ .V | mustFromXml }}`, "map[a:map[#text:text @x:1]]", map[string]any{"V": `<a x="1">text</a>`}}, ""},
+		{testCase{"TestEmptyInput", `{{ "" | mustFromXml }}`, "", nil}, "XML document has no root element"},
+		{testCase{"TestUnclosed", `{{ "<a><b></b>" | mustFromXml }}`, "", nil}, "unclosed element <a>"},
+		{testCase{"TestMismatched", `{{ "<a></b>" | mustFromXml }}`, "", nil}, "unexpected end element </b>"},
+		{testCase{"TestSeveralRoots", `{{ "<a/><b/>" | mustFromXml }}`, "", nil}, "XML document has several root elements"},
+		{testCase{"TestTextOutsideRoot", `{{ "<a/>text" | mustFromXml }}`, "", nil}, "XML text outside of the root element"},
+		{testCase{"TestUnknownEntity", `{{ "<a>&nbsp;</a>" | mustFromXml }}`, "", nil}, "invalid character entity &nbsp;"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustFromXMLWith(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ "<a><b>1</b></a>" | mustFromXmlWith (dict "forceList" (list "*")) }}`, "map[a:map[b:[1]]]", nil}, ""},
+		{testCase{"TestInvalidOption", `{{ "<a/>" | mustFromXmlWith (dict "forceLists" (list "b")) }}`, "", nil}, "unknown XML option: forceLists"},
+		{testCase{"TestInvalidDocument", `{{ "<a>" | mustFromXmlWith (dict "forceList" (list "b")) }}`, "", nil}, "unclosed element <a>"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustToXML(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ dict "note" "a < b" | mustToXml }}`, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<note>a &lt; b</note>", nil}, ""},
+		{testCase{"TestSeveralRoots", `{{ dict "a" 1 "b" 2 | mustToXml }}`, "", nil}, "cannot use map[string]interface {} as XML document, a dict with a single root element is required"},
+		{testCase{"TestNotADict", `{{ "a" | mustToXml }}`, "", nil}, "cannot use string as XML document"},
+		{testCase{"TestListRoot", `{{ dict "a" (list 1 2) | mustToXml }}`, "", nil}, `cannot use []interface {} as XML root element "a", a document has a single root element`},
+		{testCase{"TestEmptyListRoot", `{{ dict "a" (list) | mustToXml }}`, "", nil}, `cannot use []interface {} as XML root element "a"`},
+		{testCase{"TestListRootOption", `{{ list 1 2 | mustToXmlWith (dict "root" "items") }}`, "", nil}, `cannot use []interface {} as XML root element "items"`},
+		{testCase{"TestNestedList", `{{ dict "a" (dict "b" (list 1 2)) | mustToXml }}`, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<a>\n  <b>1</b>\n  <b>2</b>\n</a>", nil}, ""},
+		{testCase{"TestInvalidName", `{{ dict "1a" "x" | mustToXml }}`, "", nil}, `invalid XML element name: "1a"`},
+		{testCase{"TestInvalidAttribute", `{{ dict "a" (dict "@b c" "x") | mustToXml }}`, "", nil}, `invalid XML attribute name: "b c"`},
+		{testCase{"TestInvalidCharacter", `{{ dict "a" "\x00" | mustToXml }}`, "", nil}, `invalid text in XML element "a": character U+0000 is not allowed in XML`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustToXMLWith(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ dict "a" "1" | mustToXmlWith (dict "indent" "\t") }}`, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<a>1</a>", nil}, ""},
+		{testCase{"TestUnknownOption", `{{ dict "a" "1" | mustToXmlWith (dict "pretty" true) }}`, "", nil}, "unknown XML option: pretty"},
+		{testCase{"TestInvalidDeclaration", `{{ dict "a" "1" | mustToXmlWith (dict "declaration" "maybe") }}`, "", nil}, "invalid XML declaration"},
+		{testCase{"TestInvalidOptions", `{{ dict "a" "1" | mustToXmlWith 2 }}`, "", nil}, "cannot use int as XML options"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestFromDotenv(t *testing.T) {
+	tests := testCases{
+		{"TestSimple", `{{ "A=1\nB=two" | fromDotenv }}`, "map[A:1 B:two]", nil},
+		{"TestExportAndComments", `{{ .V | fromDotenv }}`, "map[HOST:localhost PORT:8080]", map[string]any{"V": "# settings\nexport HOST=localhost # dev\n\n  PORT = 8080\n"}},
+		{"TestSingleQuotes", `{{ (.V | fromDotenv).A }}`, `literal \n $HOME # not a comment`, map[string]any{"V": `A='literal \n $HOME # not a comment'`}},
+		{"TestDoubleQuotes", `{{ (.V | fromDotenv).A }}`, "line 1\n\t\"line\" 2 $HOME", map[string]any{"V": `A="line 1\n\t\"line\" 2 \$HOME" # comment`}},
+		{"TestMultiline", `{{ (.V | fromDotenv).KEY }}`, "-----BEGIN-----\nabc\n-----END-----", map[string]any{"V": "KEY=\"-----BEGIN-----\nabc\n-----END-----\"\nOTHER=1"}},
+		{"TestEmptyValue", `{{ "A=\nB=\"\"" | fromDotenv }}`, "map[A: B:]", nil},
+		{"TestExportAsName", `{{ "export=1" | fromDotenv }}`, "map[export:1]", nil},
+		{"TestInvalidInput", `{{ "A" | fromDotenv }}`, "map[]", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestToDotenv(t *testing.T) {
+	tests := testCases{
+		{"TestSorted", `{{ dict "PORT" 8080 "HOST" "localhost" "EMPTY" "" | toDotenv }}`, "EMPTY=\nHOST=localhost\nPORT=8080", nil},
+		{"TestQuoting", `{{ dict "A" "it's $HOME" "B" "a\nb" "C" "x=\"y\"" | toDotenv }}`, "A=\"it's \\$HOME\"\nB=\"a\\nb\"\nC=\"x=\\\"y\\\"\"", nil},
+		{"TestNil", `{{ dict "A" nil | toDotenv }}`, "A=", nil},
+		{"TestRoundTrip", `{{ $v := .V | toDotenv | fromDotenv }}{{ eq $v.A .V.A }}`, "true", map[string]any{"V": map[string]any{"A": "multi\nline \"quoted\" $VAR \\ 'x' #y"}}},
+		{"TestInvalidInput", `{{ "A" | toDotenv }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestFromINI(t *testing.T) {
+	tests := testCases{
+		{"TestSections", `{{ .V | fromIni }}`, "map[database:map[host:localhost port:5432] name:app server:map[url:http://x]]", map[string]any{"V": "; global\nname = app\n\n[database]\nhost = localhost\n# comment\nport: 5432\n\n[ server ]\nurl=http://x\n"}},
+		{"TestQuotedValue", `{{ (.V | fromIni).a.x }}`, " padded \"value\" ", map[string]any{"V": "[a]\nx = \" padded \\\"value\\\" \""}},
+		{"TestEmptyValue", `{{ "a =" | fromIni }}`, "map[a:]", nil},
+		{"TestInvalidInput", `{{ "[a" | fromIni }}`, "map[]", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestToINI(t *testing.T) {
+	tests := testCases{
+		{"TestSections", `{{ .V | toIni }}`, "name = app\n\n[database]\nhost = localhost\nport = 5432\n\n[server]\nurl = http://x", map[string]any{"V": map[string]any{
+			"name":     "app",
+			"server":   map[string]any{"url": "http://x"},
+			"database": map[string]any{"port": 5432, "host": "localhost"},
+		}}},
+		{"TestQuoting", `{{ dict "a" (dict "x" " padded " "y" "a\nb") | toIni }}`, "[a]\nx = \" padded \"\ny = \"a\\nb\"", nil},
+		{"TestRoundTrip", `{{ .V | toIni | fromIni }}`, "map[a:map[x: y \"z\"\\ ] b:1]", map[string]any{"V": map[string]any{"b": "1", "a": map[string]string{"x": " y \"z\"\\ "}}}},
+		{"TestInvalidInput", `{{ dict "a" (dict "b" (dict "c" 1)) | toIni }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestFromProperties(t *testing.T) {
+	tests := testCases{
+		{"TestSeparators", `{{ .V | fromProperties }}`, "map[a:1 b:2 c:3 d:]", map[string]any{"V": "a=1\nb : 2\n  c 3\n# comment\n! other comment\nd\n"}},
+		{"TestEscapes", `{{ (.V | fromProperties).greeting }}`, "caf\u00e9\t\U0001F600 \\", map[string]any{"V": `greeting = caf\u00e9\t\uD83D\uDE00 \\`}},
+		{"TestEscapedKey", `{{ .V | fromProperties }}`, "map[key with spaces=::value]", map[string]any{"V": `key\ with\ spaces\=\:=value`}},
+		{"TestContinuation", `{{ (.V | fromProperties).fruits }}`, "apple, banana, cherry", map[string]any{"V": "fruits = apple, \\\n         banana, \\\n\tcherry\nother = 1"}},
+		{"TestEscapedBackslashAtEnd", `{{ .V | fromProperties }}`, "map[a:x\\ b:y]", map[string]any{"V": "a = x\\\\\nb = y"}},
+		{"TestDuplicateKeys", `{{ "a=1\na=2" | fromProperties }}`, "map[a:2]", nil},
+		{"TestInvalidInput", `{{ .V | fromProperties }}`, "map[]", map[string]any{"V": `a = \u00zz`}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestToProperties(t *testing.T) {
+	tests := testCases{
+		{"TestNested", `{{ dict "app" (dict "name" "My App" "port" 8080) "debug" true | toProperties }}`, "app.name=My App\napp.port=8080\ndebug=true", nil},
+		{"TestEscapes", `{{ dict "key with spaces" " caf\u00e9\n" "a=b" "#x" | toProperties }}`, "a\\=b=\\#x\nkey\\ with\\ spaces=\\ caf\\u00E9\\n", nil},
+		{"TestSupplementary", `{{ dict "a" "\U0001F600" | toProperties }}`, "a=\\uD83D\\uDE00", nil},
+		{"TestRoundTrip", `{{ $v := .V | toProperties | fromProperties }}{{ and (eq (index $v "k =:") (index .V "k =:")) (eq $v.k .V.k) }}`, "true", map[string]any{"V": map[string]any{"k =:": " a\\b\n\u00e9 !", "k": "x"}}},
+		{"TestInvalidInput", `{{ dict "a" (list 1 2) | toProperties }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustFromDotenv(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ "A='x'" | mustFromDotenv }}`, "map[A:x]", nil}, ""},
+		{testCase{"TestMissingEquals", `{{ "A=1\nB" | mustFromDotenv }}`, "", nil}, `dotenv: line 2: missing '=' after "B"`},
+		{testCase{"TestInvalidName", `{{ "1A=1" | mustFromDotenv }}`, "", nil}, `dotenv: line 1: invalid variable name "1A"`},
+		{testCase{"TestUnterminated", `{{ "A=\"x\nB=1" | mustFromDotenv }}`, "", nil}, "dotenv: line 1: unterminated quoted value"},
+		{testCase{"TestTrailingCharacters", `{{ "A='x' y" | mustFromDotenv }}`, "", nil}, "dotenv: line 1: unexpected characters after quoted value"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustToDotenv(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ dict "A" "two words" | mustToDotenv }}`, `A="two words"`, nil}, ""},
+		{testCase{"TestNotADict", `{{ list 1 | mustToDotenv }}`, "", nil}, "cannot use []interface {} as dotenv variables"},
+		{testCase{"TestInvalidName", `{{ dict "A B" 1 | mustToDotenv }}`, "", nil}, `invalid dotenv variable name: "A B"`},
+		{testCase{"TestNestedValue", `{{ dict "A" (list 1) | mustToDotenv }}`, "", nil}, `invalid dotenv variable "A": cannot use []interface {} as a value`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustFromINI(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ "[a]\nx = 1" | mustFromIni }}`, "map[a:map[x:1]]", nil}, ""},
+		{testCase{"TestMissingSeparator", `{{ "[a]\nx" | mustFromIni }}`, "", nil}, "ini: line 2: expected key = value"},
+		{testCase{"TestUnterminatedSection", `{{ "[a" | mustFromIni }}`, "", nil}, "ini: line 1: unterminated section header"},
+		{testCase{"TestDuplicateSection", `{{ "[a]\n[a]" | mustFromIni }}`, "", nil}, `ini: line 2: section "a" is already defined`},
+		{testCase{"TestSectionCollision", `{{ "a = 1\n[a]" | mustFromIni }}`, "", nil}, `ini: line 2: section "a" is already defined`},
+		{testCase{"TestDuplicateKey", `{{ "[a]\nx = 1\nx = 2" | mustFromIni }}`, "", nil}, `ini: line 3: key "x" is already defined in section "a"`},
+		{testCase{"TestInvalidQuotes", `{{ .V | mustFromIni }}`, "", map[string]any{"V": `x = "a\q"`}}, `ini: line 1: invalid quoted value "a\q"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustToINI(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ dict "a" (dict "x" 1) | mustToIni }}`, "[a]\nx = 1", nil}, ""},
+		{testCase{"TestNotADict", `{{ "a" | mustToIni }}`, "", nil}, "cannot use string as INI content"},
+		{testCase{"TestNestedSection", `{{ dict "a" (dict "b" (dict "c" 1)) | mustToIni }}`, "", nil}, `invalid INI section "a": invalid INI key "b": cannot use map[string]interface {} as a value`},
+		{testCase{"TestInvalidSectionName", `{{ dict "a]" (dict "x" 1) | mustToIni }}`, "", nil}, `invalid INI section name: "a]"`},
+		{testCase{"TestInvalidKey", `{{ dict "a=b" 1 | mustToIni }}`, "", nil}, `invalid INI key: "a=b"`},
+		{testCase{"TestList", `{{ dict "a" (list 1) | mustToIni }}`, "", nil}, `invalid INI key "a": cannot use []interface {} as a value`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustFromProperties(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ "a = 1" | mustFromProperties }}`, "map[a:1]", nil}, ""},
+		{testCase{"TestInvalidUnicode", `{{ .V | mustFromProperties }}`, "", map[string]any{"V": "a = 1\nb = \\u00zz"}}, `properties: line 2: invalid \u escape: \u00zz`},
+		{testCase{"TestTruncatedUnicode", `{{ .V | mustFromProperties }}`, "", map[string]any{"V": `a = \u00`}}, `properties: line 1: invalid \u escape`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustToProperties(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ dict "key with spaces" "\u00e9" | mustToProperties }}`, "key\\ with\\ spaces=\\u00E9", nil}, ""},
+		{testCase{"TestNotADict", `{{ 1 | mustToProperties }}`, "", nil}, "cannot use int as properties"},
+		{testCase{"TestList", `{{ dict "a" (dict "b" (list 1)) | mustToProperties }}`, "", nil}, `invalid property "a.b": cannot use []interface {} as a value`},
+		{testCase{"TestDuplicate", `{{ dict "a" (dict "b" 1) "a.b" 2 | mustToProperties }}`, "", nil}, `property "a.b" is defined twice`},
+	}
+
+	runMustTestCases(t, tests)
+}
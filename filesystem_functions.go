@@ -195,7 +195,8 @@ func (fh *FunctionHandler) OsIsAbs(str string) bool {
 //
 //	{{ "PATH" | env }} // Output: "/usr/bin:/bin:/usr/sbin:/sbin"
 func (fh *FunctionHandler) Env(key string) string {
-	return os.Getenv(key)
+	value, _ := fh.lookupEnv(key)
+	return value
 }
 
 // ExpandEnv replaces ${var} or $var in the string based on the values of the
@@ -213,5 +214,32 @@ func (fh *FunctionHandler) Env(key string) string {
 //
 //	{{ "Path is $PATH" | expandEnv }} // Output: "Path is /usr/bin:/bin:/usr/sbin:/sbin"
 func (fh *FunctionHandler) ExpandEnv(str string) string {
-	return os.ExpandEnv(str)
+	return os.Expand(str, fh.Env)
+}
+
+// WithEnvironment makes the handler read environment variables from 'env'
+// instead of the process environment. It is used by the env and expandEnv
+// functions and by the functions honoring variables such as NO_COLOR, and
+// allows templates to be rendered reproducibly in tests.
+//
+// Example:
+//
+//	handler := NewFunctionHandler(WithEnvironment(map[string]string{"NO_COLOR": "1"}))
+func WithEnvironment(env map[string]string) FunctionHandlerOption {
+	return func(p *FunctionHandler) {
+		p.environment = make(map[string]string, len(env))
+		for key, value := range env {
+			p.environment[key] = value
+		}
+	}
+}
+
+// lookupEnv retrieves the value of the environment variable 'key' from the
+// environment given to the handler, or from the process environment.
+func (fh *FunctionHandler) lookupEnv(key string) (string, bool) {
+	if fh.environment != nil {
+		value, ok := fh.environment[key]
+		return value, ok
+	}
+	return os.LookupEnv(key)
 }
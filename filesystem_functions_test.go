@@ -3,6 +3,8 @@ package sprout
 import (
 	"os"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPathBase(t *testing.T) {
@@ -99,3 +101,22 @@ func TestExpandEnv(t *testing.T) {
 
 	runTestCases(t, tests)
 }
+
+func TestWithEnvironment(t *testing.T) {
+	t.Setenv("__SPROUT_TEST_ENV_KEY", "sprout will grow!")
+	handler := NewFunctionHandler(WithEnvironment(map[string]string{"GREETING": "hello"}))
+
+	var tests = testCases{
+		{"TestEnv", `{{ env "GREETING" }}`, "hello", nil},
+		{"TestEnvHidesProcessEnvironment", `{{ env "__SPROUT_TEST_ENV_KEY" }}`, "", nil},
+		{"TestExpandEnv", `{{ expandEnv "$GREETING world" }}`, "hello world", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := runTemplate(t, handler, test.input, test.data)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
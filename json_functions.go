@@ -0,0 +1,1546 @@
+package sprout
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// JsonPatch applies a JSON Patch (RFC 6902) to a document. Both the patch and
+// the document may be JSON strings or decoded values, such as the output of
+// fromJson, fromYaml or dict. The document is not modified. Numbers keep
+// their type, and integers read from JSON strings become int64 values, so
+// that they are not turned into floats.
+//
+// Parameters:
+//
+//	patch any - the list of operations to apply.
+//	doc any - the document to patch.
+//
+// Returns:
+//
+//	any - the patched document, or nil if the patch fails.
+//
+// Example:
+//
+//	{{ dict "a" 1 "b" 2 | jsonPatch `[{"op": "remove", "path": "/b"}]` | toJson }} // Output: {"a":1}
+func (fh *FunctionHandler) JsonPatch(patch any, doc any) any {
+	result, _ := fh.MustJsonPatch(patch, doc)
+	return result
+}
+
+// JsonMergePatch applies a JSON Merge Patch (RFC 7386) to a document: the
+// dicts of the patch are merged recursively into the document, null values
+// remove keys, and any other value, lists included, replaces the original.
+//
+// Parameters:
+//
+//	patch any - the merge patch to apply.
+//	doc any - the document to patch.
+//
+// Returns:
+//
+//	any - the patched document, or nil if an input is invalid JSON.
+//
+// Example:
+//
+//	{{ dict "a" 1 "b" 2 | jsonMergePatch `{"b": null, "c": 3}` | toJson }} // Output: {"a":1,"c":3}
+func (fh *FunctionHandler) JsonMergePatch(patch any, doc any) any {
+	result, _ := fh.MustJsonMergePatch(patch, doc)
+	return result
+}
+
+// JsonPatchDiff computes a JSON Patch (RFC 6902) turning one document into
+// another. Applying the result to 'from' with jsonPatch gives 'to'.
+//
+// Parameters:
+//
+//	from any - the original document.
+//	to any - the target document.
+//
+// Returns:
+//
+//	[]any - the operations of the patch, or nil if an input is invalid JSON.
+//
+// Example:
+//
+//	{{ jsonPatchDiff (dict "a" 1 "b" 2) (dict "a" 3) | toJson }} // Output: [{"op":"remove","path":"/b"},{"op":"replace","path":"/a","value":3}]
+func (fh *FunctionHandler) JsonPatchDiff(from any, to any) []any {
+	result, _ := fh.MustJsonPatchDiff(from, to)
+	return result
+}
+
+// JsonMergePatchDiff computes a JSON Merge Patch (RFC 7386) turning one
+// document into another. Applying the result to 'from' with jsonMergePatch
+// gives 'to'.
+//
+// Parameters:
+//
+//	from any - the original document.
+//	to any - the target document.
+//
+// Returns:
+//
+//	any - the merge patch, or nil if it cannot be computed.
+//
+// Example:
+//
+//	{{ jsonMergePatchDiff (dict "a" 1 "b" 2) (dict "a" 3) | toJson }} // Output: {"a":3,"b":null}
+func (fh *FunctionHandler) JsonMergePatchDiff(from any, to any) any {
+	result, _ := fh.MustJsonMergePatchDiff(from, to)
+	return result
+}
+
+// MustJsonPatch applies a JSON Patch (RFC 6902) to a document, returning an
+// error if an operation is invalid, targets a missing location or fails its
+// test. The operations are applied in order and the patch fails as a whole.
+//
+// Parameters:
+//
+//	patch any - the list of operations to apply.
+//	doc any - the document to patch.
+//
+// Returns:
+//
+//	any - the patched document.
+//	error - error if an input is invalid or an operation fails.
+//
+// Example:
+//
+//	{{ list 1 2 | mustJsonPatch `[{"op": "add", "path": "/-", "value": 3}]` }} // Output: [1 2 3], nil
+func (fh *FunctionHandler) MustJsonPatch(patch any, doc any) (any, error) {
+	operations, err := decodeJsonValue(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON patch: %w", err)
+	}
+	list, ok := operations.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid JSON patch: expected a list of operations, got %s", jsonType(operations))
+	}
+
+	result, err := decodeJsonValue(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	for i, operation := range list {
+		if result, err = applyJsonPatchOperation(result, operation); err != nil {
+			return nil, fmt.Errorf("JSON patch operation %d: %w", i, err)
+		}
+	}
+	return result, nil
+}
+
+// MustJsonMergePatch applies a JSON Merge Patch (RFC 7386) to a document,
+// returning an error if an input is invalid JSON.
+//
+// Parameters:
+//
+//	patch any - the merge patch to apply.
+//	doc any - the document to patch.
+//
+// Returns:
+//
+//	any - the patched document.
+//	error - error if an input is invalid.
+//
+// Example:
+//
+//	{{ dict "a" (dict "b" 1 "c" 2) | mustJsonMergePatch (dict "a" (dict "b" nil)) }} // Output: map[a:map[c:2]], nil
+func (fh *FunctionHandler) MustJsonMergePatch(patch any, doc any) (any, error) {
+	mergePatch, err := decodeJsonValue(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON merge patch: %w", err)
+	}
+	result, err := decodeJsonValue(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	return applyJsonMergePatch(result, mergePatch), nil
+}
+
+// MustJsonPatchDiff computes a JSON Patch (RFC 6902) turning one document
+// into another, returning an error if an input is invalid JSON.
+//
+// Parameters:
+//
+//	from any - the original document.
+//	to any - the target document.
+//
+// Returns:
+//
+//	[]any - the operations of the patch.
+//	error - error if an input is invalid.
+//
+// Example:
+//
+//	{{ mustJsonPatchDiff (list 1 2) (list 1 3 2) | toJson }} // Output: [{"op":"add","path":"/1","value":3}], nil
+func (fh *FunctionHandler) MustJsonPatchDiff(from any, to any) ([]any, error) {
+	source, err := decodeJsonValue(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	target, err := decodeJsonValue(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	return diffJsonPatch([]any{}, "", source, target), nil
+}
+
+// MustJsonMergePatchDiff computes a JSON Merge Patch (RFC 7386) turning one
+// document into another, returning an error if an input is invalid JSON or if
+// 'to' holds null values in dicts, which merge patches cannot express.
+//
+// Parameters:
+//
+//	from any - the original document.
+//	to any - the target document.
+//
+// Returns:
+//
+//	any - the merge patch.
+//	error - error if an input is invalid or the patch cannot be expressed.
+//
+// Example:
+//
+//	{{ mustJsonMergePatchDiff (dict "a" (list 1)) (dict "a" (list 2)) }} // Output: map[a:[2]], nil
+func (fh *FunctionHandler) MustJsonMergePatchDiff(from any, to any) (any, error) {
+	source, err := decodeJsonValue(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	target, err := decodeJsonValue(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	return diffJsonMergePatch("", source, target)
+}
+
+// decodeJsonValue decodes a JSON string, or converts a value to the types
+// produced by decoding JSON, copying it so that it can be modified. Numbers
+// keep their Go type when they are already numbers, and integral numbers
+// decoded from JSON become int64 so that they do not lose precision.
+func decodeJsonValue(v any) (any, error) {
+	switch value := v.(type) {
+	case string:
+		return unmarshalJsonValue([]byte(value))
+	case []byte:
+		return unmarshalJsonValue(value)
+	}
+	return convertJsonValue(reflect.ValueOf(v))
+}
+
+// unmarshalJsonValue decodes JSON data, turning numbers into int64 when they
+// are integral and fit, and into float64 otherwise.
+func unmarshalJsonValue(data []byte) (any, error) {
+	if !json.Valid(data) {
+		// Report the same errors as json.Unmarshal.
+		return nil, json.Unmarshal(data, new(any))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var result any
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	return convertJsonNumbers(result)
+}
+
+// convertJsonNumbers replaces in place the json.Number values of a decoded
+// JSON value by int64 or float64 values.
+func convertJsonNumbers(v any) (any, error) {
+	switch value := v.(type) {
+	case json.Number:
+		if number, err := value.Int64(); err == nil {
+			return number, nil
+		}
+		return value.Float64()
+	case []any:
+		for i, element := range value {
+			converted, err := convertJsonNumbers(element)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = converted
+		}
+	case map[string]any:
+		for key, element := range value {
+			converted, err := convertJsonNumbers(element)
+			if err != nil {
+				return nil, err
+			}
+			value[key] = converted
+		}
+	}
+	return v, nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// convertJsonValue deep-copies a value into the types produced by decoding
+// JSON, keeping numbers as they are. Values that have their own JSON
+// encoding, such as structs or marshalers, are encoded and decoded.
+func convertJsonValue(v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return roundTripJsonValue(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return convertJsonValue(v.Elem())
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type().PkgPath() == "" {
+			return v.Interface(), nil
+		}
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Type().PkgPath() == "" {
+			return v.Interface(), nil
+		}
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(v.Float()) || math.IsInf(v.Float(), 0) {
+			return nil, fmt.Errorf("unsupported number %v", v.Float())
+		}
+		if v.Type().PkgPath() == "" {
+			return v.Interface(), nil
+		}
+		return v.Float(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as base64 strings.
+			return roundTripJsonValue(v)
+		}
+		result := make([]any, v.Len())
+		for i := range result {
+			element, err := convertJsonValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = element
+		}
+		return result, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return roundTripJsonValue(v)
+		}
+		if v.IsNil() {
+			return nil, nil
+		}
+		result := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			element, err := convertJsonValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			result[iter.Key().String()] = element
+		}
+		return result, nil
+	}
+	return roundTripJsonValue(v)
+}
+
+// roundTripJsonValue encodes a value to JSON and decodes it back.
+func roundTripJsonValue(v reflect.Value) (any, error) {
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalJsonValue(data)
+}
+
+// jsonNumber returns the exact value of a decoded JSON number.
+func jsonNumber(v any) (*big.Float, bool) {
+	switch value := reflect.ValueOf(v); value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Float).SetInt64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Float).SetUint64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(value.Float()) || math.IsInf(value.Float(), 0) {
+			return nil, false
+		}
+		return new(big.Float).SetFloat64(value.Float()), true
+	}
+	return nil, false
+}
+
+// compareJsonNumbers compares two decoded JSON numbers by value, regardless
+// of their Go types.
+func compareJsonNumbers(a, b any) int {
+	x, _ := jsonNumber(a)
+	y, _ := jsonNumber(b)
+	return x.Cmp(y)
+}
+
+// jsonEqual reports whether two decoded JSON values are equal, numbers being
+// compared by value regardless of their Go types.
+func jsonEqual(a, b any) bool {
+	if x, ok := jsonNumber(a); ok {
+		y, ok := jsonNumber(b)
+		return ok && x.Cmp(y) == 0
+	}
+
+	switch x := a.(type) {
+	case []any:
+		y, ok := b.([]any)
+		return ok && slices.EqualFunc(x, y, jsonEqual)
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, exists := y[key]
+			if !exists || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// jsonType returns the JSON name of the type of a decoded value.
+func jsonType(v any) string {
+	if _, ok := jsonNumber(v); ok {
+		return "number"
+	}
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	}
+	return "object"
+}
+
+// applyJsonPatchOperation applies a single JSON Patch operation to a document.
+func applyJsonPatchOperation(doc any, operation any) (any, error) {
+	fields, ok := operation.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %s", jsonType(operation))
+	}
+	member := func(name string) (string, error) {
+		value, ok := fields[name].(string)
+		if !ok {
+			return "", fmt.Errorf("missing or invalid %q member", name)
+		}
+		return value, nil
+	}
+
+	op, err := member("op")
+	if err != nil {
+		return nil, err
+	}
+	rawPath, err := member("path")
+	if err != nil {
+		return nil, err
+	}
+	path, err := parseJsonPointer(rawPath)
+	if err != nil {
+		return nil, err
+	}
+
+	value, hasValue := fields["value"]
+	switch op {
+	case "add", "replace", "test":
+		if !hasValue {
+			return nil, fmt.Errorf("missing \"value\" member for %s", op)
+		}
+	case "move", "copy":
+		rawFrom, err := member("from")
+		if err != nil {
+			return nil, err
+		}
+		from, err := parseJsonPointer(rawFrom)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = getJsonPointer(doc, from); err != nil {
+			return nil, err
+		}
+		if op == "copy" {
+			value = copyJsonValue(value)
+			break
+		}
+		if len(from) < len(path) && reflect.DeepEqual(from, path[:len(from)]) {
+			return nil, fmt.Errorf("cannot move %q into one of its children", rawFrom)
+		}
+		if doc, err = updateJsonPointer(doc, from, removeJsonMember); err != nil {
+			return nil, err
+		}
+	case "remove":
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op)
+	}
+
+	switch op {
+	case "add", "move", "copy":
+		return updateJsonPointer(doc, path, func(parent any, token string) (any, error) {
+			return addJsonMember(parent, token, value)
+		})
+	case "remove":
+		return updateJsonPointer(doc, path, removeJsonMember)
+	case "replace":
+		if _, err := getJsonPointer(doc, path); err != nil {
+			return nil, err
+		}
+		return updateJsonPointer(doc, path, func(parent any, token string) (any, error) {
+			switch node := parent.(type) {
+			case nil:
+				return value, nil
+			case []any:
+				index, _ := jsonArrayIndex(token, len(node)-1)
+				node[index] = value
+				return node, nil
+			}
+			parent.(map[string]any)[token] = value
+			return parent, nil
+		})
+	}
+
+	// test
+	current, err := getJsonPointer(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	if !jsonEqual(current, value) {
+		return nil, fmt.Errorf("test failed: value at %q is not %s", rawPath, mustMarshalJson(value))
+	}
+	return doc, nil
+}
+
+// parseJsonPointer splits a JSON Pointer (RFC 6901) into its unescaped
+// reference tokens.
+func parseJsonPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must be empty or start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] != '~' {
+				continue
+			}
+			if j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1') {
+				return nil, fmt.Errorf("invalid JSON pointer %q: invalid escape in %q", pointer, token)
+			}
+			j++
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// formatJsonPointer escapes reference tokens into a JSON Pointer.
+func formatJsonPointer(parent string, token string) string {
+	return parent + "/" + strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// jsonArrayIndex parses an array index of a JSON Pointer, which must be a
+// number without leading zeros, no greater than 'last'.
+func jsonArrayIndex(token string, last int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > last {
+		return 0, fmt.Errorf("array index %d is out of range", index)
+	}
+	return index, nil
+}
+
+// getJsonPointer returns the value referenced by a JSON Pointer.
+func getJsonPointer(doc any, path []string) (any, error) {
+	current := doc
+	for _, token := range path {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			current = value
+		case []any:
+			index, err := jsonArrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("cannot reference %q in %s", token, jsonType(current))
+		}
+	}
+	return current, nil
+}
+
+// updateJsonPointer applies 'fn' to the parent of the location referenced by
+// a JSON Pointer and the last token, and returns the updated document. An
+// empty pointer replaces the whole document.
+func updateJsonPointer(doc any, path []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return fn(nil, "")
+	}
+	if len(path) == 1 {
+		switch doc.(type) {
+		case map[string]any, []any:
+			return fn(doc, path[0])
+		}
+		return nil, fmt.Errorf("cannot reference %q in %s", path[0], jsonType(doc))
+	}
+
+	child, err := getJsonPointer(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	if child, err = updateJsonPointer(child, path[1:], fn); err != nil {
+		return nil, err
+	}
+	if list, ok := doc.([]any); ok {
+		index, _ := jsonArrayIndex(path[0], len(list)-1)
+		list[index] = child
+		return list, nil
+	}
+	doc.(map[string]any)[path[0]] = child
+	return doc, nil
+}
+
+// addJsonMember sets a member of an object, or inserts an element in an
+// array, "-" appending it.
+func addJsonMember(parent any, token string, value any) (any, error) {
+	switch node := parent.(type) {
+	case nil:
+		return value, nil
+	case map[string]any:
+		node[token] = value
+		return node, nil
+	}
+
+	list := parent.([]any)
+	index := len(list)
+	if token != "-" {
+		var err error
+		if index, err = jsonArrayIndex(token, len(list)); err != nil {
+			return nil, err
+		}
+	}
+	list = append(list, nil)
+	copy(list[index+1:], list[index:])
+	list[index] = value
+	return list, nil
+}
+
+// removeJsonMember removes an existing member of an object or element of an
+// array.
+func removeJsonMember(parent any, token string) (any, error) {
+	if parent == nil {
+		return nil, errors.New("cannot remove the root of the document")
+	}
+	if node, ok := parent.(map[string]any); ok {
+		if _, exists := node[token]; !exists {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+		delete(node, token)
+		return node, nil
+	}
+
+	list := parent.([]any)
+	index, err := jsonArrayIndex(token, len(list)-1)
+	if err != nil {
+		return nil, err
+	}
+	return append(list[:index], list[index+1:]...), nil
+}
+
+// copyJsonValue deeply copies a decoded JSON value.
+func copyJsonValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(value))
+		for key, member := range value {
+			result[key] = copyJsonValue(member)
+		}
+		return result
+	case []any:
+		result := make([]any, len(value))
+		for i, element := range value {
+			result[i] = copyJsonValue(element)
+		}
+		return result
+	}
+	return v
+}
+
+// mustMarshalJson encodes a decoded JSON value for error messages.
+func mustMarshalJson(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// applyJsonMergePatch merges a patch into a target as defined by RFC 7386.
+func applyJsonMergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = applyJsonMergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// diffJsonPatch appends to 'operations' the JSON Patch operations turning
+// 'from' into 'to' at 'path'. Lists are compared after skipping their common
+// prefix and suffix, so that single insertions and removals stay small.
+func diffJsonPatch(operations []any, path string, from, to any) []any {
+	if jsonEqual(from, to) {
+		return operations
+	}
+
+	switch source := from.(type) {
+	case map[string]any:
+		target, ok := to.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(source) {
+			if _, exists := target[key]; !exists {
+				operations = append(operations, map[string]any{"op": "remove", "path": formatJsonPointer(path, key)})
+			}
+		}
+		for _, key := range sortedKeys(target) {
+			if value, exists := source[key]; exists {
+				operations = diffJsonPatch(operations, formatJsonPointer(path, key), value, target[key])
+				continue
+			}
+			operations = append(operations, map[string]any{"op": "add", "path": formatJsonPointer(path, key), "value": target[key]})
+		}
+		return operations
+
+	case []any:
+		target, ok := to.([]any)
+		if !ok {
+			break
+		}
+		prefix := 0
+		for prefix < len(source) && prefix < len(target) && jsonEqual(source[prefix], target[prefix]) {
+			prefix++
+		}
+		suffix := 0
+		for suffix < len(source)-prefix && suffix < len(target)-prefix &&
+			jsonEqual(source[len(source)-1-suffix], target[len(target)-1-suffix]) {
+			suffix++
+		}
+
+		removed := source[prefix : len(source)-suffix]
+		added := target[prefix : len(target)-suffix]
+		common := min(len(removed), len(added))
+		for i := 0; i < common; i++ {
+			operations = diffJsonPatch(operations, formatJsonPointer(path, strconv.Itoa(prefix+i)), removed[i], added[i])
+		}
+		for i := common; i < len(removed); i++ {
+			operations = append(operations, map[string]any{"op": "remove", "path": formatJsonPointer(path, strconv.Itoa(prefix+common))})
+		}
+		for i := common; i < len(added); i++ {
+			operations = append(operations, map[string]any{"op": "add", "path": formatJsonPointer(path, strconv.Itoa(prefix+i)), "value": added[i]})
+		}
+		return operations
+	}
+
+	return append(operations, map[string]any{"op": "replace", "path": path, "value": to})
+}
+
+// diffJsonMergePatch computes the merge patch turning 'from' into 'to' at
+// 'path', failing if 'to' holds null members that the patch would remove.
+func diffJsonMergePatch(path string, from, to any) (any, error) {
+	target, ok := to.(map[string]any)
+	if !ok {
+		return to, nil
+	}
+	source, ok := from.(map[string]any)
+	if !ok {
+		// Merged into a non-object, the target is used as is minus its nulls.
+		if err := checkJsonMergePatchNulls(path, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	}
+
+	result := map[string]any{}
+	for key := range source {
+		if _, exists := target[key]; !exists {
+			result[key] = nil
+		}
+	}
+	for key, value := range target {
+		if value == nil {
+			return nil, fmt.Errorf("cannot express null member %q in a JSON merge patch", formatJsonPointer(path, key))
+		}
+		original, exists := source[key]
+		if exists && jsonEqual(original, value) {
+			continue
+		}
+		patch, err := diffJsonMergePatch(formatJsonPointer(path, key), original, value)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = patch
+	}
+	return result, nil
+}
+
+// checkJsonMergePatchNulls fails if an object holds null members at any
+// depth.
+func checkJsonMergePatchNulls(path string, object map[string]any) error {
+	for key, value := range object {
+		if value == nil {
+			return fmt.Errorf("cannot express null member %q in a JSON merge patch", formatJsonPointer(path, key))
+		}
+		if nested, ok := value.(map[string]any); ok {
+			if err := checkJsonMergePatchNulls(formatJsonPointer(path, key), nested); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SchemaViolation describes a value that does not satisfy a JSON Schema.
+type SchemaViolation struct {
+	// InstancePath is the JSON Pointer to the invalid value, empty for the
+	// validated value itself.
+	InstancePath string
+	// Keyword is the schema keyword that failed, such as "type" or "required".
+	Keyword string
+	// Message describes the violation.
+	Message string
+}
+
+// String formats the violation with the location of the invalid value.
+func (v SchemaViolation) String() string {
+	path := v.InstancePath
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + v.Message
+}
+
+// ValidateSchema validates a value against a JSON Schema and returns the
+// violations found, or an empty list if the value is valid. The schema may be
+// a JSON string or a decoded value, while the value is validated as it is: a
+// string is a JSON string, not JSON text. An invalid schema or value gives
+// no violations, use mustValidateSchema to fail instead.
+//
+// The supported subset of draft 2020-12 covers the keywords type, enum,
+// const, required, properties, additionalProperties, items, minItems,
+// maxItems, minLength, maxLength, pattern (RE2 syntax), minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, allOf, anyOf, oneOf and not, as well
+// as the boolean schemas. Annotations, such as title, description, default,
+// format or $defs, and extension keywords prefixed with "x-" are ignored. Any
+// other keyword, such as $ref, patternProperties or multipleOf, makes the
+// schema invalid rather than being silently skipped.
+//
+// Parameters:
+//
+//	schema any - the JSON Schema.
+//	v any - the value to validate.
+//
+// Returns:
+//
+//	[]SchemaViolation - the violations, each with InstancePath, Keyword and Message fields, or nil if the schema or the value is invalid.
+//
+// Example:
+//
+//	{{ range .Values | validateSchema `{"required": ["name"]}` }}{{ .Message }}{{ end }} // Output: missing required property "name"
+func (fh *FunctionHandler) ValidateSchema(schema any, v any) []SchemaViolation {
+	violations, _ := validateJsonSchema(schema, v)
+	return violations
+}
+
+// validateJsonSchema validates a value against a JSON Schema, returning an
+// error if the schema or the value is invalid.
+func validateJsonSchema(schema any, v any) ([]SchemaViolation, error) {
+	compiled, err := compileJsonSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	value, err := convertJsonValue(reflect.ValueOf(v))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	return compiled.validate("", value, []SchemaViolation{}), nil
+}
+
+// MustValidateSchema validates a value against a JSON Schema, see
+// ValidateSchema for the supported keywords, and returns the value
+// unchanged, so that it can be used in a pipeline. It returns an error
+// listing the violations if the value is invalid.
+//
+// Parameters:
+//
+//	schema any - the JSON Schema.
+//	v any - the value to validate.
+//
+// Returns:
+//
+//	any - the value, unchanged.
+//	error - error if the schema is invalid or the value does not satisfy it.
+//
+// Example:
+//
+//	{{ .Values | mustValidateSchema .Schema | toYaml }}
+func (fh *FunctionHandler) MustValidateSchema(schema any, v any) (any, error) {
+	violations, err := validateJsonSchema(schema, v)
+	if err != nil {
+		return nil, err
+	}
+	if len(violations) == 0 {
+		return v, nil
+	}
+
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		messages[i] = violation.String()
+	}
+	return nil, fmt.Errorf("value does not match the schema: %s", strings.Join(messages, "; "))
+}
+
+// jsonSchema is a compiled JSON Schema. A nil field means that its keyword
+// is absent.
+type jsonSchema struct {
+	always *bool
+
+	types                []string
+	enum                 []any
+	constant             *any
+	required             []string
+	properties           map[string]*jsonSchema
+	additionalProperties *jsonSchema
+	items                *jsonSchema
+	minItems, maxItems   *int
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	minimum, maximum     any
+	exclusiveMinimum     any
+	exclusiveMaximum     any
+	allOf, anyOf, oneOf  []*jsonSchema
+	not                  *jsonSchema
+}
+
+// jsonSchemaTypes are the names accepted by the "type" keyword.
+var jsonSchemaTypes = map[string]bool{"null": true, "boolean": true, "object": true, "array": true, "number": true, "string": true, "integer": true}
+
+// compileJsonSchema decodes and compiles a JSON Schema.
+func compileJsonSchema(schema any) (*jsonSchema, error) {
+	decoded, err := decodeJsonValue(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return compileJsonSubschema("", decoded)
+}
+
+// compileJsonSubschema compiles the schema found at 'path' in the root
+// schema.
+func compileJsonSubschema(path string, schema any) (*jsonSchema, error) {
+	if always, ok := schema.(bool); ok {
+		return &jsonSchema{always: &always}, nil
+	}
+	keywords, ok := schema.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid schema at %q: expected an object or a boolean, got %s", path, jsonType(schema))
+	}
+
+	result := &jsonSchema{}
+	for _, keyword := range sortedKeys(keywords) {
+		value := keywords[keyword]
+		keywordPath := formatJsonPointer(path, keyword)
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("invalid schema at %q: "+format, append([]any{keywordPath}, args...)...)
+		}
+
+		var err error
+		switch keyword {
+		case "title", "description", "default", "examples", "deprecated", "readOnly", "writeOnly",
+			"$schema", "$id", "$anchor", "$comment", "$vocabulary", "$defs", "definitions",
+			"format", "contentEncoding", "contentMediaType", "contentSchema":
+			// Annotations do not affect validation.
+
+		case "type":
+			switch types := value.(type) {
+			case string:
+				result.types = []string{types}
+			case []any:
+				for _, name := range types {
+					str, _ := name.(string)
+					result.types = append(result.types, str)
+				}
+			default:
+				return nil, fail("expected a string or a list of strings")
+			}
+			for _, name := range result.types {
+				if !jsonSchemaTypes[name] {
+					return nil, fail("unknown type %q", name)
+				}
+			}
+
+		case "enum":
+			list, ok := value.([]any)
+			if !ok {
+				return nil, fail("expected a list")
+			}
+			result.enum = list
+
+		case "const":
+			result.constant = &value
+
+		case "required":
+			list, ok := value.([]any)
+			if !ok {
+				return nil, fail("expected a list of strings")
+			}
+			for _, name := range list {
+				str, ok := name.(string)
+				if !ok {
+					return nil, fail("expected a list of strings")
+				}
+				result.required = append(result.required, str)
+			}
+
+		case "properties":
+			properties, ok := value.(map[string]any)
+			if !ok {
+				return nil, fail("expected an object")
+			}
+			result.properties = make(map[string]*jsonSchema, len(properties))
+			for name, property := range properties {
+				if result.properties[name], err = compileJsonSubschema(formatJsonPointer(keywordPath, name), property); err != nil {
+					return nil, err
+				}
+			}
+
+		case "additionalProperties":
+			result.additionalProperties, err = compileJsonSubschema(keywordPath, value)
+		case "items":
+			result.items, err = compileJsonSubschema(keywordPath, value)
+		case "not":
+			result.not, err = compileJsonSubschema(keywordPath, value)
+
+		case "allOf", "anyOf", "oneOf":
+			list, ok := value.([]any)
+			if !ok || len(list) == 0 {
+				return nil, fail("expected a non-empty list of schemas")
+			}
+			schemas := make([]*jsonSchema, len(list))
+			for i, subschema := range list {
+				if schemas[i], err = compileJsonSubschema(formatJsonPointer(keywordPath, strconv.Itoa(i)), subschema); err != nil {
+					return nil, err
+				}
+			}
+			switch keyword {
+			case "allOf":
+				result.allOf = schemas
+			case "anyOf":
+				result.anyOf = schemas
+			default:
+				result.oneOf = schemas
+			}
+
+		case "minItems", "maxItems", "minLength", "maxLength":
+			number, ok := jsonNumber(value)
+			if !ok || number.Sign() < 0 || !number.IsInt() {
+				return nil, fail("expected a non-negative integer")
+			}
+			limit64, accuracy := number.Int64()
+			if accuracy != big.Exact || int64(int(limit64)) != limit64 {
+				return nil, fail("expected a non-negative integer")
+			}
+			limit := int(limit64)
+			switch keyword {
+			case "minItems":
+				result.minItems = &limit
+			case "maxItems":
+				result.maxItems = &limit
+			case "minLength":
+				result.minLength = &limit
+			default:
+				result.maxLength = &limit
+			}
+
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			if _, ok := jsonNumber(value); !ok {
+				return nil, fail("expected a number")
+			}
+			switch keyword {
+			case "minimum":
+				result.minimum = value
+			case "maximum":
+				result.maximum = value
+			case "exclusiveMinimum":
+				result.exclusiveMinimum = value
+			default:
+				result.exclusiveMaximum = value
+			}
+
+		case "pattern":
+			str, ok := value.(string)
+			if !ok {
+				return nil, fail("expected a string")
+			}
+			if result.pattern, err = regexp.Compile(str); err != nil {
+				return nil, fail("%w", err)
+			}
+
+		default:
+			// Other keywords, such as patternProperties or multipleOf, would
+			// silently accept invalid values if they were ignored. Extension
+			// keywords prefixed with "x-" are annotations.
+			if !strings.HasPrefix(keyword, "x-") {
+				return nil, fail("%s is not supported", keyword)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// validate appends to 'violations' those of a decoded JSON value located at
+// 'path'.
+func (s *jsonSchema) validate(path string, value any, violations []SchemaViolation) []SchemaViolation {
+	violation := func(keyword, format string, args ...any) {
+		violations = append(violations, SchemaViolation{InstancePath: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.always != nil {
+		if !*s.always {
+			violation("false", "no value is allowed")
+		}
+		return violations
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(name string) bool { return jsonSchemaHasType(value, name) }) {
+		violation("type", "expected %s, got %s", strings.Join(s.types, " or "), jsonType(value))
+		return violations
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(allowed any) bool { return jsonEqual(allowed, value) }) {
+		violation("enum", "value must be one of %s", mustMarshalJson(s.enum))
+	}
+	if s.constant != nil && !jsonEqual(*s.constant, value) {
+		violation("const", "value must be %s", mustMarshalJson(*s.constant))
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		for _, name := range s.required {
+			if _, exists := typed[name]; !exists {
+				violation("required", "missing required property %q", name)
+			}
+		}
+		for _, name := range sortedKeys(typed) {
+			if property, ok := s.properties[name]; ok {
+				violations = property.validate(formatJsonPointer(path, name), typed[name], violations)
+			} else if s.additionalProperties != nil {
+				if s.additionalProperties.always != nil && !*s.additionalProperties.always {
+					violations = append(violations, SchemaViolation{InstancePath: formatJsonPointer(path, name), Keyword: "additionalProperties", Message: fmt.Sprintf("property %q is not allowed", name)})
+					continue
+				}
+				violations = s.additionalProperties.validate(formatJsonPointer(path, name), typed[name], violations)
+			}
+		}
+
+	case []any:
+		if s.minItems != nil && len(typed) < *s.minItems {
+			violation("minItems", "expected at least %d items, got %d", *s.minItems, len(typed))
+		}
+		if s.maxItems != nil && len(typed) > *s.maxItems {
+			violation("maxItems", "expected at most %d items, got %d", *s.maxItems, len(typed))
+		}
+		if s.items != nil {
+			for i, item := range typed {
+				violations = s.items.validate(formatJsonPointer(path, strconv.Itoa(i)), item, violations)
+			}
+		}
+
+	case string:
+		length := utf8.RuneCountInString(typed)
+		if s.minLength != nil && length < *s.minLength {
+			violation("minLength", "expected at least %d characters, got %d", *s.minLength, length)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			violation("maxLength", "expected at most %d characters, got %d", *s.maxLength, length)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(typed) {
+			violation("pattern", "value does not match pattern %q", s.pattern.String())
+		}
+
+	default:
+		if _, ok := jsonNumber(typed); !ok {
+			break
+		}
+		if s.minimum != nil && compareJsonNumbers(typed, s.minimum) < 0 {
+			violation("minimum", "expected a value greater than or equal to %v, got %v", s.minimum, typed)
+		}
+		if s.maximum != nil && compareJsonNumbers(typed, s.maximum) > 0 {
+			violation("maximum", "expected a value less than or equal to %v, got %v", s.maximum, typed)
+		}
+		if s.exclusiveMinimum != nil && compareJsonNumbers(typed, s.exclusiveMinimum) <= 0 {
+			violation("exclusiveMinimum", "expected a value greater than %v, got %v", s.exclusiveMinimum, typed)
+		}
+		if s.exclusiveMaximum != nil && compareJsonNumbers(typed, s.exclusiveMaximum) >= 0 {
+			violation("exclusiveMaximum", "expected a value less than %v, got %v", s.exclusiveMaximum, typed)
+		}
+	}
+
+	for _, subschema := range s.allOf {
+		violations = subschema.validate(path, value, violations)
+	}
+	if s.anyOf != nil && jsonSchemaMatches(s.anyOf, value) == 0 {
+		violation("anyOf", "value does not match any schema of anyOf")
+	}
+	if s.oneOf != nil {
+		if matches := jsonSchemaMatches(s.oneOf, value); matches != 1 {
+			violation("oneOf", "value must match exactly one schema of oneOf, matched %d", matches)
+		}
+	}
+	if s.not != nil && len(s.not.validate(path, value, nil)) == 0 {
+		violation("not", "value must not match the schema of not")
+	}
+	return violations
+}
+
+// jsonSchemaMatches counts the schemas that a value satisfies.
+func jsonSchemaMatches(schemas []*jsonSchema, value any) int {
+	matches := 0
+	for _, schema := range schemas {
+		if len(schema.validate("", value, nil)) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// jsonSchemaHasType reports whether a decoded JSON value has a JSON Schema
+// type, integers being numbers without a fractional part.
+func jsonSchemaHasType(value any, name string) bool {
+	if number, ok := jsonNumber(value); ok && name == "integer" {
+		return number.IsInt()
+	}
+	return jsonType(value) == name
+}
+
+// ToCanonicalJson encodes a value into canonical JSON as defined by the JSON
+// Canonicalization Scheme (RFC 8785): no whitespace, object keys sorted by
+// their UTF-16 code units, numbers written like ECMAScript does and strings
+// escaped minimally, without HTML escaping. Equal values always produce the
+// same bytes.
+//
+// Parameters:
+//
+//	v any - the value to encode.
+//
+// Returns:
+//
+//	string - the canonical JSON, or an empty string if the value cannot be encoded.
+//
+// Example:
+//
+//	{{ dict "b" 1.0 "a" "<x>" | toCanonicalJson }} // Output: {"a":"<x>","b":1}
+func (fh *FunctionHandler) ToCanonicalJson(v any) string {
+	result, _ := fh.MustToCanonicalJson(v)
+	return result
+}
+
+// MustToCanonicalJson encodes a value into canonical JSON (RFC 8785),
+// returning an error if the value cannot be encoded to JSON.
+//
+// Parameters:
+//
+//	v any - the value to encode.
+//
+// Returns:
+//
+//	string - the canonical JSON.
+//	error - error if the value cannot be encoded.
+//
+// Example:
+//
+//	{{ list 1e21 0.000001 1e-7 | mustToCanonicalJson }} // Output: [1e+21,0.000001,1e-7], nil
+func (fh *FunctionHandler) MustToCanonicalJson(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode canonical JSON: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("unable to encode canonical JSON: %w", err)
+	}
+
+	var builder strings.Builder
+	if err := writeCanonicalJson(&builder, value); err != nil {
+		return "", fmt.Errorf("unable to encode canonical JSON: %w", err)
+	}
+	return builder.String(), nil
+}
+
+// HashValue returns the hexadecimal SHA-256 digest of the canonical JSON of
+// a value, see toCanonicalJson. The digest only depends on the content of
+// the value, not on the order of keys or the Go types of numbers, which
+// makes it suitable for checksum annotations.
+//
+// Parameters:
+//
+//	v any - the value to hash.
+//
+// Returns:
+//
+//	string - the hexadecimal digest, or an empty string if the value cannot be encoded.
+//
+// Example:
+//
+//	{{ dict "b" 2 "a" 1 | hashValue }} // Output: "43258cff783fe7036d8a43033f830adfc60ec037382473548ac742b888292777"
+func (fh *FunctionHandler) HashValue(v any) string {
+	result, _ := fh.MustHashValueWith("sha256", v)
+	return result
+}
+
+// HashValueWith returns the hexadecimal digest of the canonical JSON of a
+// value computed with the given algorithm: md5, sha1, sha256, sha384 or
+// sha512.
+//
+// Parameters:
+//
+//	algorithm string - the name of the hash algorithm.
+//	v any - the value to hash.
+//
+// Returns:
+//
+//	string - the hexadecimal digest, or an empty string on error.
+//
+// Example:
+//
+//	{{ .Values.config | hashValueWith "sha1" }}
+func (fh *FunctionHandler) HashValueWith(algorithm string, v any) string {
+	result, _ := fh.MustHashValueWith(algorithm, v)
+	return result
+}
+
+// MustHashValueWith returns the hexadecimal digest of the canonical JSON of a
+// value computed with the given algorithm, returning an error if the
+// algorithm is unknown or the value cannot be encoded.
+//
+// Parameters:
+//
+//	algorithm string - the name of the hash algorithm: md5, sha1, sha256, sha384 or sha512.
+//	v any - the value to hash.
+//
+// Returns:
+//
+//	string - the hexadecimal digest.
+//	error - error if the algorithm is unknown or the value cannot be encoded.
+//
+// Example:
+//
+//	{{ list 1 2 | mustHashValueWith "md5" }} // Output: "f79408e5ca998cd53faf44af31e6eb45", nil
+func (fh *FunctionHandler) MustHashValueWith(algorithm string, v any) (string, error) {
+	newHash, ok := hashAlgorithms[strings.ReplaceAll(strings.ToLower(algorithm), "-", "")]
+	if !ok {
+		return "", fmt.Errorf("unknown hash algorithm %q", algorithm)
+	}
+
+	canonical, err := fh.MustToCanonicalJson(v)
+	if err != nil {
+		return "", err
+	}
+
+	hash := newHash()
+	hash.Write([]byte(canonical))
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashAlgorithms are the algorithms supported by hashValueWith, by name.
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// writeCanonicalJson writes a value decoded with json.Number numbers as
+// canonical JSON.
+func writeCanonicalJson(builder *strings.Builder, value any) error {
+	switch typed := value.(type) {
+	case nil:
+		builder.WriteString("null")
+	case bool:
+		builder.WriteString(strconv.FormatBool(typed))
+	case json.Number:
+		number, err := strconv.ParseFloat(typed.String(), 64)
+		if err != nil {
+			return fmt.Errorf("number %s cannot be represented as a double: %w", typed, err)
+		}
+		builder.WriteString(formatCanonicalNumber(number))
+	case string:
+		writeCanonicalString(builder, typed)
+	case []any:
+		builder.WriteByte('[')
+		for i, element := range typed {
+			if i > 0 {
+				builder.WriteByte(',')
+			}
+			if err := writeCanonicalJson(builder, element); err != nil {
+				return err
+			}
+		}
+		builder.WriteByte(']')
+	case map[string]any:
+		keys := sortedKeys(typed)
+		// Keys are sorted by UTF-16 code units, which differs from the byte
+		// order of UTF-8 for characters above U+FFFF.
+		sort.SliceStable(keys, func(i, j int) bool {
+			return slices.Compare(utf16.Encode([]rune(keys[i])), utf16.Encode([]rune(keys[j]))) < 0
+		})
+		builder.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				builder.WriteByte(',')
+			}
+			writeCanonicalString(builder, key)
+			builder.WriteByte(':')
+			if err := writeCanonicalJson(builder, typed[key]); err != nil {
+				return err
+			}
+		}
+		builder.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected value of type %T", value)
+	}
+	return nil
+}
+
+// writeCanonicalString writes a JSON string, escaping only quotes,
+// backslashes and control characters.
+func writeCanonicalString(builder *strings.Builder, str string) {
+	builder.WriteByte('"')
+	for _, r := range str {
+		switch r {
+		case '"':
+			builder.WriteString(`\"`)
+		case '\\':
+			builder.WriteString(`\\`)
+		case '\b':
+			builder.WriteString(`\b`)
+		case '\f':
+			builder.WriteString(`\f`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(builder, `\u%04x`, r)
+				continue
+			}
+			builder.WriteRune(r)
+		}
+	}
+	builder.WriteByte('"')
+}
+
+// formatCanonicalNumber formats a finite number like ECMAScript's
+// Number.prototype.toString: the shortest digits that round-trip, in
+// positional notation for exponents from -7 to 20 and in scientific notation
+// otherwise.
+func formatCanonicalNumber(number float64) string {
+	if number == 0 {
+		return "0"
+	}
+	sign := ""
+	if number < 0 {
+		sign = "-"
+		number = -number
+	}
+
+	// The shortest representation "d.ddde±x" gives the digits and the
+	// position n of the decimal point relative to them.
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(number, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exponent)
+	n, k := e+1, len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+
+	exp := "e+" + strconv.Itoa(n-1)
+	if n-1 < 0 {
+		exp = "e-" + strconv.Itoa(1-n)
+	}
+	if k == 1 {
+		return sign + digits + exp
+	}
+	return sign + digits[:1] + "." + digits[1:] + exp
+}
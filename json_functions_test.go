@@ -0,0 +1,458 @@
+package sprout
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonPatch(t *testing.T) {
+	tests := testCases{
+		{"TestAdd", `{{ dict "a" 1 | jsonPatch "[{\"op\": \"add\", \"path\": \"/b\", \"value\": [1]}]" | toJson }}`, `{"a":1,"b":[1]}`, nil},
+		{"TestAddArrayElement", `{{ list 1 3 | jsonPatch "[{\"op\": \"add\", \"path\": \"/1\", \"value\": 2}]" | toJson }}`, `[1,2,3]`, nil},
+		{"TestAppend", `{{ list 1 2 | jsonPatch "[{\"op\": \"add\", \"path\": \"/-\", \"value\": 3}]" | toJson }}`, `[1,2,3]`, nil},
+		{"TestRemove", `{{ dict "a" 1 "b" 2 | jsonPatch "[{\"op\": \"remove\", \"path\": \"/b\"}]" | toJson }}`, `{"a":1}`, nil},
+		{"TestRemoveArrayElement", `{{ .V | jsonPatch "[{\"op\": \"remove\", \"path\": \"/spec/ports/0\"}]" | toJson }}`, `{"spec":{"ports":[443]}}`, map[string]any{"V": `{"spec": {"ports": [80, 443]}}`}},
+		{"TestReplace", `{{ .V | jsonPatch "[{\"op\": \"replace\", \"path\": \"/spec/replicas\", \"value\": 3}]" | toJson }}`, `{"spec":{"replicas":3}}`, map[string]any{"V": map[string]any{"spec": map[string]any{"replicas": 1}}}},
+		{"TestReplaceRoot", `{{ dict "a" 1 | jsonPatch "[{\"op\": \"replace\", \"path\": \"\", \"value\": [1]}]" | toJson }}`, `[1]`, nil},
+		{"TestMove", `{{ .V | jsonPatch "[{\"op\": \"move\", \"from\": \"/a/b\", \"path\": \"/c\"}]" | toJson }}`, `{"a":{},"c":1}`, map[string]any{"V": `{"a": {"b": 1}}`}},
+		{"TestMoveArrayElement", `{{ list 1 2 3 4 | jsonPatch "[{\"op\": \"move\", \"from\": \"/1\", \"path\": \"/3\"}]" | toJson }}`, `[1,3,4,2]`, nil},
+		{"TestCopy", `{{ .V | jsonPatch "[{\"op\": \"copy\", \"from\": \"/a\", \"path\": \"/b\"}, {\"op\": \"add\", \"path\": \"/b/y\", \"value\": 2}]" | toJson }}`, `{"a":{"x":1},"b":{"x":1,"y":2}}`, map[string]any{"V": `{"a": {"x": 1}}`}},
+		{"TestTest", `{{ .V | jsonPatch "[{\"op\": \"test\", \"path\": \"/a\", \"value\": [1, {\"b\": null}]}]" | toJson }}`, `{"a":[1,{"b":null}]}`, map[string]any{"V": `{"a": [1, {"b": null}]}`}},
+		{"TestEscapedPointer", `{{ .V | jsonPatch "[{\"op\": \"remove\", \"path\": \"/metadata/annotations/example.com~1name\"}, {\"op\": \"replace\", \"path\": \"/~0tilde\", \"value\": 2}]" | toJson }}`, `{"metadata":{"annotations":{}},"~tilde":2}`, map[string]any{"V": `{"metadata": {"annotations": {"example.com/name": "x"}}, "~tilde": 1}`}},
+		{"TestPatchAsList", `{{ .V | jsonPatch (list (dict "op" "add" "path" "/b" "value" 2)) | toJson }}`, `{"a":1,"b":2}`, map[string]any{"V": map[string]any{"a": 1}}},
+		{"TestDocumentNotModified", `{{ $v := dict "a" 1 }}{{ $_ := jsonPatch "[{\"op\": \"remove\", \"path\": \"/a\"}]" $v }}{{ $v | toJson }}`, `{"a":1}`, nil},
+		{"TestFailedTest", `{{ dict "a" 1 | jsonPatch "[{\"op\": \"test\", \"path\": \"/a\", \"value\": 2}]" }}`, "<no value>", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestJsonPatchRFCExamples(t *testing.T) {
+	handler := NewFunctionHandler()
+	tests := []struct {
+		name, doc, patch, expected, err string
+	}{
+		{"A.1", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"baz":"qux","foo":"bar"}`, ""},
+		{"A.2", `{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo":["bar","qux","baz"]}`, ""},
+		{"A.3", `{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo":"bar"}`, ""},
+		{"A.4", `{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo":["bar","baz"]}`, ""},
+		{"A.5", `{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz":"boo","foo":"bar"}`, ""},
+		{"A.6", `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`, `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, ""},
+		{"A.7", `{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, ""},
+		{"A.8", `{"baz": "qux", "foo": ["a", 2, "c"]}`, `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`, `{"baz":"qux","foo":["a",2,"c"]}`, ""},
+		{"A.9", `{"baz": "qux"}`, `[{"op": "test", "path": "/baz", "value": "bar"}]`, "", `JSON patch operation 0: test failed: value at "/baz" is not "bar"`},
+		{"A.10", `{"foo": "bar"}`, `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`, `{"child":{"grandchild":{}},"foo":"bar"}`, ""},
+		{"A.11", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`, `{"baz":"qux","foo":"bar"}`, ""},
+		{"A.12", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`, "", `JSON patch operation 0: member "baz" not found`},
+		{"A.14", `{"/": 9, "~1": 10}`, `[{"op": "test", "path": "/~01", "value": 10}]`, `{"/":9,"~1":10}`, ""},
+		{"A.15", `{"/": 9, "~1": 10}`, `[{"op": "test", "path": "/~01", "value": "10"}]`, "", `JSON patch operation 0: test failed: value at "/~01" is not "10"`},
+		{"A.16", `{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`, `{"foo":["bar",["abc","def"]]}`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := handler.MustJsonPatch(test.patch, test.doc)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, handler.ToRawJson(result))
+		})
+	}
+}
+
+func TestMustJsonPatch(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ list 1 2 | mustJsonPatch "[{\"op\": \"add\", \"path\": \"/-\", \"value\": 3}]" }}`, "[1 2 3]", nil}, ""},
+		{testCase{"TestInvalidPatchJson", `{{ dict | mustJsonPatch "[" }}`, "", nil}, "invalid JSON patch: unexpected end of JSON input"},
+		{testCase{"TestPatchNotAList", `{{ dict | mustJsonPatch (dict "op" "add") }}`, "", nil}, "invalid JSON patch: expected a list of operations, got object"},
+		{testCase{"TestInvalidDocument", `{{ "{" | mustJsonPatch "[]" }}`, "", nil}, "invalid JSON document: unexpected end of JSON input"},
+		{testCase{"TestOperationNotAnObject", `{{ dict | mustJsonPatch "[1]" }}`, "", nil}, "JSON patch operation 0: expected an object, got number"},
+		{testCase{"TestUnknownOperation", `{{ dict | mustJsonPatch "[{\"op\": \"merge\", \"path\": \"\"}]" }}`, "", nil}, `JSON patch operation 0: unknown operation "merge"`},
+		{testCase{"TestMissingPath", `{{ dict | mustJsonPatch "[{\"op\": \"add\", \"value\": 1}]" }}`, "", nil}, `JSON patch operation 0: missing or invalid "path" member`},
+		{testCase{"TestMissingValue", `{{ dict | mustJsonPatch "[{\"op\": \"add\", \"path\": \"/a\"}]" }}`, "", nil}, `JSON patch operation 0: missing "value" member for add`},
+		{testCase{"TestMissingFrom", `{{ dict | mustJsonPatch "[{\"op\": \"copy\", \"path\": \"/a\"}]" }}`, "", nil}, `JSON patch operation 0: missing or invalid "from" member`},
+		{testCase{"TestInvalidPointer", `{{ dict | mustJsonPatch "[{\"op\": \"remove\", \"path\": \"a\"}]" }}`, "", nil}, `invalid JSON pointer "a": must be empty or start with '/'`},
+		{testCase{"TestInvalidEscape", `{{ dict | mustJsonPatch "[{\"op\": \"remove\", \"path\": \"/a~2\"}]" }}`, "", nil}, `invalid JSON pointer "/a~2": invalid escape in "a~2"`},
+		{testCase{"TestRemoveMissing", `{{ dict "a" 1 | mustJsonPatch "[{\"op\": \"remove\", \"path\": \"/b\"}]" }}`, "", nil}, `JSON patch operation 0: member "b" not found`},
+		{testCase{"TestReplaceMissing", `{{ dict "a" 1 | mustJsonPatch "[{\"op\": \"replace\", \"path\": \"/b\", \"value\": 1}]" }}`, "", nil}, `JSON patch operation 0: member "b" not found`},
+		{testCase{"TestRemoveRoot", `{{ dict | mustJsonPatch "[{\"op\": \"remove\", \"path\": \"\"}]" }}`, "", nil}, "cannot remove the root of the document"},
+		{testCase{"TestIndexOutOfRange", `{{ list 1 | mustJsonPatch "[{\"op\": \"add\", \"path\": \"/2\", \"value\": 1}]" }}`, "", nil}, "array index 2 is out of range"},
+		{testCase{"TestLeadingZero", `{{ list 1 2 | mustJsonPatch "[{\"op\": \"remove\", \"path\": \"/01\"}]" }}`, "", nil}, `invalid array index "01"`},
+		{testCase{"TestAppendWithRemove", `{{ list 1 | mustJsonPatch "[{\"op\": \"remove\", \"path\": \"/-\"}]" }}`, "", nil}, `invalid array index "-"`},
+		{testCase{"TestScalarParent", `{{ dict "a" 1 | mustJsonPatch "[{\"op\": \"add\", \"path\": \"/a/b\", \"value\": 1}]" }}`, "", nil}, `cannot reference "b" in number`},
+		{testCase{"TestMoveIntoChild", `{{ dict "a" (dict) | mustJsonPatch "[{\"op\": \"move\", \"from\": \"/a\", \"path\": \"/a/b\"}]" }}`, "", nil}, `cannot move "/a" into one of its children`},
+		{testCase{"TestAtomic", `{{ dict "a" 1 | mustJsonPatch "[{\"op\": \"remove\", \"path\": \"/a\"}, {\"op\": \"remove\", \"path\": \"/a\"}]" }}`, "", nil}, `JSON patch operation 1: member "a" not found`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestJsonMergePatch(t *testing.T) {
+	tests := testCases{
+		{"TestMerge", `{{ dict "a" 1 "b" 2 | jsonMergePatch "{\"b\": null, \"c\": 3}" | toJson }}`, `{"a":1,"c":3}`, nil},
+		{"TestNested", `{{ .V | jsonMergePatch .P | toJson }}`, `{"spec":{"replicas":3,"template":{"image":"app:2"}}}`, map[string]any{
+			"V": map[string]any{"spec": map[string]any{"replicas": 1, "paused": true, "template": map[string]any{"image": "app:1"}}},
+			"P": map[string]any{"spec": map[string]any{"replicas": 3, "paused": nil, "template": map[string]any{"image": "app:2"}}},
+		}},
+		{"TestListsReplaced", `{{ dict "a" (list 1 2) | jsonMergePatch (dict "a" (list 3)) | toJson }}`, `{"a":[3]}`, nil},
+		{"TestNonObjectPatch", `{{ dict "a" 1 | jsonMergePatch "[1]" | toJson }}`, `[1]`, nil},
+		{"TestNonObjectTarget", `{{ list 1 | jsonMergePatch "{\"a\": {\"b\": null, \"c\": 1}}" | toJson }}`, `{"a":{"c":1}}`, nil},
+		{"TestJsonStrings", `{{ jsonMergePatch "{\"a\": {\"b\": 2}}" "{\"a\": {\"c\": 1}}" | toJson }}`, `{"a":{"b":2,"c":1}}`, nil},
+		{"TestInvalidInput", `{{ dict | jsonMergePatch "{" }}`, "<no value>", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestJsonMergePatchRFCExamples(t *testing.T) {
+	handler := NewFunctionHandler()
+	tests := [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		result, err := handler.MustJsonMergePatch(test[1], test[0])
+		assert.NoError(t, err)
+		assert.Equal(t, test[2], handler.ToRawJson(result), "%s + %s", test[0], test[1])
+	}
+}
+
+func TestMustJsonMergePatch(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ dict "a" (dict "b" 1 "c" 2) | mustJsonMergePatch (dict "a" (dict "b" nil)) }}`, "map[a:map[c:2]]", nil}, ""},
+		{testCase{"TestInvalidPatch", `{{ dict | mustJsonMergePatch "{" }}`, "", nil}, "invalid JSON merge patch: unexpected end of JSON input"},
+		{testCase{"TestInvalidDocument", `{{ "{" | mustJsonMergePatch "{}" }}`, "", nil}, "invalid JSON document: unexpected end of JSON input"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestJsonPatchDiff(t *testing.T) {
+	tests := testCases{
+		{"TestObjects", `{{ jsonPatchDiff (dict "a" 1 "b" 2) (dict "a" 3 "c" 4) | toJson }}`, `[{"op":"remove","path":"/b"},{"op":"replace","path":"/a","value":3},{"op":"add","path":"/c","value":4}]`, nil},
+		{"TestEqual", `{{ jsonPatchDiff (dict "a" (list 1)) "{\"a\": [1]}" | toJson }}`, `[]`, nil},
+		{"TestNested", `{{ jsonPatchDiff .A .B | toJson }}`, `[{"op":"replace","path":"/spec/template/image","value":"app:2"}]`, map[string]any{
+			"A": `{"spec": {"replicas": 1, "template": {"image": "app:1"}}}`,
+			"B": `{"spec": {"replicas": 1, "template": {"image": "app:2"}}}`,
+		}},
+		{"TestListInsert", `{{ jsonPatchDiff (list 1 2 3) (list 1 4 2 3) | toJson }}`, `[{"op":"add","path":"/1","value":4}]`, nil},
+		{"TestListRemove", `{{ jsonPatchDiff (list 1 2 3 4) (list 1 4) | toJson }}`, `[{"op":"remove","path":"/1"},{"op":"remove","path":"/1"}]`, nil},
+		{"TestListChange", `{{ jsonPatchDiff (list (dict "a" 1) 2) (list (dict "a" 2) 2) | toJson }}`, `[{"op":"replace","path":"/0/a","value":2}]`, nil},
+		{"TestTypeChange", `{{ jsonPatchDiff (dict "a" (list 1)) (dict "a" (dict "b" 1)) | toJson }}`, `[{"op":"replace","path":"/a","value":{"b":1}}]`, nil},
+		{"TestRoot", `{{ jsonPatchDiff 1 "[1]" | toJson }}`, `[{"op":"replace","path":"","value":[1]}]`, nil},
+		{"TestEscapedKeys", `{{ jsonPatchDiff (dict) (dict "a/b~c" 1) | toJson }}`, `[{"op":"add","path":"/a~1b~0c","value":1}]`, nil},
+		{"TestInvalidInput", `{{ jsonPatchDiff "{" (dict) }}`, "[]", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestJsonPatchDiffRoundTrip(t *testing.T) {
+	handler := NewFunctionHandler()
+	pairs := [][2]string{
+		{`{"a": 1, "b": [1, 2, 3], "c": {"d": "e"}}`, `{"a": 2, "b": [3, 2, 1, 0], "c": {"f": null}}`},
+		{`[1, 2, 3, 4, 5]`, `[0, 1, 3, 5, 6]`},
+		{`[{"a": 1}, {"b": 2}]`, `[{"b": 2}]`},
+		{`{"x": [1, {"y": [1, 2]}]}`, `{"x": [1, {"y": [2]}, 3]}`},
+		{`null`, `{"a": 1}`},
+	}
+
+	for _, pair := range pairs {
+		patch, err := handler.MustJsonPatchDiff(pair[0], pair[1])
+		assert.NoError(t, err)
+		result, err := handler.MustJsonPatch(patch, pair[0])
+		assert.NoError(t, err)
+		expected, _ := decodeJsonValue(pair[1])
+		assert.Equal(t, expected, result, "%s -> %s", pair[0], pair[1])
+	}
+}
+
+func TestJsonPatchNumbers(t *testing.T) {
+	tests := testCases{
+		{"TestIntegerToYaml", `{{ dict "mem" 1048576 | jsonPatch "[]" | toYaml }}`, "mem: 1048576", nil},
+		{"TestLargeIntegerPatch", `{{ .V | jsonPatch "[{\"op\": \"add\", \"path\": \"/b\", \"value\": 9007199254740995}]" | toJson }}`, `{"a":9007199254740993,"b":9007199254740995}`, map[string]any{"V": `{"a": 9007199254740993}`}},
+		{"TestLargeIntegerMergePatch", `{{ dict "b" 1 | jsonMergePatch "{\"a\": 9007199254740993}" | toJson }}`, `{"a":9007199254740993,"b":1}`, nil},
+		{"TestLargeIntegerPatchDiff", `{{ jsonPatchDiff (dict "a" 1) "{\"a\": 9007199254740993}" | toJson }}`, `[{"op":"replace","path":"/a","value":9007199254740993}]`, nil},
+		{"TestLargeIntegerMergePatchDiff", `{{ jsonMergePatchDiff "{\"a\": 9007199254740992}" "{\"a\": 9007199254740993}" | toJson }}`, `{"a":9007199254740993}`, nil},
+		{"TestTestAcrossTypes", `{{ dict "a" 1 "b" 2.5 | jsonPatch "[{\"op\": \"test\", \"path\": \"\", \"value\": {\"a\": 1.0, \"b\": 2.5}}]" | toJson }}`, `{"a":1,"b":2.5}`, nil},
+		{"TestEqualAcrossTypes", `{{ jsonPatchDiff (dict "a" 1) "{\"a\": 1.0}" | toJson }}`, `[]`, nil},
+		{"TestSchemaLargeInteger", `{{ range validateSchema "{\"maximum\": 9007199254740992}" .V }}{{ .Message }}{{ end }}`, "expected a value less than or equal to 9007199254740992, got 9007199254740993", map[string]any{"V": int64(9007199254740993)}},
+		{"TestSchemaIntegerType", `{{ validateSchema "{\"type\": \"integer\", \"minimum\": 1}" .V }}`, "[]", map[string]any{"V": uint8(3)}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestJsonPatchKeepsNumberTypes(t *testing.T) {
+	handler := NewFunctionHandler()
+
+	result, err := handler.MustJsonPatch("[]", map[string]any{"int": 1, "int32": int32(2), "uint": uint64(math.MaxUint64), "float": 1.5, "list": []int{1, 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"int": 1, "int32": int32(2), "uint": uint64(math.MaxUint64), "float": 1.5, "list": []any{1, 2}}, result)
+
+	result, err = handler.MustJsonMergePatch(`{"a": 9007199254740993, "b": 1.5, "c": 1e400}`, map[string]any{})
+	assert.ErrorContains(t, err, "1e400")
+	assert.Nil(t, result)
+
+	result, err = handler.MustJsonMergePatch(`{"a": 9007199254740993, "b": 1.5, "c": 1e300}`, map[string]any{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": int64(9007199254740993), "b": 1.5, "c": 1e300}, result)
+
+	source := map[string]any{"a": []any{1}}
+	_, err = handler.MustJsonPatch(`[{"op": "add", "path": "/a/-", "value": 2}]`, source)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": []any{1}}, source, "the input must not be modified")
+}
+
+func TestJsonMergePatchDiff(t *testing.T) {
+	tests := testCases{
+		{"TestObjects", `{{ jsonMergePatchDiff (dict "a" 1 "b" 2) (dict "a" 3) | toJson }}`, `{"a":3,"b":null}`, nil},
+		{"TestNested", `{{ jsonMergePatchDiff .A .B | toJson }}`, `{"spec":{"paused":null,"template":{"image":"app:2"}}}`, map[string]any{
+			"A": `{"spec": {"replicas": 1, "paused": true, "template": {"image": "app:1", "port": 80}}}`,
+			"B": `{"spec": {"replicas": 1, "template": {"image": "app:2", "port": 80}}}`,
+		}},
+		{"TestEqual", `{{ jsonMergePatchDiff (dict "a" 1) (dict "a" 1) | toJson }}`, `{}`, nil},
+		{"TestList", `{{ jsonMergePatchDiff (dict "a" (list 1)) (dict "a" (list 1 2)) | toJson }}`, `{"a":[1,2]}`, nil},
+		{"TestNonObject", `{{ jsonMergePatchDiff (dict "a" 1) (list 1) | toJson }}`, `[1]`, nil},
+		{"TestNullMember", `{{ jsonMergePatchDiff (dict) (dict "a" nil) }}`, "<no value>", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustJsonMergePatchDiff(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ mustJsonMergePatchDiff (dict "a" (list 1)) (dict "a" (list 2)) }}`, "map[a:[2]]", nil}, ""},
+		{testCase{"TestNullMember", `{{ mustJsonMergePatchDiff (dict "a" 1) (dict "a" nil) }}`, "", nil}, `cannot express null member "/a" in a JSON merge patch`},
+		{testCase{"TestNestedNullMember", `{{ mustJsonMergePatchDiff (dict "a" 1) (dict "a" (dict "b" (dict "c" nil))) }}`, "", nil}, `cannot express null member "/a/b/c" in a JSON merge patch`},
+		{testCase{"TestInvalidDocument", `{{ mustJsonMergePatchDiff (dict) "{" }}`, "", nil}, "invalid JSON document: unexpected end of JSON input"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustJsonPatchDiff(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ mustJsonPatchDiff (list 1 2) (list 1 3 2) | toJson }}`, `[{"op":"add","path":"/1","value":3}]`, nil}, ""},
+		{testCase{"TestInvalidDocument", `{{ mustJsonPatchDiff "[" (dict) }}`, "", nil}, "invalid JSON document: unexpected end of JSON input"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+const schemaTestService = `{
+  "type": "object",
+  "required": ["name", "port"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "pattern": "^[a-z][a-z0-9-]*$", "maxLength": 63},
+    "port": {"type": "integer", "minimum": 1, "maximum": 65535},
+    "protocol": {"enum": ["TCP", "UDP"]},
+    "replicas": {"type": ["integer", "null"], "exclusiveMinimum": 0},
+    "hosts": {"type": "array", "minItems": 1, "items": {"type": "string", "minLength": 1}},
+    "tls": {"oneOf": [{"type": "boolean"}, {"type": "object", "required": ["secret"]}]},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}}
+  }
+}`
+
+func TestValidateSchema(t *testing.T) {
+	data := map[string]any{"S": schemaTestService}
+	tests := testCases{
+		{"TestValid", `{{ dict "name" "web" "port" 80 "hosts" (list "a.example.com") "tls" true | validateSchema .S }}`, "[]", data},
+		{"TestValidJsonValue", `{{ validateSchema .S (fromJson "{\"name\": \"web\", \"port\": 443, \"replicas\": null, \"tls\": {\"secret\": \"x\"}}") }}`, "[]", data},
+		{"TestRequired", `{{ range dict "name" "web" | validateSchema .S }}{{ .Keyword }} {{ .Message }}{{ end }}`, `required missing required property "port"`, data},
+		{"TestType", `{{ range dict "name" 1 "port" 1.5 | validateSchema .S }}{{ .InstancePath }}: {{ .Message }};{{ end }}`, "/name: expected string, got number;/port: expected integer, got number;", data},
+		{"TestConstraints", `{{ range .V | validateSchema .S }}{{ . }};{{ end }}`, `/hosts: expected at least 1 items, got 0;/name: value does not match pattern "^[a-z][a-z0-9-]*$";/port: expected a value less than or equal to 65535, got 70000;/protocol: value must be one of ["TCP","UDP"];/replicas: expected a value greater than 0, got 0;`, map[string]any{
+			"S": schemaTestService,
+			"V": map[string]any{"name": "Web", "port": 70000, "protocol": "HTTP", "replicas": 0, "hosts": []string{}},
+		}},
+		{"TestNested", `{{ range .V | validateSchema .S }}{{ . }};{{ end }}`, `/hosts/1: expected at least 1 characters, got 0;/labels/team: expected string, got number;`, map[string]any{
+			"S": schemaTestService,
+			"V": map[string]any{"name": "web", "port": 80, "hosts": []any{"a", ""}, "labels": map[string]any{"app": "web", "team": 1}},
+		}},
+		{"TestAdditionalProperties", `{{ range dict "name" "web" "port" 80 "extra" 1 | validateSchema .S }}{{ .Keyword }} {{ . }}{{ end }}`, `additionalProperties /extra: property "extra" is not allowed`, data},
+		{"TestOneOf", `{{ range dict "name" "web" "port" 80 "tls" (dict) | validateSchema .S }}{{ . }}{{ end }}`, "/tls: value must match exactly one schema of oneOf, matched 0", data},
+		{"TestRootViolation", `{{ range validateSchema .S (list) }}{{ . }}{{ end }}`, "(root): expected object, got array", data},
+		{"TestAnyOfAndNot", `{{ range validateSchema "{\"anyOf\": [{\"type\": \"string\"}, {\"minimum\": 10}], \"not\": {\"const\": \"x\"}}" .V }}{{ .Keyword }};{{ end }}`, "anyOf;", map[string]any{"V": 5}},
+		{"TestNot", `{{ range validateSchema "{\"not\": {\"const\": \"x\"}}" "x" }}{{ . }}{{ end }}`, "(root): value must not match the schema of not", nil},
+		{"TestAllOf", `{{ range validateSchema "{\"allOf\": [{\"minLength\": 2}, {\"maxLength\": 3}]}" "abcd" }}{{ . }}{{ end }}`, "(root): expected at most 3 characters, got 4", nil},
+		{"TestBooleanSchemas", `{{ range validateSchema "{\"properties\": {\"a\": true, \"b\": false}}" (dict "a" 1 "b" 2) }}{{ . }}{{ end }}`, "/b: no value is allowed", nil},
+		{"TestConst", `{{ range validateSchema "{\"const\": {\"a\": [1]}}" (dict "a" (list 2)) }}{{ . }}{{ end }}`, `(root): value must be {"a":[1]}`, nil},
+		{"TestIgnoredKeywords", `{{ validateSchema "{\"$schema\": \"https://json-schema.org/draft/2020-12/schema\", \"title\": \"x\", \"minimum\": 1}" "a" }}`, "[]", nil},
+		{"TestInvalidSchema", `{{ validateSchema "{\"type\": 1}" "a" }}`, "[]", nil},
+		{"TestRootString", `{{ validateSchema "{\"type\": \"string\"}" "hello" }}`, "[]", nil},
+		{"TestRootNumericString", `{{ range validateSchema "{\"type\": \"string\", \"maxLength\": 2}" "123" }}{{ . }}{{ end }}`, "(root): expected at most 2 characters, got 3", nil},
+		{"TestRootStringPattern", `{{ validateSchema "{\"type\": \"string\", \"pattern\": \"^[0-9]+$\"}" "8080" }}`, "[]", nil},
+		{"TestRootNotJson", `{{ range validateSchema "{\"type\": \"object\"}" "{}" }}{{ . }}{{ end }}`, "(root): expected object, got string", nil},
+		{"TestIgnoredAnnotations", `{{ validateSchema "{\"description\": \"d\", \"default\": 1, \"format\": \"email\", \"$defs\": {\"a\": {\"multipleOf\": 2}}, \"x-kubernetes-int-or-string\": true}" "a" }}`, "[]", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestValidateSchemaInvalidSchema(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestInvalidJson", `{{ mustValidateSchema "{" 1 }}`, "", nil}, "invalid schema: unexpected end of JSON input"},
+		{testCase{"TestNotAnObject", `{{ mustValidateSchema "[]" 1 }}`, "", nil}, `invalid schema at "": expected an object or a boolean, got array`},
+		{testCase{"TestUnknownType", `{{ mustValidateSchema "{\"type\": \"int\"}" 1 }}`, "", nil}, `invalid schema at "/type": unknown type "int"`},
+		{testCase{"TestInvalidRequired", `{{ mustValidateSchema "{\"required\": \"a\"}" 1 }}`, "", nil}, `invalid schema at "/required": expected a list of strings`},
+		{testCase{"TestInvalidNested", `{{ mustValidateSchema "{\"properties\": {\"a\": {\"minLength\": -1}}}" 1 }}`, "", nil}, `invalid schema at "/properties/a/minLength": expected a non-negative integer`},
+		{testCase{"TestInvalidPattern", `{{ mustValidateSchema "{\"pattern\": \"(\"}" 1 }}`, "", nil}, `invalid schema at "/pattern": error parsing regexp: missing closing ): ` + "`(`"},
+		{testCase{"TestEmptyOneOf", `{{ mustValidateSchema "{\"oneOf\": []}" 1 }}`, "", nil}, `invalid schema at "/oneOf": expected a non-empty list of schemas`},
+		{testCase{"TestRef", `{{ mustValidateSchema "{\"items\": {\"$ref\": \"#/$defs/a\"}}" 1 }}`, "", nil}, `invalid schema at "/items/$ref": $ref is not supported`},
+		{testCase{"TestPatternProperties", `{{ mustValidateSchema "{\"patternProperties\": {\"^x-\": {}}, \"additionalProperties\": false}" (dict "x-a" 1) }}`, "", nil}, `invalid schema at "/patternProperties": patternProperties is not supported`},
+		{testCase{"TestMultipleOf", `{{ mustValidateSchema "{\"multipleOf\": 2, \"uniqueItems\": true}" (list 3 3) }}`, "", nil}, `invalid schema at "/multipleOf": multipleOf is not supported`},
+		{testCase{"TestUniqueItems", `{{ mustValidateSchema "{\"items\": {\"type\": \"integer\"}, \"uniqueItems\": true}" (list 3 3) }}`, "", nil}, `invalid schema at "/uniqueItems": uniqueItems is not supported`},
+		{testCase{"TestIfThenElse", `{{ mustValidateSchema "{\"if\": {}, \"then\": false}" 1 }}`, "", nil}, `invalid schema at "/if": if is not supported`},
+		{testCase{"TestNestedUnsupported", `{{ mustValidateSchema "{\"properties\": {\"a\": {\"minProperties\": 1}}}" 1 }}`, "", nil}, `invalid schema at "/properties/a/minProperties": minProperties is not supported`},
+		{testCase{"TestUnknownKeyword", `{{ mustValidateSchema "{\"requried\": [\"a\"]}" 1 }}`, "", nil}, `invalid schema at "/requried": requried is not supported`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustValidateSchema(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ dict "name" "web" "port" 80 | mustValidateSchema .S | toJson }}`, `{"name":"web","port":80}`, map[string]any{"S": schemaTestService}}, ""},
+		{testCase{"TestInvalid", `{{ dict "name" "web" "port" "80" "x" 1 | mustValidateSchema .S }}`, "", map[string]any{"S": schemaTestService}}, `value does not match the schema: /port: expected integer, got string; /x: property "x" is not allowed`},
+		{testCase{"TestString", `{{ "8080" | mustValidateSchema "{\"type\": \"string\", \"pattern\": \"^[0-9]+$\"}" }}`, "8080", nil}, ""},
+		{testCase{"TestStringNotNumber", `{{ "123" | mustValidateSchema "{\"type\": \"number\"}" }}`, "", nil}, "value does not match the schema: (root): expected number, got string"},
+		{testCase{"TestInvalidSchema", `{{ 1 | mustValidateSchema "{\"type\": 1}" }}`, "", nil}, `invalid schema at "/type": expected a string or a list of strings`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestValidateSchemaFromGo(t *testing.T) {
+	handler := NewFunctionHandler()
+
+	violations := handler.ValidateSchema(map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"replicas": map[string]any{"type": "integer", "minimum": 1}},
+	}, struct {
+		Replicas int `json:"replicas"`
+	}{Replicas: 0})
+	assert.Equal(t, []SchemaViolation{{
+		InstancePath: "/replicas",
+		Keyword:      "minimum",
+		Message:      "expected a value greater than or equal to 1, got 0",
+	}}, violations)
+}
+
+func TestToCanonicalJson(t *testing.T) {
+	tests := testCases{
+		{"TestSortedKeys", `{{ dict "b" 1 "a" (dict "d" 2 "c" 3) | toCanonicalJson }}`, `{"a":{"c":3,"d":2},"b":1}`, nil},
+		{"TestNoHtmlEscaping", `{{ dict "a" "<x> & y" | toCanonicalJson }}`, `{"a":"<x> & y"}`, nil},
+		{"TestNumberTypes", `{{ list 1 1.0 (int64 1) (float64 1.5) | toCanonicalJson }}`, `[1,1,1,1.5]`, nil},
+		{"TestScalars", `{{ list nil true "x" | toCanonicalJson }}`, `[null,true,"x"]`, nil},
+		{"TestStringEscaping", `{{ .V | toCanonicalJson }}`, "\"\u20ac$\\u000f\\nA'B\\\"\\\\\\\\\\\"/\\u0001\"", map[string]any{"V": "\u20ac$\u000f\nA'B\"\\\\\"/\u0001"}},
+		{"TestUtf16KeyOrder", `{{ .V | toCanonicalJson }}`, "{\"\\r\":1,\"1\":2,\"\u0080\":3,\"\u00f6\":4,\"\u20ac\":5,\"\U0001F600\":6,\"\ufb33\":7}", map[string]any{"V": map[string]any{
+			"\u20ac": 5, "\r": 1, "\ufb33": 7, "1": 2, "\U0001F600": 6, "\u0080": 3, "\u00f6": 4,
+		}}},
+		{"TestStruct", `{{ .V | toCanonicalJson }}`, `{"name":"web","port":80}`, map[string]any{"V": struct {
+			Port int    `json:"port"`
+			Name string `json:"name"`
+		}{80, "web"}}},
+		{"TestInvalidInput", `{{ .V | toCanonicalJson }}`, "", map[string]any{"V": map[string]any{"a": func() {}}}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestFormatCanonicalNumber(t *testing.T) {
+	// Examples from RFC 8785, appendix B.
+	tests := map[float64]string{
+		0:                       "0",
+		math.Copysign(0, -1):    "0",
+		5e-324:                  "5e-324",
+		-5e-324:                 "-5e-324",
+		1.7976931348623157e308:  "1.7976931348623157e+308",
+		-1.7976931348623157e308: "-1.7976931348623157e+308",
+		9007199254740992:        "9007199254740992",
+		-9007199254740992:       "-9007199254740992",
+		295147905179352830000:   "295147905179352830000",
+		9.999999999999997e22:    "9.999999999999997e+22",
+		1e23:                    "1e+23",
+		1e21:                    "1e+21",
+		1e20:                    "100000000000000000000",
+		999999999999999700000:   "999999999999999700000",
+		999999999999999900000:   "999999999999999900000",
+		0.000001:                "0.000001",
+		0.0000012345:            "0.0000012345",
+		1e-7:                    "1e-7",
+		1.5e-7:                  "1.5e-7",
+		123.456:                 "123.456",
+		-0.5:                    "-0.5",
+		100:                     "100",
+	}
+
+	for number, expected := range tests {
+		assert.Equal(t, expected, formatCanonicalNumber(number), "%v", number)
+	}
+}
+
+func TestMustToCanonicalJson(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ list 1e21 0.000001 1e-7 | mustToCanonicalJson }}`, `[1e+21,0.000001,1e-7]`, nil}, ""},
+		{testCase{"TestUnsupportedValue", `{{ .V | mustToCanonicalJson }}`, "", map[string]any{"V": func() {}}}, "unable to encode canonical JSON: json: unsupported type: func()"},
+		{testCase{"TestNaN", `{{ .V | mustToCanonicalJson }}`, "", map[string]any{"V": math.NaN()}}, "unable to encode canonical JSON: json: unsupported value: NaN"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestHashValue(t *testing.T) {
+	tests := testCases{
+		{"TestDict", `{{ dict "b" 2 "a" 1 | hashValue }}`, "43258cff783fe7036d8a43033f830adfc60ec037382473548ac742b888292777", nil},
+		{"TestKeyOrderAndNumberTypes", `{{ eq (.A | hashValue) (.B | fromJson | hashValue) }}`, "true", map[string]any{
+			"A": map[string]any{"replicas": int64(3), "image": "app:1", "ports": []int{80, 443}},
+			"B": `{"ports": [80, 443.0], "image": "app:1", "replicas": 3}`,
+		}},
+		{"TestDifferentValues", `{{ eq (list 1 2 | hashValue) (list 2 1 | hashValue) }}`, "false", nil},
+		{"TestStringAndNumberDiffer", `{{ eq ("1" | hashValue) (1 | hashValue) }}`, "false", nil},
+		{"TestInvalidInput", `{{ .V | hashValue }}`, "", map[string]any{"V": func() {}}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestHashValueWith(t *testing.T) {
+	tests := testCases{
+		{"TestMd5", `{{ list 1 2 | hashValueWith "md5" }}`, "f79408e5ca998cd53faf44af31e6eb45", nil},
+		{"TestSha1", `{{ list 1 2 | hashValueWith "sha1" | len }}`, "40", nil},
+		{"TestSha256", `{{ eq (list 1 2 | hashValueWith "SHA-256") (list 1 2 | hashValue) }}`, "true", nil},
+		{"TestSha384", `{{ list 1 2 | hashValueWith "sha384" | len }}`, "96", nil},
+		{"TestSha512", `{{ list 1 2 | hashValueWith "sha512" | len }}`, "128", nil},
+		{"TestUnknownAlgorithm", `{{ list 1 2 | hashValueWith "crc32" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustHashValueWith(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ list 1 2 | mustHashValueWith "md5" }}`, "f79408e5ca998cd53faf44af31e6eb45", nil}, ""},
+		{testCase{"TestUnknownAlgorithm", `{{ list 1 2 | mustHashValueWith "crc32" }}`, "", nil}, `unknown hash algorithm "crc32"`},
+		{testCase{"TestInvalidValue", `{{ .V | mustHashValueWith "sha256" }}`, "", map[string]any{"V": func() {}}}, "unable to encode canonical JSON"},
+	}
+
+	runMustTestCases(t, tests)
+}
@@ -0,0 +1,974 @@
+package sprout
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// quantityFormat is the notation of a Kubernetes resource quantity, kept
+// through arithmetic so results are written the way their operands were.
+type quantityFormat string
+
+const (
+	quantityBinarySI        quantityFormat = "binarySI"        // 1.5Gi, 512Mi
+	quantityDecimalSI       quantityFormat = "decimalSI"       // 500m, 2k
+	quantityDecimalExponent quantityFormat = "decimalExponent" // 2e3
+)
+
+// quantity is an exact Kubernetes resource quantity.
+type quantity struct {
+	value  *big.Rat
+	format quantityFormat
+}
+
+// quantityRegex matches a Kubernetes resource quantity: a signed decimal
+// number followed by an optional binary suffix, decimal suffix or exponent.
+var quantityRegex = regexp.MustCompile(`^([+-]?(?:\d+(?:\.\d*)?|\.\d+))(Ki|Mi|Gi|Ti|Pi|Ei|[eE][+-]?\d+|[numkMGTPE])?$`)
+
+// quantityBinarySuffixes and quantityDecimalSuffixes map the quantity suffixes
+// to the power of 2 and the power of 10 they stand for.
+var (
+	quantityBinarySuffixes  = map[string]int{"Ki": 10, "Mi": 20, "Gi": 30, "Ti": 40, "Pi": 50, "Ei": 60}
+	quantityDecimalSuffixes = map[string]int{"n": -9, "u": -6, "m": -3, "": 0, "k": 3, "M": 6, "G": 9, "T": 12, "P": 15, "E": 18}
+)
+
+// dns1123InvalidRunsRegex matches the runs of characters that are not allowed
+// in a DNS-1123 label.
+var dns1123InvalidRunsRegex = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ParseQuantity parses a Kubernetes resource quantity such as "500m", "1.5Gi"
+// or "2e3" into its numeric value. Invalid quantities return 0.
+//
+// Parameters:
+//
+//	value any - the quantity to parse, as a string or a number.
+//
+// Returns:
+//
+//	float64 - the value of the quantity.
+//
+// Example:
+//
+//	{{ "1.5Gi" | parseQuantity }} // Output: 1.610612736e+09
+//	{{ "500m" | parseQuantity }} // Output: 0.5
+func (fh *FunctionHandler) ParseQuantity(value any) float64 {
+	result, _ := fh.MustParseQuantity(value)
+	return result
+}
+
+// MustParseQuantity parses a Kubernetes resource quantity into its numeric
+// value, returning an error if the quantity is invalid.
+//
+// Parameters:
+//
+//	value any - the quantity to parse, as a string or a number.
+//
+// Returns:
+//
+//	float64 - the value of the quantity.
+//	error - error if the quantity is malformed.
+//
+// Example:
+//
+//	{{ "250m" | mustParseQuantity }} // Output: 0.25, nil
+func (fh *FunctionHandler) MustParseQuantity(value any) (float64, error) {
+	q, err := parseQuantity(value)
+	if err != nil {
+		return 0, err
+	}
+	result, _ := q.value.Float64()
+	return result, nil
+}
+
+// FormatQuantity writes 'value' as a canonical Kubernetes resource quantity
+// in 'format', one of "binarySI" (1536Mi), "decimalSI" (1500m) or
+// "decimalExponent" (2e3). Values are written with an integer mantissa and
+// the largest suffix possible, precision below the nano unit being rounded
+// up, as Kubernetes does. Invalid values or formats produce an empty string.
+//
+// Parameters:
+//
+//	format string - the notation to use.
+//	value any - the value or quantity to format.
+//
+// Returns:
+//
+//	string - the canonical quantity.
+//
+// Example:
+//
+//	{{ 1610612736 | formatQuantity "binarySI" }} // Output: "1536Mi"
+//	{{ 0.5 | formatQuantity "decimalSI" }} // Output: "500m"
+func (fh *FunctionHandler) FormatQuantity(format string, value any) string {
+	result, _ := fh.MustFormatQuantity(format, value)
+	return result
+}
+
+// MustFormatQuantity writes 'value' as a canonical Kubernetes resource
+// quantity in 'format', returning an error if the value or the format is
+// invalid.
+//
+// Parameters:
+//
+//	format string - the notation to use, "binarySI", "decimalSI" or "decimalExponent".
+//	value any - the value or quantity to format.
+//
+// Returns:
+//
+//	string - the canonical quantity.
+//	error - error if the value is not a quantity or the format is unknown.
+//
+// Example:
+//
+//	{{ "1.5Gi" | mustFormatQuantity "decimalSI" }} // Output: "1610612736", nil
+func (fh *FunctionHandler) MustFormatQuantity(format string, value any) (string, error) {
+	q, err := parseQuantity(value)
+	if err != nil {
+		return "", err
+	}
+
+	switch quantityFormat(format) {
+	case quantityBinarySI, quantityDecimalSI, quantityDecimalExponent:
+		q.format = quantityFormat(format)
+	default:
+		return "", fmt.Errorf("unknown quantity format: %s", format)
+	}
+	return q.String(), nil
+}
+
+// AddQuantity adds Kubernetes resource quantities and writes the sum in the
+// notation of the first one. Invalid quantities produce an empty string.
+//
+// Parameters:
+//
+//	values ...any - the quantities to add.
+//
+// Returns:
+//
+//	string - the canonical sum.
+//
+// Example:
+//
+//	{{ addQuantity "1Gi" "512Mi" }} // Output: "1536Mi"
+//	{{ addQuantity "250m" "1.5" }} // Output: "1750m"
+func (fh *FunctionHandler) AddQuantity(values ...any) string {
+	result, _ := fh.MustAddQuantity(values...)
+	return result
+}
+
+// MustAddQuantity adds Kubernetes resource quantities and writes the sum in
+// the notation of the first one, returning an error if a quantity is invalid.
+//
+// Parameters:
+//
+//	values ...any - the quantities to add.
+//
+// Returns:
+//
+//	string - the canonical sum.
+//	error - error if a quantity is malformed.
+//
+// Example:
+//
+//	{{ mustAddQuantity "100m" "200m" }} // Output: "300m", nil
+func (fh *FunctionHandler) MustAddQuantity(values ...any) (string, error) {
+	sum := quantity{value: new(big.Rat), format: quantityDecimalSI}
+	for i, value := range values {
+		q, err := parseQuantity(value)
+		if err != nil {
+			return "", err
+		}
+		if i == 0 {
+			sum.format = q.format
+		}
+		sum.value.Add(sum.value, q.value)
+	}
+	return sum.String(), nil
+}
+
+// SubQuantity subtracts the Kubernetes resource quantity 'b' from 'a' and
+// writes the difference in the notation of 'a'. Invalid quantities produce an
+// empty string.
+//
+// Parameters:
+//
+//	a any - the quantity to subtract from.
+//	b any - the quantity to subtract.
+//
+// Returns:
+//
+//	string - the canonical difference.
+//
+// Example:
+//
+//	{{ subQuantity "2Gi" "512Mi" }} // Output: "1536Mi"
+func (fh *FunctionHandler) SubQuantity(a, b any) string {
+	result, _ := fh.MustSubQuantity(a, b)
+	return result
+}
+
+// MustSubQuantity subtracts the Kubernetes resource quantity 'b' from 'a' and
+// writes the difference in the notation of 'a', returning an error if a
+// quantity is invalid.
+//
+// Parameters:
+//
+//	a any - the quantity to subtract from.
+//	b any - the quantity to subtract.
+//
+// Returns:
+//
+//	string - the canonical difference.
+//	error - error if a quantity is malformed.
+//
+// Example:
+//
+//	{{ mustSubQuantity "1" "250m" }} // Output: "750m", nil
+func (fh *FunctionHandler) MustSubQuantity(a, b any) (string, error) {
+	qa, err := parseQuantity(a)
+	if err != nil {
+		return "", err
+	}
+	qb, err := parseQuantity(b)
+	if err != nil {
+		return "", err
+	}
+	qa.value.Sub(qa.value, qb.value)
+	return qa.String(), nil
+}
+
+// MulQuantity multiplies the Kubernetes resource quantity 'q' by 'factor' and
+// writes the product in the notation of 'q'. Invalid quantities or factors
+// produce an empty string.
+//
+// Parameters:
+//
+//	factor any - the number to multiply by.
+//	q any - the quantity to multiply.
+//
+// Returns:
+//
+//	string - the canonical product.
+//
+// Example:
+//
+//	{{ "512Mi" | mulQuantity 3 }} // Output: "1536Mi"
+//	{{ "300m" | mulQuantity 0.5 }} // Output: "150m"
+func (fh *FunctionHandler) MulQuantity(factor any, q any) string {
+	result, _ := fh.MustMulQuantity(factor, q)
+	return result
+}
+
+// MustMulQuantity multiplies the Kubernetes resource quantity 'q' by 'factor'
+// and writes the product in the notation of 'q', returning an error if the
+// quantity or the factor is invalid.
+//
+// Parameters:
+//
+//	factor any - the number to multiply by.
+//	q any - the quantity to multiply.
+//
+// Returns:
+//
+//	string - the canonical product.
+//	error - error if the quantity or the factor is malformed.
+//
+// Example:
+//
+//	{{ "1Gi" | mustMulQuantity 2 }} // Output: "2Gi", nil
+func (fh *FunctionHandler) MustMulQuantity(factor any, q any) (string, error) {
+	qf, err := parseQuantity(factor)
+	if err != nil {
+		return "", err
+	}
+	qq, err := parseQuantity(q)
+	if err != nil {
+		return "", err
+	}
+	qq.value.Mul(qq.value, qf.value)
+	return qq.String(), nil
+}
+
+// CmpQuantity compares the Kubernetes resource quantities 'a' and 'b'. It
+// returns -1 if 'a' is smaller than 'b', 0 if they are equal and 1 if 'a' is
+// larger. Invalid quantities compare as 0.
+//
+// Parameters:
+//
+//	a any - the first quantity.
+//	b any - the second quantity.
+//
+// Returns:
+//
+//	int - the result of the comparison.
+//
+// Example:
+//
+//	{{ cmpQuantity "1Gi" "1000Mi" }} // Output: 1
+//	{{ cmpQuantity "500m" "0.5" }} // Output: 0
+func (fh *FunctionHandler) CmpQuantity(a, b any) int {
+	result, _ := fh.MustCmpQuantity(a, b)
+	return result
+}
+
+// MustCmpQuantity compares the Kubernetes resource quantities 'a' and 'b',
+// returning an error if a quantity is invalid.
+//
+// Parameters:
+//
+//	a any - the first quantity.
+//	b any - the second quantity.
+//
+// Returns:
+//
+//	int - -1 if 'a' is smaller than 'b', 0 if they are equal, 1 otherwise.
+//	error - error if a quantity is malformed.
+//
+// Example:
+//
+//	{{ mustCmpQuantity "100m" "1" }} // Output: -1, nil
+func (fh *FunctionHandler) MustCmpQuantity(a, b any) (int, error) {
+	qa, err := parseQuantity(a)
+	if err != nil {
+		return 0, err
+	}
+	qb, err := parseQuantity(b)
+	if err != nil {
+		return 0, err
+	}
+	return qa.value.Cmp(qb.value), nil
+}
+
+// Dns1123Label turns 'str' into a valid DNS-1123 label, as required for most
+// Kubernetes resource names: lowercase alphanumeric characters and '-',
+// starting and ending with an alphanumeric character, at most 63 characters.
+// Diacritics are removed and runs of other characters replaced with '-'.
+// Longer names are shortened with a hash suffix, as truncWithHash does.
+//
+// Parameters:
+//
+//	str string - the string to sanitize.
+//
+// Returns:
+//
+//	string - the DNS-1123 label, or an empty string if 'str' has no valid
+//	         characters.
+//
+// Example:
+//
+//	{{ "My_App (Café)" | dns1123Label }} // Output: "my-app-cafe"
+func (fh *FunctionHandler) Dns1123Label(str string) string {
+	result, _ := fh.MustDns1123Label(str)
+	return result
+}
+
+// MustDns1123Label turns 'str' into a valid DNS-1123 label like Dns1123Label,
+// returning an error if no label can be built from it, as an empty string is
+// not a valid name.
+//
+// Parameters:
+//
+//	str string - the string to sanitize.
+//
+// Returns:
+//
+//	string - the DNS-1123 label.
+//	error - error if 'str' has no valid characters.
+//
+// Example:
+//
+//	{{ "My_App" | mustDns1123Label }} // Output: "my-app", nil
+//	{{ "日本" | mustDns1123Label }} // Error
+func (fh *FunctionHandler) MustDns1123Label(str string) (string, error) {
+	label := sanitizeDns1123Label(str)
+	if label == "" {
+		return "", fmt.Errorf("cannot make a DNS-1123 label from %q: no valid characters", str)
+	}
+	return truncWithHash(63, label), nil
+}
+
+// Dns1123Subdomain turns 'str' into a valid DNS-1123 subdomain, as required
+// for Kubernetes resources such as ConfigMaps or Secrets: dot separated
+// DNS-1123 labels, at most 253 characters. Empty labels are removed and
+// longer names are shortened with a hash suffix, as truncWithHash does.
+//
+// Parameters:
+//
+//	str string - the string to sanitize.
+//
+// Returns:
+//
+//	string - the DNS-1123 subdomain, or an empty string if 'str' has no valid
+//	         characters.
+//
+// Example:
+//
+//	{{ "Config..Example.COM_" | dns1123Subdomain }} // Output: "config.example.com"
+func (fh *FunctionHandler) Dns1123Subdomain(str string) string {
+	result, _ := fh.MustDns1123Subdomain(str)
+	return result
+}
+
+// MustDns1123Subdomain turns 'str' into a valid DNS-1123 subdomain like
+// Dns1123Subdomain, returning an error if no label can be built from it, as
+// an empty string is not a valid name.
+//
+// Parameters:
+//
+//	str string - the string to sanitize.
+//
+// Returns:
+//
+//	string - the DNS-1123 subdomain.
+//	error - error if 'str' has no valid characters.
+//
+// Example:
+//
+//	{{ "Config.Example.COM" | mustDns1123Subdomain }} // Output: "config.example.com", nil
+//	{{ "..." | mustDns1123Subdomain }} // Error
+func (fh *FunctionHandler) MustDns1123Subdomain(str string) (string, error) {
+	var labels []string
+	for _, label := range strings.Split(str, ".") {
+		if label = sanitizeDns1123Label(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	if len(labels) == 0 {
+		return "", fmt.Errorf("cannot make a DNS-1123 subdomain from %q: no valid characters", str)
+	}
+	return truncWithHash(253, strings.Join(labels, ".")), nil
+}
+
+// TruncWithHash truncates 'str' to at most 'length' bytes, the way Helm charts
+// truncate names to 63 characters, but replaces the end of truncated strings
+// with '-' and the first 8 hexadecimal digits of the SHA-256 of the whole
+// string. Different long names sharing a prefix thus stay distinct, and the
+// same name always gives the same result.
+//
+// Parameters:
+//
+//	length int - the maximum length of the result.
+//	str string - the string to truncate.
+//
+// Returns:
+//
+//	string - the string itself if short enough, or its truncated form.
+//
+// Example:
+//
+//	{{ "my-release-with-a-very-long-name" | truncWithHash 20 }} // Output: "my-release-e618f21e"
+func (fh *FunctionHandler) TruncWithHash(length int, str string) string {
+	return truncWithHash(length, str)
+}
+
+// parseQuantity reads a Kubernetes resource quantity from a string or a
+// number.
+func parseQuantity(value any) (quantity, error) {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = strings.TrimSpace(v)
+	case float32:
+		str = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		str = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		str = fmt.Sprint(value)
+	}
+
+	matches := quantityRegex.FindStringSubmatch(str)
+	if matches == nil {
+		return quantity{}, fmt.Errorf("invalid quantity: %q", str)
+	}
+
+	number, ok := new(big.Rat).SetString(matches[1])
+	if !ok {
+		return quantity{}, fmt.Errorf("invalid quantity: %q", str)
+	}
+
+	suffix := matches[2]
+	if exponent, ok := quantityBinarySuffixes[suffix]; ok {
+		number.Mul(number, new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), uint(exponent))))
+		return quantity{value: number, format: quantityBinarySI}, nil
+	}
+	if exponent, ok := quantityDecimalSuffixes[suffix]; ok {
+		return quantity{value: number.Mul(number, pow10Rat(exponent)), format: quantityDecimalSI}, nil
+	}
+
+	exponent, err := strconv.Atoi(strings.TrimPrefix(suffix[1:], "+"))
+	if err != nil || exponent < -1000 || exponent > 1000 {
+		return quantity{}, fmt.Errorf("invalid quantity exponent: %q", str)
+	}
+	return quantity{value: number.Mul(number, pow10Rat(exponent)), format: quantityDecimalExponent}, nil
+}
+
+// String writes the quantity in its canonical form: an integer mantissa with
+// the largest suffix possible. Binary quantities below 1024 or that are not a
+// whole number are written with decimal suffixes.
+func (q quantity) String() string {
+	value := q.value
+	if value.Sign() == 0 {
+		return "0"
+	}
+
+	// Kubernetes does not represent values below the nano unit and rounds
+	// them up, away from zero for negative values.
+	nanos := new(big.Rat).Mul(value, pow10Rat(9))
+	if !nanos.IsInt() {
+		rounded := new(big.Int).Quo(nanos.Num(), nanos.Denom())
+		rounded.Add(rounded, big.NewInt(int64(value.Sign())))
+		value = new(big.Rat).Quo(new(big.Rat).SetInt(rounded), pow10Rat(9))
+	}
+
+	if q.format == quantityBinarySI && value.IsInt() && value.Num().CmpAbs(big.NewInt(1024)) >= 0 {
+		mantissa := new(big.Int).Abs(value.Num())
+		suffix := ""
+		for _, s := range []string{"Ki", "Mi", "Gi", "Ti", "Pi", "Ei"} {
+			if new(big.Int).And(mantissa, big.NewInt(1023)).Sign() != 0 {
+				break
+			}
+			mantissa.Rsh(mantissa, 10)
+			suffix = s
+		}
+		if value.Sign() < 0 {
+			mantissa.Neg(mantissa)
+		}
+		return mantissa.String() + suffix
+	}
+
+	// Find the largest power of 1000 keeping the mantissa an integer.
+	exponent := -9
+	mantissa := new(big.Rat).Mul(value, pow10Rat(9)).Num()
+	thousand := big.NewInt(1000)
+	for exponent < 18 {
+		quotient, remainder := new(big.Int).QuoRem(mantissa, thousand, new(big.Int))
+		if remainder.Sign() != 0 {
+			break
+		}
+		mantissa = quotient
+		exponent += 3
+	}
+
+	if q.format == quantityDecimalExponent {
+		if exponent == 0 {
+			return mantissa.String()
+		}
+		return mantissa.String() + "e" + strconv.Itoa(exponent)
+	}
+	for suffix, e := range quantityDecimalSuffixes {
+		if e == exponent {
+			return mantissa.String() + suffix
+		}
+	}
+	return mantissa.String()
+}
+
+// pow10Rat returns 10 raised to 'exponent' as a rational number.
+func pow10Rat(exponent int) *big.Rat {
+	if exponent < 0 {
+		return new(big.Rat).Inv(pow10Rat(-exponent))
+	}
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil))
+}
+
+// sanitizeDns1123Label lowercases 'str', removes its diacritics, replaces runs
+// of characters not allowed in a DNS-1123 label with '-' and trims the
+// characters a label cannot start or end with.
+func sanitizeDns1123Label(str string) string {
+	label := strings.ToLower(removeDiacritics(str))
+	label = dns1123InvalidRunsRegex.ReplaceAllString(label, "-")
+	return strings.Trim(label, "-")
+}
+
+// truncWithHash truncates 'str' to 'length' bytes, replacing its end with a
+// hash of the whole string when it is too long.
+func truncWithHash(length int, str string) string {
+	if len(str) <= length {
+		return str
+	}
+
+	sum := sha256.Sum256([]byte(str))
+	hash := hex.EncodeToString(sum[:])[:8]
+	if length <= len(hash)+1 {
+		return hash[:max(length, 0)]
+	}
+
+	prefix := str[:length-len(hash)-1]
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return strings.TrimRight(prefix, "-.") + "-" + hash
+}
+
+// imageReference is a parsed container image reference, such as
+// "registry.example.com:5000/team/app:1.2.3@sha256:...".
+type imageReference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// Regular expressions validating the parts of an image reference, following
+// the grammar of the distribution project used by Docker and containerd.
+var (
+	imageRegistryRegex   = regexp.MustCompile(`^(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*|\[[0-9a-fA-F:.]+\])(?::[0-9]+)?$`)
+	imageRepositoryRegex = regexp.MustCompile(`^[a-z0-9]+(?:(?:\.|_|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:\.|_|__|-+)[a-z0-9]+)*)*$`)
+	imageTagRegex        = regexp.MustCompile(`^\w[\w.-]{0,127}$`)
+	imageDigestRegex     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+// ImageParse splits a container image reference into a dict with the keys
+// "registry", "repository", "tag" and "digest". Parts missing from the
+// reference are empty strings; use imageNormalize first to get the Docker Hub
+// defaults. Invalid references produce an empty dict.
+//
+// Parameters:
+//
+//	ref string - the image reference to parse.
+//
+// Returns:
+//
+//	map[string]any - the parts of the reference.
+//
+// Example:
+//
+//	{{ (imageParse "registry.example.com:5000/team/app:1.2.3").registry }} // Output: "registry.example.com:5000"
+func (fh *FunctionHandler) ImageParse(ref string) map[string]any {
+	result, _ := fh.MustImageParse(ref)
+	return result
+}
+
+// MustImageParse splits a container image reference into a dict with the keys
+// "registry", "repository", "tag" and "digest", returning an error if the
+// reference is invalid.
+//
+// Parameters:
+//
+//	ref string - the image reference to parse.
+//
+// Returns:
+//
+//	map[string]any - the parts of the reference.
+//	error - error if the reference is malformed.
+//
+// Example:
+//
+//	{{ mustImageParse "nginx:1.25" }} // Output: map[digest: registry: repository:nginx tag:1.25], nil
+func (fh *FunctionHandler) MustImageParse(ref string) (map[string]any, error) {
+	image, err := parseImageReference(ref)
+	if err != nil {
+		return map[string]any{}, err
+	}
+	return map[string]any{
+		"registry":   image.registry,
+		"repository": image.repository,
+		"tag":        image.tag,
+		"digest":     image.digest,
+	}, nil
+}
+
+// ImageNormalize writes a container image reference in its fully qualified
+// form, the way Docker resolves short names: the registry defaults to
+// "docker.io", official images get the "library/" namespace and the tag
+// defaults to "latest" when there is no digest. Invalid references produce an
+// empty string.
+//
+// Parameters:
+//
+//	ref string - the image reference to normalize.
+//
+// Returns:
+//
+//	string - the normalized reference.
+//
+// Example:
+//
+//	{{ "nginx" | imageNormalize }} // Output: "docker.io/library/nginx:latest"
+func (fh *FunctionHandler) ImageNormalize(ref string) string {
+	result, _ := fh.MustImageNormalize(ref)
+	return result
+}
+
+// MustImageNormalize writes a container image reference in its fully
+// qualified form, returning an error if the reference is invalid.
+//
+// Parameters:
+//
+//	ref string - the image reference to normalize.
+//
+// Returns:
+//
+//	string - the normalized reference.
+//	error - error if the reference is malformed.
+//
+// Example:
+//
+//	{{ "bitnami/redis:7.2" | mustImageNormalize }} // Output: "docker.io/bitnami/redis:7.2", nil
+func (fh *FunctionHandler) MustImageNormalize(ref string) (string, error) {
+	image, err := parseImageReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if image.registry == "" || image.registry == "index.docker.io" {
+		image.registry = "docker.io"
+	}
+	if image.registry == "docker.io" && !strings.Contains(image.repository, "/") {
+		image.repository = "library/" + image.repository
+	}
+	if image.tag == "" && image.digest == "" {
+		image.tag = "latest"
+	}
+	return image.String(), nil
+}
+
+// ImageBuild builds a container image reference from a dict with the keys
+// "registry", "repository", "tag" and "digest", as returned by imageParse.
+// Only the repository is required. Invalid parts produce an empty string.
+//
+// Parameters:
+//
+//	parts any - the dict of the parts of the reference.
+//
+// Returns:
+//
+//	string - the image reference.
+//
+// Example:
+//
+//	{{ imageBuild (dict "registry" "ghcr.io" "repository" "team/app" "tag" "v2") }} // Output: "ghcr.io/team/app:v2"
+func (fh *FunctionHandler) ImageBuild(parts any) string {
+	result, _ := fh.MustImageBuild(parts)
+	return result
+}
+
+// MustImageBuild builds a container image reference from a dict with the
+// keys "registry", "repository", "tag" and "digest", returning an error if a
+// part is invalid.
+//
+// Parameters:
+//
+//	parts any - the dict of the parts of the reference.
+//
+// Returns:
+//
+//	string - the image reference.
+//	error - error if a key is unknown or a part is malformed.
+//
+// Example:
+//
+//	{{ mustImageBuild (dict "repository" "app" "tag" "1.0") }} // Output: "app:1.0", nil
+func (fh *FunctionHandler) MustImageBuild(parts any) (string, error) {
+	dict, ok := parts.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("cannot use %T as image reference parts", parts)
+	}
+
+	var image imageReference
+	for key, value := range dict {
+		switch key {
+		case "registry":
+			image.registry = fh.ToString(value)
+		case "repository":
+			image.repository = fh.ToString(value)
+		case "tag":
+			image.tag = fh.ToString(value)
+		case "digest":
+			image.digest = fh.ToString(value)
+		default:
+			return "", fmt.Errorf("unknown image reference key: %s", key)
+		}
+	}
+
+	if err := image.validate(); err != nil {
+		return "", err
+	}
+	return image.String(), nil
+}
+
+// ImageWithTag replaces the tag of a container image reference. The digest,
+// if any, is removed as it pins the previous tag. Invalid references or tags
+// produce an empty string.
+//
+// Parameters:
+//
+//	tag string - the new tag.
+//	ref string - the image reference.
+//
+// Returns:
+//
+//	string - the image reference with the new tag.
+//
+// Example:
+//
+//	{{ "ghcr.io/team/app:1.2.3" | imageWithTag "1.3.0" }} // Output: "ghcr.io/team/app:1.3.0"
+func (fh *FunctionHandler) ImageWithTag(tag string, ref string) string {
+	result, _ := fh.MustImageWithTag(tag, ref)
+	return result
+}
+
+// MustImageWithTag replaces the tag of a container image reference, returning
+// an error if the reference or the tag is invalid.
+//
+// Parameters:
+//
+//	tag string - the new tag.
+//	ref string - the image reference.
+//
+// Returns:
+//
+//	string - the image reference with the new tag.
+//	error - error if the reference or the tag is malformed.
+//
+// Example:
+//
+//	{{ "app@sha256:0123456789abcdef0123456789abcdef" | mustImageWithTag "v2" }} // Output: "app:v2", nil
+func (fh *FunctionHandler) MustImageWithTag(tag string, ref string) (string, error) {
+	image, err := parseImageReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	image.tag, image.digest = tag, ""
+	if err := image.validate(); err != nil {
+		return "", err
+	}
+	return image.String(), nil
+}
+
+// ImageWithRegistry replaces the registry of a container image reference, an
+// empty registry resetting it to the default Docker Hub registry. Invalid
+// references or registries produce an empty string.
+//
+// Parameters:
+//
+//	registry string - the new registry host, with an optional port.
+//	ref string - the image reference.
+//
+// Returns:
+//
+//	string - the image reference with the new registry.
+//
+// Example:
+//
+//	{{ "docker.io/library/nginx:1.25" | imageWithRegistry "mirror.local:5000" }} // Output: "mirror.local:5000/library/nginx:1.25"
+func (fh *FunctionHandler) ImageWithRegistry(registry string, ref string) string {
+	result, _ := fh.MustImageWithRegistry(registry, ref)
+	return result
+}
+
+// MustImageWithRegistry replaces the registry of a container image reference,
+// returning an error if the reference or the registry is invalid. An empty
+// registry resets it to the default Docker Hub registry, written explicitly
+// when the repository would otherwise be read as starting with a registry.
+//
+// Parameters:
+//
+//	registry string - the new registry host, with an optional port.
+//	ref string - the image reference.
+//
+// Returns:
+//
+//	string - the image reference with the new registry.
+//	error - error if the reference or the registry is malformed.
+//
+// Example:
+//
+//	{{ "team/app:1.0" | mustImageWithRegistry "ghcr.io" }} // Output: "ghcr.io/team/app:1.0", nil
+func (fh *FunctionHandler) MustImageWithRegistry(registry string, ref string) (string, error) {
+	image, err := parseImageReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	image.registry = registry
+	if err := image.validate(); err != nil {
+		return "", err
+	}
+	return image.String(), nil
+}
+
+// parseImageReference splits 'ref' into its registry, repository, tag and
+// digest. Like Docker, the first path component is taken as the registry only
+// when it contains a '.' or a ':', or is "localhost".
+func parseImageReference(ref string) (imageReference, error) {
+	var image imageReference
+	name := ref
+	hasTag, hasDigest := false, false
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, image.digest, hasDigest = name[:i], name[i+1:], true
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, image.tag, hasTag = name[:i], name[i+1:], true
+	}
+	if first, rest, found := strings.Cut(name, "/"); found && isImageRegistry(first) {
+		image.registry, name = first, rest
+	}
+	image.repository = name
+
+	err := image.validate()
+	switch {
+	case err != nil:
+	case hasTag && image.tag == "":
+		err = fmt.Errorf("invalid image tag: %q", image.tag)
+	case hasDigest && image.digest == "":
+		err = fmt.Errorf("invalid image digest: %q", image.digest)
+	}
+	if err != nil {
+		return imageReference{}, fmt.Errorf("invalid image reference %q: %w", ref, err)
+	}
+	return image, nil
+}
+
+// isImageRegistry reports whether the first path component of an image name
+// is read as a registry host rather than as part of the repository.
+func isImageRegistry(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
+
+// validate checks every part of the image reference.
+func (r imageReference) validate() error {
+	switch {
+	case r.registry != "" && !imageRegistryRegex.MatchString(r.registry):
+		return fmt.Errorf("invalid image registry: %q", r.registry)
+	case !imageRepositoryRegex.MatchString(r.repository):
+		return fmt.Errorf("invalid image repository: %q", r.repository)
+	case len(r.registry)+len(r.repository)+1 > 255:
+		return fmt.Errorf("image name longer than 255 characters: %q", r.repository)
+	case r.tag != "" && !imageTagRegex.MatchString(r.tag):
+		return fmt.Errorf("invalid image tag: %q", r.tag)
+	case r.digest != "" && !imageDigestRegex.MatchString(r.digest):
+		return fmt.Errorf("invalid image digest: %q", r.digest)
+	default:
+		return nil
+	}
+}
+
+// String writes the image reference back in the
+// "registry/repository:tag@digest" form, leaving out the missing parts. The
+// default registry is written when the repository would otherwise be parsed
+// as starting with a registry.
+func (r imageReference) String() string {
+	var builder strings.Builder
+	if r.registry != "" {
+		builder.WriteString(r.registry + "/")
+	} else if first, _, found := strings.Cut(r.repository, "/"); found && isImageRegistry(first) {
+		builder.WriteString("docker.io/")
+	}
+	builder.WriteString(r.repository)
+	if r.tag != "" {
+		builder.WriteString(":" + r.tag)
+	}
+	if r.digest != "" {
+		builder.WriteString("@" + r.digest)
+	}
+	return builder.String()
+}
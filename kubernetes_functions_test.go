@@ -0,0 +1,346 @@
+package sprout
+
+import "testing"
+
+func TestParseQuantity(t *testing.T) {
+	var tests = testCases{
+		{"TestInteger", `{{ "2" | parseQuantity }}`, "2", nil},
+		{"TestMilli", `{{ "500m" | parseQuantity }}`, "0.5", nil},
+		{"TestBinary", `{{ "1.5Gi" | parseQuantity }}`, "1.610612736e+09", nil},
+		{"TestDecimal", `{{ "2k" | parseQuantity }}`, "2000", nil},
+		{"TestExponent", `{{ "2e3" | parseQuantity }}`, "2000", nil},
+		{"TestNegativeExponent", `{{ "5E-1" | parseQuantity }}`, "0.5", nil},
+		{"TestExa", `{{ "1E" | parseQuantity }}`, "1e+18", nil},
+		{"TestNumber", `{{ 1.5 | parseQuantity }}`, "1.5", nil},
+		{"TestNegative", `{{ "-100Mi" | parseQuantity }}`, "-1.048576e+08", nil},
+		{"TestInvalid", `{{ "1.5 Gi" | parseQuantity }}`, "0", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustParseQuantity(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "250m" | mustParseQuantity }}`, "0.25", nil}, ""},
+		{testCase{"TestEmpty", `{{ "" | mustParseQuantity }}`, "", nil}, `invalid quantity: ""`},
+		{testCase{"TestUnknownSuffix", `{{ "1GB" | mustParseQuantity }}`, "", nil}, `invalid quantity: "1GB"`},
+		{testCase{"TestLowercaseBinary", `{{ "1gi" | mustParseQuantity }}`, "", nil}, `invalid quantity: "1gi"`},
+		{testCase{"TestHugeExponent", `{{ "1e9999" | mustParseQuantity }}`, "", nil}, `invalid quantity exponent: "1e9999"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestFormatQuantity(t *testing.T) {
+	var tests = testCases{
+		{"TestZero", `{{ 0 | formatQuantity "binarySI" }}`, "0", nil},
+		{"TestBinary", `{{ 1610612736 | formatQuantity "binarySI" }}`, "1536Mi", nil},
+		{"TestBinaryExact", `{{ 2147483648 | formatQuantity "binarySI" }}`, "2Gi", nil},
+		{"TestBinaryNotMultiple", `{{ 3000 | formatQuantity "binarySI" }}`, "3000", nil},
+		{"TestBinarySmall", `{{ 0.5 | formatQuantity "binarySI" }}`, "500m", nil},
+		{"TestBinaryNegative", `{{ -2048 | formatQuantity "binarySI" }}`, "-2Ki", nil},
+		{"TestDecimal", `{{ 0.5 | formatQuantity "decimalSI" }}`, "500m", nil},
+		{"TestDecimalLarge", `{{ 3000000 | formatQuantity "decimalSI" }}`, "3M", nil},
+		{"TestDecimalFromBinary", `{{ "1.5Gi" | formatQuantity "decimalSI" }}`, "1610612736", nil},
+		{"TestDecimalRoundUp", `{{ "0.0000000001" | formatQuantity "decimalSI" }}`, "1n", nil},
+		{"TestDecimalRoundUpNegative", `{{ "-1.5n" | formatQuantity "decimalSI" }}`, "-2n", nil},
+		{"TestDecimalHuge", `{{ "1e21" | formatQuantity "decimalSI" }}`, "1000E", nil},
+		{"TestExponent", `{{ 2000 | formatQuantity "decimalExponent" }}`, "2e3", nil},
+		{"TestExponentSmall", `{{ "0.5" | formatQuantity "decimalExponent" }}`, "500e-3", nil},
+		{"TestExponentUnit", `{{ "12" | formatQuantity "decimalExponent" }}`, "12", nil},
+		{"TestInvalidFormat", `{{ 1 | formatQuantity "hex" }}`, "", nil},
+		{"TestInvalidValue", `{{ "x" | formatQuantity "decimalSI" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustFormatQuantity(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "1.5Gi" | mustFormatQuantity "decimalSI" }}`, "1610612736", nil}, ""},
+		{testCase{"TestInvalidFormat", `{{ 1 | mustFormatQuantity "hex" }}`, "", nil}, "unknown quantity format: hex"},
+		{testCase{"TestInvalidValue", `{{ "1.2.3" | mustFormatQuantity "decimalSI" }}`, "", nil}, `invalid quantity: "1.2.3"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestAddQuantity(t *testing.T) {
+	var tests = testCases{
+		{"TestNone", `{{ addQuantity }}`, "0", nil},
+		{"TestBinary", `{{ addQuantity "1Gi" "512Mi" }}`, "1536Mi", nil},
+		{"TestDecimal", `{{ addQuantity "250m" "1.5" }}`, "1750m", nil},
+		{"TestMany", `{{ addQuantity "100m" "200m" "700m" }}`, "1", nil},
+		{"TestFirstFormat", `{{ addQuantity "1k" "1Ki" }}`, "2024", nil},
+		{"TestExponent", `{{ addQuantity "1e3" "1e3" }}`, "2e3", nil},
+		{"TestNumbers", `{{ addQuantity 1 0.5 }}`, "1500m", nil},
+		{"TestInvalid", `{{ addQuantity "1Gi" "lots" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustAddQuantity(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ mustAddQuantity "100m" "200m" }}`, "300m", nil}, ""},
+		{testCase{"TestInvalid", `{{ mustAddQuantity "100m" "lots" }}`, "", nil}, `invalid quantity: "lots"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestSubQuantity(t *testing.T) {
+	var tests = testCases{
+		{"TestBinary", `{{ subQuantity "2Gi" "512Mi" }}`, "1536Mi", nil},
+		{"TestDecimal", `{{ subQuantity "1" "250m" }}`, "750m", nil},
+		{"TestNegative", `{{ subQuantity "1Gi" "2Gi" }}`, "-1Gi", nil},
+		{"TestZero", `{{ subQuantity "500m" "0.5" }}`, "0", nil},
+		{"TestInvalid", `{{ subQuantity "1" "x" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustSubQuantity(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ mustSubQuantity "1" "250m" }}`, "750m", nil}, ""},
+		{testCase{"TestInvalidFirst", `{{ mustSubQuantity "x" "1" }}`, "", nil}, `invalid quantity: "x"`},
+		{testCase{"TestInvalidSecond", `{{ mustSubQuantity "1" "y" }}`, "", nil}, `invalid quantity: "y"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMulQuantity(t *testing.T) {
+	var tests = testCases{
+		{"TestBinary", `{{ "512Mi" | mulQuantity 3 }}`, "1536Mi", nil},
+		{"TestFraction", `{{ "300m" | mulQuantity 0.5 }}`, "150m", nil},
+		{"TestDouble", `{{ "1Gi" | mulQuantity 2 }}`, "2Gi", nil},
+		{"TestQuantityFactor", `{{ "2" | mulQuantity "500m" }}`, "1", nil},
+		{"TestInvalid", `{{ "1Gi" | mulQuantity "twice" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustMulQuantity(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "1Gi" | mustMulQuantity 2 }}`, "2Gi", nil}, ""},
+		{testCase{"TestInvalidFactor", `{{ "1Gi" | mustMulQuantity "twice" }}`, "", nil}, `invalid quantity: "twice"`},
+		{testCase{"TestInvalidQuantity", `{{ "1GB" | mustMulQuantity 2 }}`, "", nil}, `invalid quantity: "1GB"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestCmpQuantity(t *testing.T) {
+	var tests = testCases{
+		{"TestGreater", `{{ cmpQuantity "1Gi" "1000Mi" }}`, "1", nil},
+		{"TestEqual", `{{ cmpQuantity "500m" "0.5" }}`, "0", nil},
+		{"TestLess", `{{ cmpQuantity "100m" 1 }}`, "-1", nil},
+		{"TestAcrossFormats", `{{ cmpQuantity "1k" "1Ki" }}`, "-1", nil},
+		{"TestInTemplate", `{{ if lt (cmpQuantity .Requested .Limit) 1 }}ok{{ end }}`, "ok", map[string]any{"Requested": "256Mi", "Limit": "1Gi"}},
+		{"TestInvalid", `{{ cmpQuantity "x" "1" }}`, "0", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustCmpQuantity(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ mustCmpQuantity "100m" "1" }}`, "-1", nil}, ""},
+		{testCase{"TestInvalid", `{{ mustCmpQuantity "1" "1 Gi" }}`, "", nil}, `invalid quantity: "1 Gi"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestDns1123Label(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | dns1123Label }}`, "", nil},
+		{"TestValid", `{{ "my-app" | dns1123Label }}`, "my-app", nil},
+		{"TestSanitize", `{{ "My_App (Café)" | dns1123Label }}`, "my-app-cafe", nil},
+		{"TestDots", `{{ "v1.2.3" | dns1123Label }}`, "v1-2-3", nil},
+		{"TestTrimHyphens", `{{ "--app--" | dns1123Label }}`, "app", nil},
+		{"TestLong", `{{ .V | dns1123Label }}`, "release-0123456789-0123456789-0123456789-0123456789-01-3668a391", map[string]any{"V": "Release-0123456789-0123456789-0123456789-0123456789-0123456789-0123456789"}},
+		{"TestLongLength", `{{ .V | dns1123Label | len }}`, "63", map[string]any{"V": "Release-0123456789-0123456789-0123456789-0123456789-0123456789-0123456789"}},
+		{"TestNoValidCharacters", `{{ "---" | dns1123Label }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustDns1123Label(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "My_App" | mustDns1123Label }}`, "my-app", nil}, ""},
+		{testCase{"TestEmpty", `{{ "" | mustDns1123Label }}`, "", nil}, `cannot make a DNS-1123 label from "": no valid characters`},
+		{testCase{"TestHyphens", `{{ "---" | mustDns1123Label }}`, "", nil}, `cannot make a DNS-1123 label from "---": no valid characters`},
+		{testCase{"TestNonLatin", `{{ "日本" | mustDns1123Label }}`, "", nil}, `cannot make a DNS-1123 label from "日本": no valid characters`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestDns1123Subdomain(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | dns1123Subdomain }}`, "", nil},
+		{"TestValid", `{{ "config.example.com" | dns1123Subdomain }}`, "config.example.com", nil},
+		{"TestSanitize", `{{ "Config..Example.COM_" | dns1123Subdomain }}`, "config.example.com", nil},
+		{"TestLabels", `{{ ".-my_app-.v1." | dns1123Subdomain }}`, "my-app.v1", nil},
+		{"TestLongLength", `{{ repeat 300 "a" | dns1123Subdomain | len }}`, "253", nil},
+		{"TestNoValidCharacters", `{{ "-.日本.-" | dns1123Subdomain }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustDns1123Subdomain(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "Config.Example.COM" | mustDns1123Subdomain }}`, "config.example.com", nil}, ""},
+		{testCase{"TestDots", `{{ "..." | mustDns1123Subdomain }}`, "", nil}, `cannot make a DNS-1123 subdomain from "...": no valid characters`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestTruncWithHash(t *testing.T) {
+	var tests = testCases{
+		{"TestShort", `{{ "my-release" | truncWithHash 63 }}`, "my-release", nil},
+		{"TestExactLength", `{{ "abcdef" | truncWithHash 6 }}`, "abcdef", nil},
+		{"TestTruncated", `{{ "my-release-with-a-very-long-name" | truncWithHash 20 }}`, "my-release-e618f21e", nil},
+		{"TestStable", `{{ eq ("my-release-with-a-very-long-name" | truncWithHash 20) ("my-release-with-a-very-long-name" | truncWithHash 20) }}`, "true", nil},
+		{"TestDistinct", `{{ eq ("my-release-with-a-very-long-name-a" | truncWithHash 20) ("my-release-with-a-very-long-name-b" | truncWithHash 20) }}`, "false", nil},
+		{"TestTooShortForPrefix", `{{ "my-release-with-a-very-long-name" | truncWithHash 5 }}`, "e618f", nil},
+		{"TestMultibyte", `{{ "ééééééééééééé" | truncWithHash 12 }}`, "é-40bef4e3", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestImageParse(t *testing.T) {
+	var tests = testCases{
+		{"TestShortName", `{{ imageParse "nginx" }}`, "map[digest: registry: repository:nginx tag:]", nil},
+		{"TestTag", `{{ imageParse "nginx:1.25" }}`, "map[digest: registry: repository:nginx tag:1.25]", nil},
+		{"TestNamespace", `{{ imageParse "bitnami/redis:7.2" }}`, "map[digest: registry: repository:bitnami/redis tag:7.2]", nil},
+		{"TestRegistryPort", `{{ (imageParse "registry.example.com:5000/team/app:1.2.3").registry }}`, "registry.example.com:5000", nil},
+		{"TestRegistryPortRepository", `{{ (imageParse "registry.example.com:5000/team/app:1.2.3").repository }}`, "team/app", nil},
+		{"TestRegistryPortWithoutTag", `{{ imageParse "localhost:5000/app" }}`, "map[digest: registry:localhost:5000 repository:app tag:]", nil},
+		{"TestLocalhost", `{{ (imageParse "localhost/app").registry }}`, "localhost", nil},
+		{"TestDigest", `{{ imageParse "app@sha256:0123456789abcdef0123456789abcdef" }}`, "map[digest:sha256:0123456789abcdef0123456789abcdef registry: repository:app tag:]", nil},
+		{"TestTagAndDigest", `{{ $i := imageParse "ghcr.io/team/app:1.2.3@sha256:0123456789abcdef0123456789abcdef" }}{{ $i.registry }} {{ $i.repository }} {{ $i.tag }} {{ $i.digest }}`, "ghcr.io team/app 1.2.3 sha256:0123456789abcdef0123456789abcdef", nil},
+		{"TestIPv6Registry", `{{ (imageParse "[::1]:5000/app:v1").registry }}`, "[::1]:5000", nil},
+		{"TestInvalid", `{{ imageParse "UPPER/case" }}`, "map[]", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustImageParse(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ mustImageParse "nginx:1.25" }}`, "map[digest: registry: repository:nginx tag:1.25]", nil}, ""},
+		{testCase{"TestEmpty", `{{ mustImageParse "" }}`, "", nil}, `invalid image reference "": invalid image repository: ""`},
+		{testCase{"TestUppercase", `{{ mustImageParse "App" }}`, "", nil}, `invalid image repository: "App"`},
+		{testCase{"TestInvalidTag", `{{ mustImageParse "app:-tag" }}`, "", nil}, `invalid image tag: "-tag"`},
+		{testCase{"TestInvalidDigest", `{{ mustImageParse "app@sha256:abc" }}`, "", nil}, `invalid image digest: "sha256:abc"`},
+		{testCase{"TestInvalidRegistry", `{{ mustImageParse "-bad.io/app" }}`, "", nil}, `invalid image registry: "-bad.io"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestImageNormalize(t *testing.T) {
+	var tests = testCases{
+		{"TestOfficialImage", `{{ "nginx" | imageNormalize }}`, "docker.io/library/nginx:latest", nil},
+		{"TestOfficialImageTag", `{{ "nginx:1.25" | imageNormalize }}`, "docker.io/library/nginx:1.25", nil},
+		{"TestNamespace", `{{ "bitnami/redis" | imageNormalize }}`, "docker.io/bitnami/redis:latest", nil},
+		{"TestIndexRegistry", `{{ "index.docker.io/library/nginx" | imageNormalize }}`, "docker.io/library/nginx:latest", nil},
+		{"TestDockerHubShort", `{{ "docker.io/nginx" | imageNormalize }}`, "docker.io/library/nginx:latest", nil},
+		{"TestDigest", `{{ "nginx@sha256:0123456789abcdef0123456789abcdef" | imageNormalize }}`, "docker.io/library/nginx@sha256:0123456789abcdef0123456789abcdef", nil},
+		{"TestOtherRegistry", `{{ "ghcr.io/app" | imageNormalize }}`, "ghcr.io/app:latest", nil},
+		{"TestInvalid", `{{ "Nginx" | imageNormalize }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustImageNormalize(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "bitnami/redis:7.2" | mustImageNormalize }}`, "docker.io/bitnami/redis:7.2", nil}, ""},
+		{testCase{"TestInvalid", `{{ "app:" | mustImageNormalize }}`, "", nil}, `invalid image reference "app:"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestImageBuild(t *testing.T) {
+	var tests = testCases{
+		{"TestFull", `{{ imageBuild (dict "registry" "ghcr.io" "repository" "team/app" "tag" "v2" "digest" "sha256:0123456789abcdef0123456789abcdef") }}`, "ghcr.io/team/app:v2@sha256:0123456789abcdef0123456789abcdef", nil},
+		{"TestRepositoryOnly", `{{ imageBuild (dict "repository" "app") }}`, "app", nil},
+		{"TestRoundTrip", `{{ imageParse "registry.example.com:5000/team/app:1.2.3" | imageBuild }}`, "registry.example.com:5000/team/app:1.2.3", nil},
+		{"TestModifiedParse", `{{ $i := imageParse "nginx:1.25" }}{{ $_ := set $i "tag" "1.26" }}{{ imageBuild $i }}`, "nginx:1.26", nil},
+		{"TestAmbiguousRepository", `{{ imageBuild (dict "repository" "example.com/app") }}`, "docker.io/example.com/app", nil},
+		{"TestMissingRepository", `{{ imageBuild (dict "tag" "v1") }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustImageBuild(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ mustImageBuild (dict "repository" "app" "tag" "1.0") }}`, "app:1.0", nil}, ""},
+		{testCase{"TestUnknownKey", `{{ mustImageBuild (dict "repository" "app" "version" "1.0") }}`, "", nil}, "unknown image reference key: version"},
+		{testCase{"TestInvalidTag", `{{ mustImageBuild (dict "repository" "app" "tag" "1.0/x") }}`, "", nil}, `invalid image tag: "1.0/x"`},
+		{testCase{"TestNotADict", `{{ mustImageBuild "app" }}`, "", nil}, "cannot use string as image reference parts"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestImageWithTag(t *testing.T) {
+	var tests = testCases{
+		{"TestReplace", `{{ "ghcr.io/team/app:1.2.3" | imageWithTag "1.3.0" }}`, "ghcr.io/team/app:1.3.0", nil},
+		{"TestAdd", `{{ "nginx" | imageWithTag "1.25" }}`, "nginx:1.25", nil},
+		{"TestRegistryPort", `{{ "localhost:5000/app" | imageWithTag "dev" }}`, "localhost:5000/app:dev", nil},
+		{"TestRemoveDigest", `{{ "app:1@sha256:0123456789abcdef0123456789abcdef" | imageWithTag "2" }}`, "app:2", nil},
+		{"TestRemoveTag", `{{ "app:1" | imageWithTag "" }}`, "app", nil},
+		{"TestInvalidTag", `{{ "app:1" | imageWithTag "a b" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustImageWithTag(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "app@sha256:0123456789abcdef0123456789abcdef" | mustImageWithTag "v2" }}`, "app:v2", nil}, ""},
+		{testCase{"TestInvalidTag", `{{ "app" | mustImageWithTag ".v2" }}`, "", nil}, `invalid image tag: ".v2"`},
+		{testCase{"TestInvalidReference", `{{ "app:" | mustImageWithTag "v2" }}`, "", nil}, `invalid image reference "app:"`},
+		{testCase{"TestEmptyDigest", `{{ "app:1@" | mustImageWithTag "v2" }}`, "", nil}, `invalid image digest: ""`},
+		{testCase{"TestEmptyTagBeforeDigest", `{{ "app:@sha256:0123456789abcdef0123456789abcdef" | mustImageWithTag "v2" }}`, "", nil}, `invalid image tag: ""`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestImageWithRegistry(t *testing.T) {
+	var tests = testCases{
+		{"TestReplace", `{{ "docker.io/library/nginx:1.25" | imageWithRegistry "mirror.local:5000" }}`, "mirror.local:5000/library/nginx:1.25", nil},
+		{"TestAdd", `{{ "team/app:1.0" | imageWithRegistry "ghcr.io" }}`, "ghcr.io/team/app:1.0", nil},
+		{"TestRemove", `{{ "ghcr.io/team/app:1.0" | imageWithRegistry "" }}`, "team/app:1.0", nil},
+		{"TestRemoveAmbiguous", `{{ "ghcr.io/example.com/app:1.0" | imageWithRegistry "" }}`, "docker.io/example.com/app:1.0", nil},
+		{"TestRemoveLocalhost", `{{ "quay.io/localhost/app" | imageWithRegistry "" }}`, "docker.io/localhost/app", nil},
+		{"TestRemoveRoundTrip", `{{ ("ghcr.io/example.com/app" | imageWithRegistry "" | imageParse).repository }}`, "example.com/app", nil},
+		{"TestKeepDigest", `{{ "app@sha256:0123456789abcdef0123456789abcdef" | imageWithRegistry "ghcr.io" }}`, "ghcr.io/app@sha256:0123456789abcdef0123456789abcdef", nil},
+		{"TestInvalidRegistry", `{{ "app" | imageWithRegistry "bad_host" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustImageWithRegistry(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "team/app:1.0" | mustImageWithRegistry "ghcr.io" }}`, "ghcr.io/team/app:1.0", nil}, ""},
+		{testCase{"TestInvalidRegistry", `{{ "app" | mustImageWithRegistry "bad_host" }}`, "", nil}, `invalid image registry: "bad_host"`},
+	}
+
+	runMustTestCases(t, tests)
+}
@@ -149,11 +149,13 @@ func (fh *FunctionHandler) Values(dict map[string]any) []any {
 }
 
 // Pluck extracts values associated with a specified key from a list of dictionaries.
+// Each argument may be a dictionary or a list of dictionaries, such as the rows
+// returned by fromCsvWith.
 //
 // Parameters:
 //
 //	key string - the key to pluck values for.
-//	dicts ...map[string]any - one or more dictionaries.
+//	dicts ...any - one or more dictionaries or lists of dictionaries.
 //
 // Returns:
 //
@@ -162,11 +164,22 @@ func (fh *FunctionHandler) Values(dict map[string]any) []any {
 // Example:
 //
 //	{{ [{"key": "value1"}, {"key": "value2"}] | pluck "key" }} // Output: ["value1", "value2"]
-func (fh *FunctionHandler) Pluck(key string, dicts ...map[string]any) []any {
+func (fh *FunctionHandler) Pluck(key string, dicts ...any) []any {
 	result := []any{}
 	for _, dict := range dicts {
-		if val, ok := dict[key]; ok {
-			result = append(result, val)
+		switch value := dict.(type) {
+		case map[string]any:
+			if val, ok := value[key]; ok {
+				result = append(result, val)
+			}
+		case []map[string]any:
+			for _, item := range value {
+				if val, ok := item[key]; ok {
+					result = append(result, val)
+				}
+			}
+		case []any:
+			result = append(result, fh.Pluck(key, value...)...)
 		}
 	}
 	return result
@@ -70,6 +70,8 @@ func TestPluck(t *testing.T) {
 		{"TestEmpty", `{{pluck "a" .}}`, "[]", nil},
 		{"TestWithOneMap", `{{. | pluck "a"}}`, "[1]", map[string]any{"a": 1, "b": 2}},
 		{"TestWithTwoMaps", `{{pluck "a" .A .B }}`, "[1 3]", map[string]any{"A": map[string]any{"a": 1, "b": 2}, "B": map[string]any{"a": 3, "b": 4}}},
+		{"TestWithListOfMaps", `{{pluck "a" .L .M }}`, "[1 3 5]", map[string]any{"L": []map[string]any{{"a": 1}, {"b": 2}, {"a": 3}}, "M": map[string]any{"a": 5}}},
+		{"TestWithAnyList", `{{list (dict "a" 1) (dict "a" 2) | pluck "a"}}`, "[1 2]", nil},
 	}
 
 	runTestCases(t, tests)
@@ -0,0 +1,1313 @@
+package sprout
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+
+	"github.com/spf13/cast"
+)
+
+// maxCidrHosts is the maximum number of addresses listed by cidrHosts, to
+// protect templates from allocating huge lists for large networks.
+const maxCidrHosts = 65536
+
+// IsIPv4 reports whether 'str' is a valid IPv4 address. IPv4-mapped IPv6
+// addresses such as "::ffff:10.0.0.1" are not IPv4 addresses.
+//
+// Parameters:
+//
+//	str string - the string to check.
+//
+// Returns:
+//
+//	bool - true if 'str' is an IPv4 address.
+//
+// Example:
+//
+//	{{ "192.168.1.10" | isIPv4 }} // Output: true
+func (fh *FunctionHandler) IsIPv4(str string) bool {
+	addr, err := netip.ParseAddr(str)
+	return err == nil && addr.Is4()
+}
+
+// IsIPv6 reports whether 'str' is a valid IPv6 address, zone included.
+//
+// Parameters:
+//
+//	str string - the string to check.
+//
+// Returns:
+//
+//	bool - true if 'str' is an IPv6 address.
+//
+// Example:
+//
+//	{{ "2001:db8::1" | isIPv6 }} // Output: true
+func (fh *FunctionHandler) IsIPv6(str string) bool {
+	addr, err := netip.ParseAddr(str)
+	return err == nil && addr.Is6()
+}
+
+// IpNormalize writes an IP address in its canonical form: IPv6 addresses are
+// lowercased with the longest run of zero groups compressed, and IPv4-mapped
+// IPv6 addresses are converted to IPv4. Invalid addresses produce an empty
+// string.
+//
+// Parameters:
+//
+//	str string - the IP address to normalize.
+//
+// Returns:
+//
+//	string - the canonical IP address.
+//
+// Example:
+//
+//	{{ "2001:0DB8:0000:0000:0000:0000:0000:0001" | ipNormalize }} // Output: "2001:db8::1"
+func (fh *FunctionHandler) IpNormalize(str string) string {
+	result, _ := fh.MustIpNormalize(str)
+	return result
+}
+
+// MustIpNormalize writes an IP address in its canonical form, returning an
+// error if the address is invalid.
+//
+// Parameters:
+//
+//	str string - the IP address to normalize.
+//
+// Returns:
+//
+//	string - the canonical IP address.
+//	error - error if the address is malformed.
+//
+// Example:
+//
+//	{{ "::ffff:10.0.0.1" | mustIpNormalize }} // Output: "10.0.0.1", nil
+func (fh *FunctionHandler) MustIpNormalize(str string) (string, error) {
+	addr, err := parseIP(str)
+	if err != nil {
+		return "", err
+	}
+	return addr.Unmap().String(), nil
+}
+
+// CidrContains reports whether the network 'cidr' contains the IP address
+// 'ip'. Invalid networks or addresses are reported as not contained.
+//
+// Parameters:
+//
+//	cidr string - the network, in CIDR notation.
+//	ip string - the IP address to look for.
+//
+// Returns:
+//
+//	bool - true if the address belongs to the network.
+//
+// Example:
+//
+//	{{ cidrContains "10.0.0.0/8" "10.20.30.40" }} // Output: true
+func (fh *FunctionHandler) CidrContains(cidr string, ip string) bool {
+	result, _ := fh.MustCidrContains(cidr, ip)
+	return result
+}
+
+// MustCidrContains reports whether the network 'cidr' contains the IP address
+// 'ip', returning an error if the network or the address is invalid.
+//
+// Parameters:
+//
+//	cidr string - the network, in CIDR notation.
+//	ip string - the IP address to look for.
+//
+// Returns:
+//
+//	bool - true if the address belongs to the network.
+//	error - error if the network or the address is malformed.
+//
+// Example:
+//
+//	{{ mustCidrContains "2001:db8::/32" "2001:db9::1" }} // Output: false, nil
+func (fh *FunctionHandler) MustCidrContains(cidr string, ip string) (bool, error) {
+	prefix, err := parseCidr(cidr)
+	if err != nil {
+		return false, err
+	}
+	addr, err := parseIP(ip)
+	if err != nil {
+		return false, err
+	}
+	return prefix.Contains(addr.Unmap()), nil
+}
+
+// CidrNetwork returns the network address of 'cidr', its first address.
+// Invalid networks produce an empty string.
+//
+// Parameters:
+//
+//	cidr string - the network, in CIDR notation.
+//
+// Returns:
+//
+//	string - the network address.
+//
+// Example:
+//
+//	{{ "192.168.1.130/25" | cidrNetwork }} // Output: "192.168.1.128"
+func (fh *FunctionHandler) CidrNetwork(cidr string) string {
+	result, _ := fh.MustCidrNetwork(cidr)
+	return result
+}
+
+// MustCidrNetwork returns the network address of 'cidr', returning an error
+// if the network is invalid.
+//
+// Parameters:
+//
+//	cidr string - the network, in CIDR notation.
+//
+// Returns:
+//
+//	string - the network address.
+//	error - error if the network is malformed.
+//
+// Example:
+//
+//	{{ "2001:db8:1:2::/48" | mustCidrNetwork }} // Output: "2001:db8:1::", nil
+func (fh *FunctionHandler) MustCidrNetwork(cidr string) (string, error) {
+	prefix, err := parseCidr(cidr)
+	if err != nil {
+		return "", err
+	}
+	return prefix.Addr().String(), nil
+}
+
+// CidrBroadcast returns the broadcast address of 'cidr', its last address.
+// IPv6 has no broadcast, the last address of the network is returned as well.
+// Invalid networks produce an empty string.
+//
+// Parameters:
+//
+//	cidr string - the network, in CIDR notation.
+//
+// Returns:
+//
+//	string - the broadcast address.
+//
+// Example:
+//
+//	{{ "192.168.1.0/24" | cidrBroadcast }} // Output: "192.168.1.255"
+func (fh *FunctionHandler) CidrBroadcast(cidr string) string {
+	result, _ := fh.MustCidrBroadcast(cidr)
+	return result
+}
+
+// MustCidrBroadcast returns the broadcast address of 'cidr', returning an
+// error if the network is invalid.
+//
+// Parameters:
+//
+//	cidr string - the network, in CIDR notation.
+//
+// Returns:
+//
+//	string - the broadcast address.
+//	error - error if the network is malformed.
+//
+// Example:
+//
+//	{{ "10.0.0.0/8" | mustCidrBroadcast }} // Output: "10.255.255.255", nil
+func (fh *FunctionHandler) MustCidrBroadcast(cidr string) (string, error) {
+	prefix, err := parseCidr(cidr)
+	if err != nil {
+		return "", err
+	}
+	return lastAddr(prefix).String(), nil
+}
+
+// CidrHost returns the address numbered 'hostnum' in the network 'cidr', like
+// Terraform's cidrhost. Host 0 is the network address, and negative numbers
+// count from the end of the network, -1 being the last address. Invalid
+// networks or out of range numbers produce an empty string.
+//
+// Parameters:
+//
+//	hostnum any - the number of the host in the network.
+//	cidr string - the network, in CIDR notation.
+//
+// Returns:
+//
+//	string - the host address.
+//
+// Example:
+//
+//	{{ "10.12.112.0/20" | cidrHost 16 }} // Output: "10.12.112.16"
+//	{{ "10.12.112.0/20" | cidrHost -2 }} // Output: "10.12.127.254"
+func (fh *FunctionHandler) CidrHost(hostnum any, cidr string) string {
+	result, _ := fh.MustCidrHost(hostnum, cidr)
+	return result
+}
+
+// MustCidrHost returns the address numbered 'hostnum' in the network 'cidr',
+// returning an error if the network is invalid or the number out of range.
+//
+// Parameters:
+//
+//	hostnum any - the number of the host in the network.
+//	cidr string - the network, in CIDR notation.
+//
+// Returns:
+//
+//	string - the host address.
+//	error - error if the network is malformed or the host is outside of it.
+//
+// Example:
+//
+//	{{ "fd00::/64" | mustCidrHost 5 }} // Output: "fd00::5", nil
+func (fh *FunctionHandler) MustCidrHost(hostnum any, cidr string) (string, error) {
+	prefix, err := parseCidr(cidr)
+	if err != nil {
+		return "", err
+	}
+	number, err := cast.ToInt64E(hostnum)
+	if err != nil {
+		return "", fmt.Errorf("invalid host number: %w", err)
+	}
+
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(hostBits))
+	offset := big.NewInt(number)
+	if number < 0 {
+		offset.Add(offset, size)
+	}
+	if offset.Sign() < 0 || offset.Cmp(size) >= 0 {
+		return "", fmt.Errorf("host number %d out of range for %s", number, prefix)
+	}
+	return addrAdd(prefix.Addr(), offset).String(), nil
+}
+
+// CidrSubnet splits the network 'cidr' into subnets with 'newbits' more bits
+// in their prefix, and returns the subnet numbered 'netnum', like Terraform's
+// cidrsubnet. Invalid input produces an empty string.
+//
+// Parameters:
+//
+//	newbits any - the number of bits to add to the prefix length.
+//	netnum any - the number of the subnet.
+//	cidr string - the network to split, in CIDR notation.
+//
+// Returns:
+//
+//	string - the subnet, in CIDR notation.
+//
+// Example:
+//
+//	{{ "10.1.0.0/16" | cidrSubnet 8 2 }} // Output: "10.1.2.0/24"
+//	{{ "fd00:fd12:3456:7890::/56" | cidrSubnet 16 162 }} // Output: "fd00:fd12:3456:7800:a200::/72"
+func (fh *FunctionHandler) CidrSubnet(newbits any, netnum any, cidr string) string {
+	result, _ := fh.MustCidrSubnet(newbits, netnum, cidr)
+	return result
+}
+
+// MustCidrSubnet returns the subnet numbered 'netnum' among the subnets of
+// 'cidr' with 'newbits' more bits in their prefix, returning an error if the
+// input is invalid.
+//
+// Parameters:
+//
+//	newbits any - the number of bits to add to the prefix length.
+//	netnum any - the number of the subnet.
+//	cidr string - the network to split, in CIDR notation.
+//
+// Returns:
+//
+//	string - the subnet, in CIDR notation.
+//	error - error if the network is malformed or the subnet does not exist.
+//
+// Example:
+//
+//	{{ "172.16.0.0/12" | mustCidrSubnet 4 15 }} // Output: "172.31.0.0/16", nil
+func (fh *FunctionHandler) MustCidrSubnet(newbits any, netnum any, cidr string) (string, error) {
+	prefix, err := parseCidr(cidr)
+	if err != nil {
+		return "", err
+	}
+	bits, err := cast.ToIntE(newbits)
+	if err != nil {
+		return "", fmt.Errorf("invalid new bits: %w", err)
+	}
+	number, err := cast.ToInt64E(netnum)
+	if err != nil {
+		return "", fmt.Errorf("invalid network number: %w", err)
+	}
+
+	newLength := prefix.Bits() + bits
+	if bits < 0 || newLength > prefix.Addr().BitLen() {
+		return "", fmt.Errorf("cannot add %d bits to the prefix length of %s", bits, prefix)
+	}
+	if number < 0 || big.NewInt(number).BitLen() > bits {
+		return "", fmt.Errorf("network number %d out of range for %d new bits", number, bits)
+	}
+
+	offset := new(big.Int).Lsh(big.NewInt(number), uint(prefix.Addr().BitLen()-newLength))
+	return netip.PrefixFrom(addrAdd(prefix.Addr(), offset), newLength).String(), nil
+}
+
+// CidrHosts lists the addresses usable by hosts in the network 'cidr'. The
+// network and broadcast addresses of IPv4 networks are left out, except for
+// /31 and /32 networks. Invalid networks or networks of more than 65536
+// addresses produce an empty list.
+//
+// Parameters:
+//
+//	cidr string - the network, in CIDR notation.
+//
+// Returns:
+//
+//	[]string - the host addresses.
+//
+// Example:
+//
+//	{{ "192.168.1.0/30" | cidrHosts }} // Output: [192.168.1.1 192.168.1.2]
+func (fh *FunctionHandler) CidrHosts(cidr string) []string {
+	result, _ := fh.MustCidrHosts(cidr)
+	return result
+}
+
+// MustCidrHosts lists the addresses usable by hosts in the network 'cidr',
+// returning an error if the network is invalid or too large.
+//
+// Parameters:
+//
+//	cidr string - the network, in CIDR notation.
+//
+// Returns:
+//
+//	[]string - the host addresses.
+//	error - error if the network is malformed or has more than 65536 addresses.
+//
+// Example:
+//
+//	{{ "10.0.0.0/31" | mustCidrHosts }} // Output: [10.0.0.0 10.0.0.1], nil
+func (fh *FunctionHandler) MustCidrHosts(cidr string) ([]string, error) {
+	prefix, err := parseCidr(cidr)
+	if err != nil {
+		return []string{}, err
+	}
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits > 16 {
+		return []string{}, fmt.Errorf("network %s has more than %d addresses", prefix, maxCidrHosts)
+	}
+
+	first, last := prefix.Addr(), lastAddr(prefix)
+	if prefix.Addr().Is4() && hostBits > 1 {
+		first, last = first.Next(), last.Prev()
+	}
+
+	hosts := make([]string, 0, 1<<hostBits)
+	for addr := first; addr.IsValid() && addr.Compare(last) <= 0; addr = addr.Next() {
+		hosts = append(hosts, addr.String())
+	}
+	return hosts, nil
+}
+
+// parseCidr parses a network in CIDR notation, clearing the host bits of
+// addresses such as "10.0.0.5/24".
+func parseCidr(cidr string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+	}
+	if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), nil
+}
+
+// parseIP parses an IPv4 or IPv6 address.
+func parseIP(ip string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid IP address %q: %w", ip, err)
+	}
+	return addr, nil
+}
+
+// lastAddr returns the last address of 'prefix'.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	offset := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(hostBits)), big.NewInt(1))
+	return addrAdd(prefix.Addr(), offset)
+}
+
+// addrAdd returns the address 'offset' addresses after 'addr'. The offset
+// must keep the result within the address family.
+func addrAdd(addr netip.Addr, offset *big.Int) netip.Addr {
+	sum := new(big.Int).Add(new(big.Int).SetBytes(addr.AsSlice()), offset)
+
+	buffer := make([]byte, addr.BitLen()/8)
+	sum.FillBytes(buffer)
+	result, _ := netip.AddrFromSlice(buffer)
+	return result.WithZone(addr.Zone())
+}
+
+// UrlQuery parses the query of a URL into a map of parameter names to their
+// values. Invalid URLs or queries produce an empty map.
+//
+// Parameters:
+//
+//	rawURL string - the URL to read the query from.
+//
+// Returns:
+//
+//	map[string][]string - the values of each parameter.
+//
+// Example:
+//
+//	{{ "https://example.com/?tag=a&tag=b&page=2" | urlQuery }} // Output: map[page:[2] tag:[a b]]
+func (fh *FunctionHandler) UrlQuery(rawURL string) map[string][]string {
+	result, _ := fh.MustUrlQuery(rawURL)
+	return result
+}
+
+// MustUrlQuery parses the query of a URL into a map of parameter names to
+// their values, returning an error if the URL or its query is invalid.
+//
+// Parameters:
+//
+//	rawURL string - the URL to read the query from.
+//
+// Returns:
+//
+//	map[string][]string - the values of each parameter.
+//	error - error if the URL or its query is malformed.
+//
+// Example:
+//
+//	{{ "?q=go%20templates" | mustUrlQuery }} // Output: map[q:[go templates]], nil
+func (fh *FunctionHandler) MustUrlQuery(rawURL string) (map[string][]string, error) {
+	_, query, err := parseURLQuery(rawURL)
+	if err != nil {
+		return map[string][]string{}, err
+	}
+	return query, nil
+}
+
+// UrlQueryGet returns the first value of the query parameter 'key' of a URL.
+// Missing parameters, invalid URLs or queries produce an empty string.
+//
+// Parameters:
+//
+//	key string - the name of the parameter.
+//	rawURL string - the URL to read the query from.
+//
+// Returns:
+//
+//	string - the first value of the parameter.
+//
+// Example:
+//
+//	{{ "https://example.com/?page=2" | urlQueryGet "page" }} // Output: "2"
+func (fh *FunctionHandler) UrlQueryGet(key string, rawURL string) string {
+	result, _ := fh.MustUrlQueryGet(key, rawURL)
+	return result
+}
+
+// MustUrlQueryGet returns the first value of the query parameter 'key' of a
+// URL, returning an error if the URL or its query is invalid. Missing
+// parameters produce an empty string.
+//
+// Parameters:
+//
+//	key string - the name of the parameter.
+//	rawURL string - the URL to read the query from.
+//
+// Returns:
+//
+//	string - the first value of the parameter.
+//	error - error if the URL or its query is malformed.
+//
+// Example:
+//
+//	{{ "https://example.com/?a=1&a=2" | mustUrlQueryGet "a" }} // Output: "1", nil
+func (fh *FunctionHandler) MustUrlQueryGet(key string, rawURL string) (string, error) {
+	_, query, err := parseURLQuery(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return query.Get(key), nil
+}
+
+// UrlQuerySet replaces the values of the query parameter 'key' of a URL with
+// 'value'. The parameters of the resulting query are sorted by name. Invalid
+// URLs or queries produce an empty string.
+//
+// Parameters:
+//
+//	key string - the name of the parameter.
+//	value any - the new value of the parameter.
+//	rawURL string - the URL to update.
+//
+// Returns:
+//
+//	string - the updated URL.
+//
+// Example:
+//
+//	{{ "https://example.com/?page=2" | urlQuerySet "page" 3 }} // Output: "https://example.com/?page=3"
+func (fh *FunctionHandler) UrlQuerySet(key string, value any, rawURL string) string {
+	result, _ := fh.MustUrlQuerySet(key, value, rawURL)
+	return result
+}
+
+// MustUrlQuerySet replaces the values of the query parameter 'key' of a URL
+// with 'value', returning an error if the URL or its query is invalid.
+//
+// Parameters:
+//
+//	key string - the name of the parameter.
+//	value any - the new value of the parameter.
+//	rawURL string - the URL to update.
+//
+// Returns:
+//
+//	string - the updated URL.
+//	error - error if the URL or its query is malformed.
+//
+// Example:
+//
+//	{{ "/search" | mustUrlQuerySet "q" "a&b" }} // Output: "/search?q=a%26b", nil
+func (fh *FunctionHandler) MustUrlQuerySet(key string, value any, rawURL string) (string, error) {
+	return updateURLQuery(rawURL, func(query url.Values) {
+		query.Set(key, fh.ToString(value))
+	})
+}
+
+// UrlQueryAdd appends 'value' to the values of the query parameter 'key' of a
+// URL. The parameters of the resulting query are sorted by name. Invalid URLs
+// or queries produce an empty string.
+//
+// Parameters:
+//
+//	key string - the name of the parameter.
+//	value any - the value to append.
+//	rawURL string - the URL to update.
+//
+// Returns:
+//
+//	string - the updated URL.
+//
+// Example:
+//
+//	{{ "https://example.com/?tag=a" | urlQueryAdd "tag" "b" }} // Output: "https://example.com/?tag=a&tag=b"
+func (fh *FunctionHandler) UrlQueryAdd(key string, value any, rawURL string) string {
+	result, _ := fh.MustUrlQueryAdd(key, value, rawURL)
+	return result
+}
+
+// MustUrlQueryAdd appends 'value' to the values of the query parameter 'key'
+// of a URL, returning an error if the URL or its query is invalid.
+//
+// Parameters:
+//
+//	key string - the name of the parameter.
+//	value any - the value to append.
+//	rawURL string - the URL to update.
+//
+// Returns:
+//
+//	string - the updated URL.
+//	error - error if the URL or its query is malformed.
+//
+// Example:
+//
+//	{{ "https://example.com/" | mustUrlQueryAdd "tag" "a" }} // Output: "https://example.com/?tag=a", nil
+func (fh *FunctionHandler) MustUrlQueryAdd(key string, value any, rawURL string) (string, error) {
+	return updateURLQuery(rawURL, func(query url.Values) {
+		query.Add(key, fh.ToString(value))
+	})
+}
+
+// UrlQueryDel removes the query parameter 'key' from a URL. The parameters of
+// the resulting query are sorted by name. Invalid URLs or queries produce an
+// empty string.
+//
+// Parameters:
+//
+//	key string - the name of the parameter.
+//	rawURL string - the URL to update.
+//
+// Returns:
+//
+//	string - the updated URL.
+//
+// Example:
+//
+//	{{ "https://example.com/?page=2&q=go" | urlQueryDel "page" }} // Output: "https://example.com/?q=go"
+func (fh *FunctionHandler) UrlQueryDel(key string, rawURL string) string {
+	result, _ := fh.MustUrlQueryDel(key, rawURL)
+	return result
+}
+
+// MustUrlQueryDel removes the query parameter 'key' from a URL, returning an
+// error if the URL or its query is invalid.
+//
+// Parameters:
+//
+//	key string - the name of the parameter.
+//	rawURL string - the URL to update.
+//
+// Returns:
+//
+//	string - the updated URL.
+//	error - error if the URL or its query is malformed.
+//
+// Example:
+//
+//	{{ "https://example.com/?page=2" | mustUrlQueryDel "page" }} // Output: "https://example.com/", nil
+func (fh *FunctionHandler) MustUrlQueryDel(key string, rawURL string) (string, error) {
+	return updateURLQuery(rawURL, func(query url.Values) {
+		query.Del(key)
+	})
+}
+
+// UrlJoinPath appends path segments to the path of a base URL. Each segment
+// is escaped, so slashes and other reserved characters it contains are kept
+// as part of the segment, and the resulting path is cleaned of "." and ".."
+// elements. Invalid base URLs produce an empty string.
+//
+// Parameters:
+//
+//	base string - the URL to append the segments to.
+//	segments ...any - the path segments.
+//
+// Returns:
+//
+//	string - the resulting URL.
+//
+// Example:
+//
+//	{{ urlJoinPath "https://example.com/api/" "users" "jane doe" }} // Output: "https://example.com/api/users/jane%20doe"
+func (fh *FunctionHandler) UrlJoinPath(base string, segments ...any) string {
+	result, _ := fh.MustUrlJoinPath(base, segments...)
+	return result
+}
+
+// MustUrlJoinPath appends escaped path segments to the path of a base URL,
+// returning an error if the base URL is invalid.
+//
+// Parameters:
+//
+//	base string - the URL to append the segments to.
+//	segments ...any - the path segments.
+//
+// Returns:
+//
+//	string - the resulting URL.
+//	error - error if the base URL is malformed.
+//
+// Example:
+//
+//	{{ mustUrlJoinPath "/files" "a/b.txt" }} // Output: "/files/a%2Fb.txt", nil
+func (fh *FunctionHandler) MustUrlJoinPath(base string, segments ...any) (string, error) {
+	parsedURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	escaped := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		escaped = append(escaped, url.PathEscape(fh.ToString(segment)))
+	}
+	return parsedURL.JoinPath(escaped...).String(), nil
+}
+
+// UrlResolve resolves the reference 'ref' against the URL 'base', as a
+// browser resolves a link found in a page. Invalid URLs produce an empty
+// string.
+//
+// Parameters:
+//
+//	base string - the base URL.
+//	ref string - the reference to resolve, absolute or relative.
+//
+// Returns:
+//
+//	string - the resolved URL.
+//
+// Example:
+//
+//	{{ "../img/logo.png" | urlResolve "https://example.com/docs/guide/" }} // Output: "https://example.com/docs/img/logo.png"
+func (fh *FunctionHandler) UrlResolve(base string, ref string) string {
+	result, _ := fh.MustUrlResolve(base, ref)
+	return result
+}
+
+// MustUrlResolve resolves the reference 'ref' against the URL 'base',
+// returning an error if either URL is invalid.
+//
+// Parameters:
+//
+//	base string - the base URL.
+//	ref string - the reference to resolve, absolute or relative.
+//
+// Returns:
+//
+//	string - the resolved URL.
+//	error - error if the base URL or the reference is malformed.
+//
+// Example:
+//
+//	{{ "?page=2" | mustUrlResolve "https://example.com/docs?page=1" }} // Output: "https://example.com/docs?page=2", nil
+func (fh *FunctionHandler) MustUrlResolve(base string, ref string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(refURL).String(), nil
+}
+
+// UrlPathEscape percent-encodes a string to be used as a path segment, where
+// spaces become "%20" and slashes are escaped.
+//
+// Parameters:
+//
+//	str string - the string to escape.
+//
+// Returns:
+//
+//	string - the escaped string.
+//
+// Example:
+//
+//	{{ "a b/c" | urlPathEscape }} // Output: "a%20b%2Fc"
+func (fh *FunctionHandler) UrlPathEscape(str string) string {
+	return url.PathEscape(str)
+}
+
+// UrlPathUnescape decodes a percent-encoded path segment. Unlike
+// urlQueryUnescape, "+" is kept as is. Invalid escapes produce an empty
+// string. It is also registered as percentDecode, the inverse of
+// percentEncode.
+//
+// Parameters:
+//
+//	str string - the string to decode.
+//
+// Returns:
+//
+//	string - the decoded string.
+//
+// Example:
+//
+//	{{ "a%20b+c" | urlPathUnescape }} // Output: "a b+c"
+func (fh *FunctionHandler) UrlPathUnescape(str string) string {
+	result, _ := fh.MustUrlPathUnescape(str)
+	return result
+}
+
+// MustUrlPathUnescape decodes a percent-encoded path segment, returning an
+// error if an escape is invalid.
+//
+// Parameters:
+//
+//	str string - the string to decode.
+//
+// Returns:
+//
+//	string - the decoded string.
+//	error - error if the string contains an invalid escape.
+//
+// Example:
+//
+//	{{ "a%2Fb" | mustUrlPathUnescape }} // Output: "a/b", nil
+func (fh *FunctionHandler) MustUrlPathUnescape(str string) (string, error) {
+	return url.PathUnescape(str)
+}
+
+// UrlQueryEscape percent-encodes a string to be used as a query parameter
+// name or value, where spaces become "+".
+//
+// Parameters:
+//
+//	str string - the string to escape.
+//
+// Returns:
+//
+//	string - the escaped string.
+//
+// Example:
+//
+//	{{ "a b&c" | urlQueryEscape }} // Output: "a+b%26c"
+func (fh *FunctionHandler) UrlQueryEscape(str string) string {
+	return url.QueryEscape(str)
+}
+
+// UrlQueryUnescape decodes a percent-encoded query parameter name or value,
+// where "+" stands for a space. Invalid escapes produce an empty string.
+//
+// Parameters:
+//
+//	str string - the string to decode.
+//
+// Returns:
+//
+//	string - the decoded string.
+//
+// Example:
+//
+//	{{ "a+b%26c" | urlQueryUnescape }} // Output: "a b&c"
+func (fh *FunctionHandler) UrlQueryUnescape(str string) string {
+	result, _ := fh.MustUrlQueryUnescape(str)
+	return result
+}
+
+// MustUrlQueryUnescape decodes a percent-encoded query parameter name or
+// value, returning an error if an escape is invalid.
+//
+// Parameters:
+//
+//	str string - the string to decode.
+//
+// Returns:
+//
+//	string - the decoded string.
+//	error - error if the string contains an invalid escape.
+//
+// Example:
+//
+//	{{ "100%" | mustUrlQueryUnescape }} // Output: "", error
+func (fh *FunctionHandler) MustUrlQueryUnescape(str string) (string, error) {
+	return url.QueryUnescape(str)
+}
+
+// parseURLQuery parses a URL and its query, reporting invalid escapes in the
+// query that url.URL.Query silently drops.
+func parseURLQuery(rawURL string) (*url.URL, url.Values, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	query, err := url.ParseQuery(parsedURL.RawQuery)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid query in %q: %w", rawURL, err)
+	}
+	return parsedURL, query, nil
+}
+
+// updateURLQuery applies 'update' to the query of a URL and returns the URL
+// with the updated query.
+func updateURLQuery(rawURL string, update func(url.Values)) (string, error) {
+	parsedURL, query, err := parseURLQuery(rawURL)
+	if err != nil {
+		return "", err
+	}
+	update(query)
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String(), nil
+}
+
+// Resolver performs the DNS lookups of a FunctionHandler. It is implemented by
+// *net.Resolver, used by default, and by StaticResolver for tests.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// StaticResolver is a Resolver answering from in-memory records, so that
+// templates performing DNS lookups can be rendered reproducibly. Names missing
+// from the records are reported as not found. Service records are keyed by
+// their full name, such as "_http._tcp.example.com".
+type StaticResolver struct {
+	Hosts map[string][]string
+	CNAME map[string]string
+	SRV   map[string][]*net.SRV
+	TXT   map[string][]string
+	MX    map[string][]*net.MX
+}
+
+// LookupHost returns the addresses of 'host'.
+func (r StaticResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addrs, ok := r.Hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, notFoundError(host)
+}
+
+// LookupCNAME returns the canonical name of 'host'.
+func (r StaticResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if cname, ok := r.CNAME[host]; ok {
+		return cname, nil
+	}
+	return "", notFoundError(host)
+}
+
+// LookupSRV returns the service records of '_service._proto.name', or of
+// 'name' when both 'service' and 'proto' are empty.
+func (r StaticResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	target := name
+	if service != "" || proto != "" {
+		target = "_" + service + "._" + proto + "." + name
+	}
+	if records, ok := r.SRV[target]; ok {
+		return target, records, nil
+	}
+	return "", nil, notFoundError(target)
+}
+
+// LookupTXT returns the text records of 'name'.
+func (r StaticResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if records, ok := r.TXT[name]; ok {
+		return records, nil
+	}
+	return nil, notFoundError(name)
+}
+
+// LookupMX returns the mail exchanger records of 'name'.
+func (r StaticResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	if records, ok := r.MX[name]; ok {
+		return records, nil
+	}
+	return nil, notFoundError(name)
+}
+
+// WithResolver makes the handler perform DNS lookups with 'r' instead of the
+// system resolver.
+//
+// Example:
+//
+//	handler := NewFunctionHandler(WithResolver(StaticResolver{
+//		Hosts: map[string][]string{"db.internal": {"10.0.0.5"}},
+//	}))
+func WithResolver(r Resolver) FunctionHandlerOption {
+	return func(p *FunctionHandler) {
+		p.resolver = r
+	}
+}
+
+// WithNetwork enables or disables the network access of the handler's
+// functions. Network access is enabled by default; once disabled, every DNS
+// lookup fails, including those of a resolver set with WithResolver, so
+// templates rendered with the handler cannot reach the network.
+//
+// Example:
+//
+//	handler := NewFunctionHandler(WithNetwork(false))
+func WithNetwork(enabled bool) FunctionHandlerOption {
+	return func(p *FunctionHandler) {
+		p.offline = !enabled
+	}
+}
+
+// LookupHost returns all the IPv4 and IPv6 addresses of a host name. Names
+// that cannot be resolved produce an empty list.
+//
+// Parameters:
+//
+//	name string - the host name to resolve.
+//
+// Returns:
+//
+//	[]string - the addresses of the host.
+//
+// Example:
+//
+//	{{ lookupHost "localhost" }} // Output: [127.0.0.1 ::1]
+func (fh *FunctionHandler) LookupHost(name string) []string {
+	result, _ := fh.MustLookupHost(name)
+	return result
+}
+
+// MustLookupHost returns all the IPv4 and IPv6 addresses of a host name,
+// returning an error if the lookup fails.
+//
+// Parameters:
+//
+//	name string - the host name to resolve.
+//
+// Returns:
+//
+//	[]string - the addresses of the host.
+//	error - error if the lookup fails or times out, or if network access is
+//	        disabled.
+//
+// Example:
+//
+//	{{ mustLookupHost "localhost" }} // Output: [127.0.0.1 ::1], nil
+func (fh *FunctionHandler) MustLookupHost(name string) ([]string, error) {
+	ctx, cancel := fh.operationContext()
+	defer cancel()
+
+	addrs, err := fh.getResolver().LookupHost(ctx, name)
+	if err != nil {
+		return []string{}, err
+	}
+	return addrs, nil
+}
+
+// LookupCname returns the canonical name of a host name, following its CNAME
+// records. Names that cannot be resolved produce an empty string.
+//
+// Parameters:
+//
+//	name string - the host name to resolve.
+//
+// Returns:
+//
+//	string - the canonical name, fully qualified.
+//
+// Example:
+//
+//	{{ lookupCname "www.example.com" }} // Output: "example.com."
+func (fh *FunctionHandler) LookupCname(name string) string {
+	result, _ := fh.MustLookupCname(name)
+	return result
+}
+
+// MustLookupCname returns the canonical name of a host name, returning an
+// error if the lookup fails.
+//
+// Parameters:
+//
+//	name string - the host name to resolve.
+//
+// Returns:
+//
+//	string - the canonical name, fully qualified.
+//	error - error if the lookup fails or times out, or if network access is
+//	        disabled.
+//
+// Example:
+//
+//	{{ mustLookupCname "www.example.com" }} // Output: "example.com.", nil
+func (fh *FunctionHandler) MustLookupCname(name string) (string, error) {
+	ctx, cancel := fh.operationContext()
+	defer cancel()
+
+	return fh.getResolver().LookupCNAME(ctx, name)
+}
+
+// LookupSrv returns the SRV records of a service as a list of dicts with the
+// keys "target", "port", "priority" and "weight". When 'service' and 'proto'
+// are empty, 'name' is looked up directly. Failed lookups produce an empty
+// list.
+//
+// Parameters:
+//
+//	service string - the service name, such as "http", without underscore.
+//	proto string - the protocol, "tcp" or "udp".
+//	name string - the domain of the service.
+//
+// Returns:
+//
+//	[]map[string]any - the service records.
+//
+// Example:
+//
+//	{{ range lookupSrv "ldap" "tcp" "example.com" }}{{ .target }}:{{ .port }} {{ end }} // Output: "ldap1.example.com.:389 "
+func (fh *FunctionHandler) LookupSrv(service string, proto string, name string) []map[string]any {
+	result, _ := fh.MustLookupSrv(service, proto, name)
+	return result
+}
+
+// MustLookupSrv returns the SRV records of a service as a list of dicts,
+// returning an error if the lookup fails.
+//
+// Parameters:
+//
+//	service string - the service name, such as "http", without underscore.
+//	proto string - the protocol, "tcp" or "udp".
+//	name string - the domain of the service.
+//
+// Returns:
+//
+//	[]map[string]any - the service records.
+//	error - error if the lookup fails or times out, or if network access is
+//	        disabled.
+//
+// Example:
+//
+//	{{ mustLookupSrv "" "" "_sip._udp.example.com" }} // Output: [map[port:5060 priority:10 target:sip.example.com. weight:5]], nil
+func (fh *FunctionHandler) MustLookupSrv(service string, proto string, name string) ([]map[string]any, error) {
+	ctx, cancel := fh.operationContext()
+	defer cancel()
+
+	_, records, err := fh.getResolver().LookupSRV(ctx, service, proto, name)
+	if err != nil {
+		return []map[string]any{}, err
+	}
+
+	result := make([]map[string]any, 0, len(records))
+	for _, record := range records {
+		result = append(result, map[string]any{
+			"target":   record.Target,
+			"port":     int(record.Port),
+			"priority": int(record.Priority),
+			"weight":   int(record.Weight),
+		})
+	}
+	return result, nil
+}
+
+// LookupTxt returns the TXT records of a domain. Failed lookups produce an
+// empty list.
+//
+// Parameters:
+//
+//	name string - the domain to look up.
+//
+// Returns:
+//
+//	[]string - the text records.
+//
+// Example:
+//
+//	{{ lookupTxt "example.com" }} // Output: [v=spf1 -all]
+func (fh *FunctionHandler) LookupTxt(name string) []string {
+	result, _ := fh.MustLookupTxt(name)
+	return result
+}
+
+// MustLookupTxt returns the TXT records of a domain, returning an error if
+// the lookup fails.
+//
+// Parameters:
+//
+//	name string - the domain to look up.
+//
+// Returns:
+//
+//	[]string - the text records.
+//	error - error if the lookup fails or times out, or if network access is
+//	        disabled.
+//
+// Example:
+//
+//	{{ mustLookupTxt "example.com" }} // Output: [v=spf1 -all], nil
+func (fh *FunctionHandler) MustLookupTxt(name string) ([]string, error) {
+	ctx, cancel := fh.operationContext()
+	defer cancel()
+
+	records, err := fh.getResolver().LookupTXT(ctx, name)
+	if err != nil {
+		return []string{}, err
+	}
+	return records, nil
+}
+
+// LookupMx returns the MX records of a domain as a list of dicts with the keys
+// "host" and "pref". Failed lookups produce an empty list.
+//
+// Parameters:
+//
+//	name string - the domain to look up.
+//
+// Returns:
+//
+//	[]map[string]any - the mail exchanger records.
+//
+// Example:
+//
+//	{{ range lookupMx "example.com" }}{{ .host }} {{ end }} // Output: "mx1.example.com. mx2.example.com. "
+func (fh *FunctionHandler) LookupMx(name string) []map[string]any {
+	result, _ := fh.MustLookupMx(name)
+	return result
+}
+
+// MustLookupMx returns the MX records of a domain as a list of dicts,
+// returning an error if the lookup fails.
+//
+// Parameters:
+//
+//	name string - the domain to look up.
+//
+// Returns:
+//
+//	[]map[string]any - the mail exchanger records.
+//	error - error if the lookup fails or times out, or if network access is
+//	        disabled.
+//
+// Example:
+//
+//	{{ mustLookupMx "example.com" }} // Output: [map[host:mx1.example.com. pref:10]], nil
+func (fh *FunctionHandler) MustLookupMx(name string) ([]map[string]any, error) {
+	ctx, cancel := fh.operationContext()
+	defer cancel()
+
+	records, err := fh.getResolver().LookupMX(ctx, name)
+	if err != nil {
+		return []map[string]any{}, err
+	}
+
+	result := make([]map[string]any, 0, len(records))
+	for _, record := range records {
+		result = append(result, map[string]any{
+			"host": record.Host,
+			"pref": int(record.Pref),
+		})
+	}
+	return result, nil
+}
+
+// getResolver returns the resolver given to the handler, or the system
+// resolver, unless network access is disabled.
+func (fh *FunctionHandler) getResolver() Resolver {
+	switch {
+	case fh.offline:
+		return offlineResolver{}
+	case fh.resolver != nil:
+		return fh.resolver
+	default:
+		return net.DefaultResolver
+	}
+}
+
+// offlineResolver is the Resolver of handlers without network access, failing
+// every lookup.
+type offlineResolver struct{}
+
+func (offlineResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	return nil, networkDisabledError(host)
+}
+
+func (offlineResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	return "", networkDisabledError(host)
+}
+
+func (offlineResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if service != "" || proto != "" {
+		name = "_" + service + "._" + proto + "." + name
+	}
+	return "", nil, networkDisabledError(name)
+}
+
+func (offlineResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return nil, networkDisabledError(name)
+}
+
+func (offlineResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	return nil, networkDisabledError(name)
+}
+
+// networkDisabledError returns the error reported for lookups performed
+// without network access.
+func networkDisabledError(name string) error {
+	return &net.DNSError{Err: "network access is disabled", Name: name}
+}
+
+// notFoundError returns the error reported by StaticResolver for names
+// without records.
+func notFoundError(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
@@ -1,8 +1,12 @@
 package sprout
 
 import (
+	"fmt"
 	"math"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cast"
 )
@@ -362,3 +366,272 @@ func (fh *FunctionHandler) Maxf(a any, i ...any) float64 {
 	}
 	return aa
 }
+
+// byteUnitsIEC and byteUnitsSI are the units used to format byte sizes in
+// powers of 1024 and 1000.
+var (
+	byteUnitsIEC = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	byteUnitsSI  = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+)
+
+// byteSizeMultipliers maps the lowercased units accepted when parsing a byte
+// size to their value in bytes. Decimal units are powers of 1000 and binary
+// units powers of 1024, following the SI and IEC conventions.
+var byteSizeMultipliers = map[string]float64{
+	"": 1, "b": 1, "byte": 1, "bytes": 1,
+	"k": 1e3, "kb": 1e3, "ki": 1 << 10, "kib": 1 << 10,
+	"m": 1e6, "mb": 1e6, "mi": 1 << 20, "mib": 1 << 20,
+	"g": 1e9, "gb": 1e9, "gi": 1 << 30, "gib": 1 << 30,
+	"t": 1e12, "tb": 1e12, "ti": 1 << 40, "tib": 1 << 40,
+	"p": 1e15, "pb": 1e15, "pi": 1 << 50, "pib": 1 << 50,
+	"e": 1e18, "eb": 1e18, "ei": 1 << 60, "eib": 1 << 60,
+}
+
+// byteSizeRegex matches a byte size made of a number and an optional unit.
+var byteSizeRegex = regexp.MustCompile(`^\s*([+-]?(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?)\s*([A-Za-z]*)\s*$`)
+
+// groupableNumberRegex matches the numbers accepted by GroupThousands.
+var groupableNumberRegex = regexp.MustCompile(`^[+-]?\d+(\.\d+)?$`)
+
+// siPrefixes are the SI prefixes from 10^-18 to 10^18, by steps of 10^3.
+var siPrefixes = []string{"a", "f", "p", "n", "µ", "m", "", "k", "M", "G", "T", "P", "E"}
+
+// FormatBytes formats 'size', a number of bytes, using binary units (KiB, MiB,
+// GiB...) with at most 'precision' decimals. Trailing zeros are removed.
+//
+// Parameters:
+//
+//	precision int - the maximum number of decimals.
+//	size any - the number of bytes.
+//
+// Returns:
+//
+//	string - the formatted size, or an empty string if 'size' is not a number.
+//
+// Example:
+//
+//	{{ 1610612736 | formatBytes 1 }} // Output: "1.5 GiB"
+func (fh *FunctionHandler) FormatBytes(precision int, size any) string {
+	return formatByteSize(precision, size, 1024, byteUnitsIEC)
+}
+
+// FormatBytesSI formats 'size', a number of bytes, using decimal units (kB,
+// MB, GB...) with at most 'precision' decimals. Trailing zeros are removed.
+//
+// Parameters:
+//
+//	precision int - the maximum number of decimals.
+//	size any - the number of bytes.
+//
+// Returns:
+//
+//	string - the formatted size, or an empty string if 'size' is not a number.
+//
+// Example:
+//
+//	{{ 320000 | formatBytesSI 1 }} // Output: "320 kB"
+func (fh *FunctionHandler) FormatBytesSI(precision int, size any) string {
+	return formatByteSize(precision, size, 1000, byteUnitsSI)
+}
+
+// ParseBytes parses a byte size such as "512Mi", "1.5 GiB" or "320kB" into a
+// number of bytes. Units without "i" are decimal (k = 1000), units with "i"
+// are binary (Ki = 1024); units are case-insensitive. Invalid sizes return 0.
+//
+// Parameters:
+//
+//	str string - the byte size to parse.
+//
+// Returns:
+//
+//	int64 - the number of bytes, rounded to the nearest integer.
+//
+// Example:
+//
+//	{{ "512Mi" | parseBytes }} // Output: 536870912
+func (fh *FunctionHandler) ParseBytes(str string) int64 {
+	result, _ := fh.MustParseBytes(str)
+	return result
+}
+
+// MustParseBytes parses a byte size such as "512Mi", "1.5 GiB" or "320kB" into
+// a number of bytes, returning an error if the size is invalid.
+//
+// Parameters:
+//
+//	str string - the byte size to parse.
+//
+// Returns:
+//
+//	int64 - the number of bytes, rounded to the nearest integer.
+//	error - error if the size is malformed, its unit unknown or it overflows.
+//
+// Example:
+//
+//	{{ "1.5 GB" | mustParseBytes }} // Output: 1500000000, nil
+func (fh *FunctionHandler) MustParseBytes(str string) (int64, error) {
+	matches := byteSizeRegex.FindStringSubmatch(str)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid byte size: %q", str)
+	}
+
+	multiplier, ok := byteSizeMultipliers[strings.ToLower(matches[2])]
+	if !ok {
+		return 0, fmt.Errorf("unknown byte size unit: %q", matches[2])
+	}
+
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size: %q", str)
+	}
+
+	size := math.Round(value * multiplier)
+	if size >= math.MaxInt64 || size < math.MinInt64 {
+		return 0, fmt.Errorf("byte size out of range: %q", str)
+	}
+	return int64(size), nil
+}
+
+// FormatSI formats 'value' with an SI prefix (k, M, G... or m, µ, n...) and at
+// most 'precision' decimals. Trailing zeros are removed and no space is added,
+// so a unit can directly follow.
+//
+// Parameters:
+//
+//	precision int - the maximum number of decimals.
+//	value any - the number to format.
+//
+// Returns:
+//
+//	string - the formatted number, or an empty string if 'value' is not a number.
+//
+// Example:
+//
+//	{{ 12345 | formatSI 1 }} requests // Output: "12.3k requests"
+//	{{ 0.0042 | formatSI 1 }}s // Output: "4.2ms"
+func (fh *FunctionHandler) FormatSI(precision int, value any) string {
+	number, err := cast.ToFloat64E(value)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return ""
+	}
+
+	sign, number := splitSign(number)
+	exponent := 0
+	for number >= 1000 && exponent < 6 {
+		number /= 1000
+		exponent++
+	}
+	for number != 0 && number < 1 && exponent > -6 {
+		number *= 1000
+		exponent--
+	}
+	if roundDecimals(number, precision) >= 1000 && exponent < 6 {
+		number /= 1000
+		exponent++
+	}
+
+	return sign + formatDecimals(number, precision) + siPrefixes[exponent+6]
+}
+
+// GroupThousands formats 'value' with 'separator' between each group of three
+// digits of its integer part. Strings are grouped as written, which preserves
+// the decimals of numbers too large or too precise for a float.
+//
+// Parameters:
+//
+//	separator string - the string inserted between groups of digits.
+//	value any - the number to format.
+//
+// Returns:
+//
+//	string - the grouped number, or an empty string if 'value' is not a number.
+//
+// Example:
+//
+//	{{ 1234567.5 | groupThousands "," }} // Output: "1,234,567.5"
+//	{{ 1234567 | groupThousands " " }} // Output: "1 234 567"
+func (fh *FunctionHandler) GroupThousands(separator string, value any) string {
+	var number string
+	switch v := value.(type) {
+	case string:
+		number = strings.TrimSpace(v)
+	case float32:
+		number = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		number = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		number = fmt.Sprint(value)
+	}
+	if !groupableNumberRegex.MatchString(number) {
+		return ""
+	}
+
+	sign := ""
+	if number[0] == '-' || number[0] == '+' {
+		sign, number = number[:1], number[1:]
+	}
+	integer, decimals, _ := strings.Cut(number, ".")
+	if decimals != "" {
+		decimals = "." + decimals
+	}
+
+	var builder strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			builder.WriteString(separator)
+		}
+		builder.WriteRune(digit)
+	}
+	return sign + builder.String() + decimals
+}
+
+// formatByteSize formats 'size' in the largest unit in which it is at least
+// 1, each unit being 'base' times the previous one. Sizes in bytes are
+// written without decimals.
+func formatByteSize(precision int, size any, base float64, units []string) string {
+	number, err := cast.ToFloat64E(size)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return ""
+	}
+
+	sign, number := splitSign(number)
+	unit := 0
+	for number >= base && unit < len(units)-1 {
+		number /= base
+		unit++
+	}
+	if unit == 0 {
+		precision = 0
+	}
+	if roundDecimals(number, precision) >= base && unit < len(units)-1 {
+		number /= base
+		unit++
+	}
+
+	return sign + formatDecimals(number, precision) + " " + units[unit]
+}
+
+// splitSign returns the sign prefix of 'number' and its absolute value.
+func splitSign(number float64) (string, float64) {
+	if number < 0 {
+		return "-", -number
+	}
+	return "", number
+}
+
+// roundDecimals rounds 'number' to 'precision' decimals, as formatDecimals
+// does.
+func roundDecimals(number float64, precision int) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(number, 'f', max(precision, 0), 64), 64)
+	return rounded
+}
+
+// formatDecimals formats 'number' with at most 'precision' decimals, removing
+// trailing zeros.
+func formatDecimals(number float64, precision int) string {
+	formatted := strconv.FormatFloat(number, 'f', max(precision, 0), 64)
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	}
+	return formatted
+}
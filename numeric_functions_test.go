@@ -220,3 +220,98 @@ func TestMaxf(t *testing.T) {
 
 	runTestCases(t, tests)
 }
+
+func TestFormatBytes(t *testing.T) {
+	var tests = testCases{
+		{"TestZero", `{{ 0 | formatBytes 1 }}`, "0 B", nil},
+		{"TestBytes", `{{ 512 | formatBytes 2 }}`, "512 B", nil},
+		{"TestKibibytes", `{{ 1536 | formatBytes 1 }}`, "1.5 KiB", nil},
+		{"TestGibibytes", `{{ 1610612736 | formatBytes 1 }}`, "1.5 GiB", nil},
+		{"TestPrecision", `{{ 1234567 | formatBytes 3 }}`, "1.177 MiB", nil},
+		{"TestNoDecimals", `{{ 1234567 | formatBytes 0 }}`, "1 MiB", nil},
+		{"TestTrailingZeros", `{{ 1048576 | formatBytes 2 }}`, "1 MiB", nil},
+		{"TestRoundingCarry", `{{ 1048575 | formatBytes 1 }}`, "1 MiB", nil},
+		{"TestNegative", `{{ -2048 | formatBytes 1 }}`, "-2 KiB", nil},
+		{"TestLargest", `{{ 9223372036854775807 | formatBytes 1 }}`, "8 EiB", nil},
+		{"TestString", `{{ "2048" | formatBytes 1 }}`, "2 KiB", nil},
+		{"TestInvalid", `{{ "many" | formatBytes 1 }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestFormatBytesSI(t *testing.T) {
+	var tests = testCases{
+		{"TestBytes", `{{ 999 | formatBytesSI 1 }}`, "999 B", nil},
+		{"TestKilobytes", `{{ 320000 | formatBytesSI 1 }}`, "320 kB", nil},
+		{"TestMegabytes", `{{ 1500000 | formatBytesSI 1 }}`, "1.5 MB", nil},
+		{"TestRoundingCarry", `{{ 999999 | formatBytesSI 1 }}`, "1 MB", nil},
+		{"TestFloat", `{{ 2.5e9 | formatBytesSI 2 }}`, "2.5 GB", nil},
+		{"TestInvalid", `{{ "many" | formatBytesSI 1 }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestParseBytes(t *testing.T) {
+	var tests = testCases{
+		{"TestPlain", `{{ "512" | parseBytes }}`, "512", nil},
+		{"TestBytes", `{{ "512B" | parseBytes }}`, "512", nil},
+		{"TestBinary", `{{ "512Mi" | parseBytes }}`, "536870912", nil},
+		{"TestBinaryLong", `{{ "1.5 GiB" | parseBytes }}`, "1610612736", nil},
+		{"TestDecimal", `{{ "320kB" | parseBytes }}`, "320000", nil},
+		{"TestDecimalShort", `{{ "2G" | parseBytes }}`, "2000000000", nil},
+		{"TestCaseInsensitive", `{{ "1kib" | parseBytes }}`, "1024", nil},
+		{"TestExponent", `{{ "1e3" | parseBytes }}`, "1000", nil},
+		{"TestRounding", `{{ "1.5" | parseBytes }}`, "2", nil},
+		{"TestNegative", `{{ "-1Ki" | parseBytes }}`, "-1024", nil},
+		{"TestInvalid", `{{ "lots" | parseBytes }}`, "0", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustParseBytes(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "1.5 GB" | mustParseBytes }}`, "1500000000", nil}, ""},
+		{testCase{"TestEmpty", `{{ "" | mustParseBytes }}`, "", nil}, `invalid byte size: ""`},
+		{testCase{"TestMalformed", `{{ "1.2.3M" | mustParseBytes }}`, "", nil}, `invalid byte size: "1.2.3M"`},
+		{testCase{"TestUnknownUnit", `{{ "12 parsecs" | mustParseBytes }}`, "", nil}, `unknown byte size unit: "parsecs"`},
+		{testCase{"TestOverflow", `{{ "16Ei" | mustParseBytes }}`, "", nil}, `byte size out of range: "16Ei"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestFormatSI(t *testing.T) {
+	var tests = testCases{
+		{"TestZero", `{{ 0 | formatSI 1 }}`, "0", nil},
+		{"TestUnit", `{{ 42 | formatSI 1 }}`, "42", nil},
+		{"TestKilo", `{{ 12345 | formatSI 1 }} requests`, "12.3k requests", nil},
+		{"TestMega", `{{ 2500000 | formatSI 2 }}`, "2.5M", nil},
+		{"TestMilli", `{{ 0.0042 | formatSI 1 }}s`, "4.2ms", nil},
+		{"TestMicro", `{{ 0.0000015 | formatSI 1 }}`, "1.5µ", nil},
+		{"TestRoundingCarry", `{{ 999999 | formatSI 1 }}`, "1M", nil},
+		{"TestRoundingCarryFraction", `{{ 0.99999 | formatSI 1 }}`, "1", nil},
+		{"TestNegative", `{{ -1500 | formatSI 1 }}`, "-1.5k", nil},
+		{"TestInvalid", `{{ "many" | formatSI 1 }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestGroupThousands(t *testing.T) {
+	var tests = testCases{
+		{"TestSmall", `{{ 123 | groupThousands "," }}`, "123", nil},
+		{"TestThousand", `{{ 1000 | groupThousands "," }}`, "1,000", nil},
+		{"TestMillion", `{{ 1234567 | groupThousands " " }}`, "1 234 567", nil},
+		{"TestFloat", `{{ 1234567.5 | groupThousands "," }}`, "1,234,567.5", nil},
+		{"TestNegative", `{{ -1234567 | groupThousands "." }}`, "-1.234.567", nil},
+		{"TestString", `{{ "9876543210.0001" | groupThousands "," }}`, "9,876,543,210.0001", nil},
+		{"TestUnsigned", `{{ .V | groupThousands "," }}`, "18,446,744,073,709,551,615", map[string]any{"V": uint64(18446744073709551615)}},
+		{"TestNil", `{{ .V | groupThousands "," }}`, "", map[string]any{"V": nil}},
+		{"TestInvalid", `{{ "12a" | groupThousands "," }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
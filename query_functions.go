@@ -0,0 +1,1275 @@
+package sprout
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Query evaluates a jq expression against a value, typically decoded with
+// fromJson or fromYaml, and returns all the values it produces.
+//
+// The supported subset of jq covers:
+//   - paths: ".", ".name", ".\"quoted name\"", ".[expr]", ".[]", ".[from:to]"
+//     and the recursive descent "..", with "?" to ignore errors;
+//   - the pipe "|", the comma ",", the alternative "//", and "and", "or";
+//   - the comparisons "==", "!=", "<", "<=", ">" and ">=";
+//   - string, number, boolean and null literals, and the array "[...]" and
+//     object "{key: expr}" constructors used for projections;
+//   - the functions select, map, has, length, keys, type, not and empty.
+//
+// Parameters:
+//
+//	expr string - the jq expression to evaluate.
+//	v any - the value to query.
+//
+// Returns:
+//
+//	[]any - the values produced by the expression, or nil on error.
+//
+// Example:
+//
+//	{{ .Manifest | fromJson | query `.items[] | select(.kind == "Service") | .metadata.name` }} // Output: [frontend backend]
+func (fh *FunctionHandler) Query(expr string, v any) []any {
+	result, _ := fh.MustQuery(expr, v)
+	return result
+}
+
+// QueryFirst evaluates a jq expression against a value and returns the first
+// value it produces, see Query for the supported syntax.
+//
+// Parameters:
+//
+//	expr string - the jq expression to evaluate.
+//	v any - the value to query.
+//
+// Returns:
+//
+//	any - the first value produced by the expression, or nil if there is none.
+//
+// Example:
+//
+//	{{ .Config | fromYaml | queryFirst ".servers[0].host" }} // Output: "alpha"
+func (fh *FunctionHandler) QueryFirst(expr string, v any) any {
+	result, _ := fh.MustQueryFirst(expr, v)
+	return result
+}
+
+// MustQuery evaluates a jq expression against a value and returns all the
+// values it produces, returning an error if the expression is invalid or
+// cannot be applied to the value, see Query for the supported syntax.
+//
+// Parameters:
+//
+//	expr string - the jq expression to evaluate.
+//	v any - the value to query.
+//
+// Returns:
+//
+//	[]any - the values produced by the expression.
+//	error - error if the expression is invalid or fails.
+//
+// Example:
+//
+//	{{ dict "a" (list 1 2 3) | mustQuery ".a[1:]" }} // Output: [[2 3]], nil
+func (fh *FunctionHandler) MustQuery(expr string, v any) ([]any, error) {
+	query, err := fh.compileQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := query(v)
+	if err != nil {
+		return nil, fmt.Errorf("query %q: %w", expr, err)
+	}
+	if result == nil {
+		result = []any{}
+	}
+	return result, nil
+}
+
+// MustQueryFirst evaluates a jq expression against a value and returns the
+// first value it produces, returning an error if the expression is invalid
+// or cannot be applied to the value.
+//
+// Parameters:
+//
+//	expr string - the jq expression to evaluate.
+//	v any - the value to query.
+//
+// Returns:
+//
+//	any - the first value produced by the expression, or nil if there is none.
+//	error - error if the expression is invalid or fails.
+//
+// Example:
+//
+//	{{ dict "a" (dict "b" 1) | mustQueryFirst ".a.b" }} // Output: 1, nil
+func (fh *FunctionHandler) MustQueryFirst(expr string, v any) (any, error) {
+	result, err := fh.MustQuery(expr, v)
+	if err != nil || len(result) == 0 {
+		return nil, err
+	}
+	return result[0], nil
+}
+
+// maxCachedQueries is the number of compiled queries a FunctionHandler keeps
+// before its cache is reset.
+const maxCachedQueries = 256
+
+// queryFunc evaluates a compiled query against its input.
+type queryFunc func(input any) ([]any, error)
+
+// queryCache holds the compiled queries of a FunctionHandler by expression.
+type queryCache struct {
+	mu      sync.Mutex
+	queries map[string]queryFunc
+}
+
+// compileQuery compiles a jq expression, reusing the handler's cache.
+func (fh *FunctionHandler) compileQuery(expr string) (queryFunc, error) {
+	if fh.queries == nil {
+		return compileQuery(expr)
+	}
+
+	fh.queries.mu.Lock()
+	defer fh.queries.mu.Unlock()
+	if query, ok := fh.queries.queries[expr]; ok {
+		return query, nil
+	}
+
+	query, err := compileQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(fh.queries.queries) >= maxCachedQueries {
+		fh.queries.queries = make(map[string]queryFunc)
+	}
+	fh.queries.queries[expr] = query
+	return query, nil
+}
+
+// compileQuery parses a jq expression into a function evaluating it.
+func compileQuery(expr string) (queryFunc, error) {
+	tokens, err := lexQuery(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", expr, err)
+	}
+
+	parser := &queryParser{tokens: tokens}
+	query, err := parser.parsePipe()
+	if err == nil && parser.peek().kind != queryTokenEOF {
+		err = parser.unexpected()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", expr, err)
+	}
+	return query, nil
+}
+
+type queryTokenKind int
+
+const (
+	queryTokenEOF     queryTokenKind = iota
+	queryTokenField                  // .name
+	queryTokenDot                    // .
+	queryTokenRecurse                // ..
+	queryTokenIdent                  // select, and, true...
+	queryTokenString                 // "text"
+	queryTokenNumber                 // 1.5
+	queryTokenPunct                  // | , ( ) [ ] { } : ? == != < <= > >= //
+)
+
+type queryToken struct {
+	kind   queryTokenKind
+	text   string
+	value  any
+	offset int
+}
+
+// lexQuery splits a jq expression into tokens.
+func lexQuery(expr string) ([]queryToken, error) {
+	var tokens []queryToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+
+		case c == '.':
+			switch {
+			case strings.HasPrefix(expr[i:], ".."):
+				tokens = append(tokens, queryToken{kind: queryTokenRecurse, text: "..", offset: start})
+				i += 2
+			case i+1 < len(expr) && isQueryIdentStart(expr[i+1]):
+				i++
+				for i < len(expr) && isQueryIdentPart(expr[i]) {
+					i++
+				}
+				tokens = append(tokens, queryToken{kind: queryTokenField, text: expr[start:i], value: expr[start+1 : i], offset: start})
+			default:
+				tokens = append(tokens, queryToken{kind: queryTokenDot, text: ".", offset: start})
+				i++
+			}
+
+		case isQueryIdentStart(c):
+			for i < len(expr) && isQueryIdentPart(expr[i]) {
+				i++
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenIdent, text: expr[start:i], offset: start})
+
+		case c == '"':
+			for i++; i < len(expr) && expr[i] != '"'; i++ {
+				if expr[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+			var value string
+			if err := json.Unmarshal([]byte(expr[start:i]), &value); err != nil {
+				return nil, fmt.Errorf("invalid string %s at offset %d", expr[start:i], start)
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenString, text: expr[start:i], value: value, offset: start})
+
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(expr) && expr[i+1] >= '0' && expr[i+1] <= '9':
+			for i++; i < len(expr) && (expr[i] >= '0' && expr[i] <= '9' || expr[i] == '.' || expr[i] == 'e' || expr[i] == 'E' ||
+				(expr[i] == '-' || expr[i] == '+') && (expr[i-1] == 'e' || expr[i-1] == 'E')); i++ {
+			}
+			value, err := strconv.ParseFloat(expr[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %s at offset %d", expr[start:i], start)
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenNumber, text: expr[start:i], value: value, offset: start})
+
+		default:
+			text := ""
+			for _, punct := range []string{"==", "!=", "<=", ">=", "//", "|", ",", "(", ")", "[", "]", "{", "}", ":", "?", "<", ">"} {
+				if strings.HasPrefix(expr[i:], punct) {
+					text = punct
+					break
+				}
+			}
+			if text == "" {
+				r, _ := utf8.DecodeRuneInString(expr[i:])
+				return nil, fmt.Errorf("unexpected character %q at offset %d", r, i)
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenPunct, text: text, offset: start})
+			i += len(text)
+		}
+	}
+	return append(tokens, queryToken{kind: queryTokenEOF, offset: len(expr)}), nil
+}
+
+func isQueryIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isQueryIdentPart(c byte) bool {
+	return isQueryIdentStart(c) || '0' <= c && c <= '9'
+}
+
+// queryParser builds query functions from tokens by recursive descent, from
+// the lowest precedence operator "|" to postfix paths.
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	token := p.tokens[p.pos]
+	if token.kind != queryTokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+// accept consumes the next token if it is the given punctuation or keyword.
+func (p *queryParser) accept(text string) bool {
+	token := p.peek()
+	if (token.kind == queryTokenPunct || token.kind == queryTokenIdent) && token.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) expect(text string) error {
+	if !p.accept(text) {
+		return fmt.Errorf("expected %q, %w", text, p.unexpected())
+	}
+	return nil
+}
+
+func (p *queryParser) unexpected() error {
+	token := p.peek()
+	if token.kind == queryTokenEOF {
+		return errors.New("unexpected end of query")
+	}
+	return fmt.Errorf("unexpected %s at offset %d", token.text, token.offset)
+}
+
+// parsePipe parses "a | b", feeding each output of a to b.
+func (p *queryParser) parsePipe() (queryFunc, error) {
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("|") {
+		right, err := p.parseComma()
+		if err != nil {
+			return nil, err
+		}
+		left = queryPipe(left, right)
+	}
+	return left, nil
+}
+
+// parseComma parses "a, b", concatenating the outputs of a and b.
+func (p *queryParser) parseComma() (queryFunc, error) {
+	left, err := p.parseAlternative()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(",") {
+		right, err := p.parseAlternative()
+		if err != nil {
+			return nil, err
+		}
+		first := left
+		left = func(input any) ([]any, error) {
+			result, err := first(input)
+			if err != nil {
+				return result, err
+			}
+			more, err := right(input)
+			return append(result, more...), err
+		}
+	}
+	return left, nil
+}
+
+// parseAlternative parses "a // b", producing the truthy outputs of a, or the
+// outputs of b if there are none.
+func (p *queryParser) parseAlternative() (queryFunc, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("//") {
+		return left, nil
+	}
+	right, err := p.parseAlternative()
+	if err != nil {
+		return nil, err
+	}
+	return func(input any) ([]any, error) {
+		values, _ := left(input)
+		var result []any
+		for _, value := range values {
+			if queryTruthy(value) {
+				result = append(result, value)
+			}
+		}
+		if len(result) > 0 {
+			return result, nil
+		}
+		return right(input)
+	}, nil
+}
+
+// parseOr parses "a or b".
+func (p *queryParser) parseOr() (queryFunc, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = queryBoolean(left, right, true)
+	}
+	return left, nil
+}
+
+// parseAnd parses "a and b".
+func (p *queryParser) parseAnd() (queryFunc, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") {
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = queryBoolean(left, right, false)
+	}
+	return left, nil
+}
+
+// parseComparison parses "a == b" and the other comparisons, which do not
+// chain.
+func (p *queryParser) parseComparison() (queryFunc, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.peek()
+	var test func(int) bool
+	switch token.text {
+	case "==":
+		test = func(c int) bool { return c == 0 }
+	case "!=":
+		test = func(c int) bool { return c != 0 }
+	case "<":
+		test = func(c int) bool { return c < 0 }
+	case "<=":
+		test = func(c int) bool { return c <= 0 }
+	case ">":
+		test = func(c int) bool { return c > 0 }
+	case ">=":
+		test = func(c int) bool { return c >= 0 }
+	}
+	if token.kind != queryTokenPunct || test == nil {
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	return func(input any) ([]any, error) {
+		lefts, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		rights, err := right(input)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]any, 0, len(lefts)*len(rights))
+		for _, l := range lefts {
+			for _, r := range rights {
+				result = append(result, test(queryCompare(l, r)))
+			}
+		}
+		return result, nil
+	}, nil
+}
+
+// querySuffix applies a path component to a value, given the input of the
+// term the path belongs to.
+type querySuffix func(input, value any) ([]any, error)
+
+// parsePostfix parses a term followed by path components, such as
+// ".a[0].b[]?". A "?" ignores the errors of the component before it, value
+// by value, or of the whole term if it has no path components.
+func (p *queryParser) parsePostfix() (queryFunc, error) {
+	term, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	var suffix querySuffix
+	base := term
+	for {
+		token := p.peek()
+		next := querySuffix(nil)
+		switch {
+		case token.kind == queryTokenField:
+			p.next()
+			next = queryFieldSuffix(token.value.(string))
+		case token.kind == queryTokenDot && p.tokens[p.pos+1].kind == queryTokenString:
+			p.next()
+			next = queryFieldSuffix(p.next().value.(string))
+		case token.kind == queryTokenDot && p.tokens[p.pos+1].text == "[":
+			p.next()
+			continue
+		case token.kind == queryTokenPunct && token.text == "[":
+			if next, err = p.parseBrackets(); err != nil {
+				return nil, err
+			}
+		case token.kind == queryTokenPunct && token.text == "?":
+			p.next()
+			if suffix == nil {
+				base = queryTry(base)
+				term = base
+				continue
+			}
+			suffix = queryTrySuffix(suffix)
+			term = queryChain(base, suffix)
+			continue
+		default:
+			return term, nil
+		}
+
+		base, suffix = term, next
+		term = queryChain(base, suffix)
+	}
+}
+
+// parseBrackets parses the iteration "[]", the index "[expr]" or the slice
+// "[from:to]".
+func (p *queryParser) parseBrackets() (querySuffix, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	if p.accept("]") {
+		return func(_, value any) ([]any, error) { return queryIterate(value) }, nil
+	}
+
+	var from, to queryFunc
+	var err error
+	if p.peek().text != ":" {
+		if from, err = p.parsePipe(); err != nil {
+			return nil, err
+		}
+	}
+	if !p.accept(":") {
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return func(input, value any) ([]any, error) {
+			return queryProduct(value, from, input, queryIndex)
+		}, nil
+	}
+
+	if p.peek().text != "]" {
+		if to, err = p.parsePipe(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	bounds := queryBounds(from, to)
+	return func(input, value any) ([]any, error) {
+		return queryProduct(value, bounds, input, func(value any, bounds any) (any, error) {
+			pair := bounds.([2]any)
+			return querySlice(value, pair[0], pair[1])
+		})
+	}, nil
+}
+
+// parseTerm parses a path, a literal, a parenthesized expression, a
+// constructor or a function call.
+func (p *queryParser) parseTerm() (queryFunc, error) {
+	if p.peek().kind == queryTokenEOF {
+		return nil, p.unexpected()
+	}
+	token := p.next()
+	switch token.kind {
+	case queryTokenDot:
+		if p.peek().kind == queryTokenString {
+			return queryField(p.next().value.(string)), nil
+		}
+		return queryIdentity, nil
+	case queryTokenField:
+		return queryField(token.value.(string)), nil
+	case queryTokenRecurse:
+		return queryRecurse, nil
+	case queryTokenString, queryTokenNumber:
+		return queryLiteral(token.value), nil
+	case queryTokenPunct:
+		switch token.text {
+		case "(":
+			query, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return query, p.expect(")")
+		case "[":
+			return p.parseArray()
+		case "{":
+			return p.parseObject()
+		}
+	case queryTokenIdent:
+		return p.parseFunction(token)
+	}
+	p.pos--
+	return nil, p.unexpected()
+}
+
+// parseArray parses "[expr]", collecting the outputs of expr in a list.
+func (p *queryParser) parseArray() (queryFunc, error) {
+	if p.accept("]") {
+		return func(any) ([]any, error) { return []any{[]any{}}, nil }, nil
+	}
+	query, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return func(input any) ([]any, error) {
+		values, err := query(input)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			values = []any{}
+		}
+		return []any{values}, nil
+	}, nil
+}
+
+// parseObject parses "{key: expr, ...}", where a key is a name, a string or
+// a parenthesized expression, and "{name}" is short for "{name: .name}".
+func (p *queryParser) parseObject() (queryFunc, error) {
+	type entry struct{ key, value queryFunc }
+	var entries []entry
+
+	for !p.accept("}") {
+		if len(entries) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		var key queryFunc
+		var name string
+		if p.peek().kind == queryTokenEOF {
+			return nil, p.unexpected()
+		}
+		token := p.next()
+		switch {
+		case token.kind == queryTokenIdent:
+			name = token.text
+			key = queryLiteral(name)
+		case token.kind == queryTokenString:
+			name = token.value.(string)
+			key = queryLiteral(name)
+		case token.kind == queryTokenPunct && token.text == "(":
+			var err error
+			if key, err = p.parsePipe(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		default:
+			p.pos--
+			return nil, fmt.Errorf("expected an object key, %w", p.unexpected())
+		}
+
+		value := queryField(name)
+		if p.accept(":") {
+			var err error
+			if value, err = p.parseAlternative(); err != nil {
+				return nil, err
+			}
+		} else if name == "" {
+			return nil, fmt.Errorf("expected \":\", %w", p.unexpected())
+		}
+		entries = append(entries, entry{key, value})
+	}
+
+	return func(input any) ([]any, error) {
+		results := []map[string]any{{}}
+		for _, entry := range entries {
+			keys, err := entry.key(input)
+			if err != nil {
+				return nil, err
+			}
+			values, err := entry.value(input)
+			if err != nil {
+				return nil, err
+			}
+
+			var next []map[string]any
+			for _, result := range results {
+				for _, key := range keys {
+					name, ok := key.(string)
+					if !ok {
+						return nil, fmt.Errorf("object keys must be strings, not %s", queryType(key))
+					}
+					for _, value := range values {
+						object := make(map[string]any, len(result)+1)
+						for k, v := range result {
+							object[k] = v
+						}
+						object[name] = value
+						next = append(next, object)
+					}
+				}
+			}
+			results = next
+		}
+
+		output := make([]any, len(results))
+		for i, result := range results {
+			output[i] = result
+		}
+		return output, nil
+	}, nil
+}
+
+// parseFunction parses the keywords and the calls of the built-in functions.
+func (p *queryParser) parseFunction(token queryToken) (queryFunc, error) {
+	switch token.text {
+	case "true", "false":
+		return queryLiteral(token.text == "true"), nil
+	case "null":
+		return queryLiteral(nil), nil
+	case "empty":
+		return func(any) ([]any, error) { return nil, nil }, nil
+	case "not":
+		return func(input any) ([]any, error) { return []any{!queryTruthy(input)}, nil }, nil
+	case "type":
+		return func(input any) ([]any, error) { return []any{queryType(input)}, nil }, nil
+	case "length":
+		return queryEach(queryLength), nil
+	case "keys":
+		return queryEach(queryKeys), nil
+	case "select", "map", "has":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		arg, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		switch token.text {
+		case "select":
+			return querySelect(arg), nil
+		case "map":
+			return queryArray(queryPipe(queryIterate, arg)), nil
+		default:
+			return func(input any) ([]any, error) {
+				return queryProduct(input, arg, input, queryHas)
+			}, nil
+		}
+	}
+	p.pos--
+	return nil, fmt.Errorf("unknown function %s at offset %d", token.text, token.offset)
+}
+
+func queryIdentity(input any) ([]any, error) {
+	return []any{input}, nil
+}
+
+func queryLiteral(value any) queryFunc {
+	return func(any) ([]any, error) { return []any{value}, nil }
+}
+
+// queryEach applies a function producing a single value to the input.
+func queryEach(fn func(any) (any, error)) queryFunc {
+	return func(input any) ([]any, error) {
+		value, err := fn(input)
+		if err != nil {
+			return nil, err
+		}
+		return []any{value}, nil
+	}
+}
+
+// queryPipe feeds each output of 'left' to 'right'.
+func queryPipe(left, right queryFunc) queryFunc {
+	return func(input any) ([]any, error) {
+		values, err := left(input)
+		if err != nil {
+			return values, err
+		}
+		var result []any
+		for _, value := range values {
+			outputs, err := right(value)
+			result = append(result, outputs...)
+			if err != nil {
+				return result, err
+			}
+		}
+		return result, nil
+	}
+}
+
+// queryProduct applies 'fn' to a value with each output of 'arg' evaluated
+// against the input.
+func queryProduct(value any, arg queryFunc, input any, fn func(value, arg any) (any, error)) ([]any, error) {
+	args, err := arg(input)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]any, 0, len(args))
+	for _, arg := range args {
+		output, err := fn(value, arg)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, output)
+	}
+	return result, nil
+}
+
+// queryChain applies a path component to each output of a term.
+func queryChain(term queryFunc, suffix querySuffix) queryFunc {
+	return func(input any) ([]any, error) {
+		values, err := term(input)
+		if err != nil {
+			return values, err
+		}
+		var result []any
+		for _, value := range values {
+			outputs, err := suffix(input, value)
+			result = append(result, outputs...)
+			if err != nil {
+				return result, err
+			}
+		}
+		return result, nil
+	}
+}
+
+// queryTry drops the error of a query, keeping the outputs produced before.
+func queryTry(query queryFunc) queryFunc {
+	return func(input any) ([]any, error) {
+		result, _ := query(input)
+		return result, nil
+	}
+}
+
+// queryTrySuffix drops the error of a path component.
+func queryTrySuffix(suffix querySuffix) querySuffix {
+	return func(input, value any) ([]any, error) {
+		result, _ := suffix(input, value)
+		return result, nil
+	}
+}
+
+// queryArray collects the outputs of a query in a single list.
+func queryArray(query queryFunc) queryFunc {
+	return func(input any) ([]any, error) {
+		values, err := query(input)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			values = []any{}
+		}
+		return []any{values}, nil
+	}
+}
+
+// queryBoolean combines the truthiness of two queries with "and" or "or",
+// evaluating 'right' only when needed.
+func queryBoolean(left, right queryFunc, or bool) queryFunc {
+	return func(input any) ([]any, error) {
+		lefts, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		var result []any
+		for _, l := range lefts {
+			if queryTruthy(l) == or {
+				result = append(result, or)
+				continue
+			}
+			rights, err := right(input)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rights {
+				result = append(result, queryTruthy(r))
+			}
+		}
+		return result, nil
+	}
+}
+
+// querySelect produces the input once for each truthy output of 'cond'.
+func querySelect(cond queryFunc) queryFunc {
+	return func(input any) ([]any, error) {
+		values, err := cond(input)
+		if err != nil {
+			return nil, err
+		}
+		var result []any
+		for _, value := range values {
+			if queryTruthy(value) {
+				result = append(result, input)
+			}
+		}
+		return result, nil
+	}
+}
+
+// queryBounds evaluates the optional bounds of a slice as pairs.
+func queryBounds(from, to queryFunc) queryFunc {
+	optional := func(query queryFunc, input any) ([]any, error) {
+		if query == nil {
+			return []any{nil}, nil
+		}
+		return query(input)
+	}
+	return func(input any) ([]any, error) {
+		froms, err := optional(from, input)
+		if err != nil {
+			return nil, err
+		}
+		tos, err := optional(to, input)
+		if err != nil {
+			return nil, err
+		}
+		var result []any
+		for _, f := range froms {
+			for _, t := range tos {
+				result = append(result, [2]any{f, t})
+			}
+		}
+		return result, nil
+	}
+}
+
+// queryField returns a query producing the value of a key of an object.
+func queryField(name string) queryFunc {
+	suffix := queryFieldSuffix(name)
+	return func(input any) ([]any, error) {
+		return suffix(nil, input)
+	}
+}
+
+// queryFieldSuffix returns a path component producing the value of a key of
+// an object.
+func queryFieldSuffix(name string) querySuffix {
+	return func(_, value any) ([]any, error) {
+		value, err := queryIndex(value, name)
+		if err != nil {
+			return nil, err
+		}
+		return []any{value}, nil
+	}
+}
+
+// queryIndex returns the value of an object at a string key, or of a list at
+// a number index counting from the end when negative. Missing keys and out
+// of range indices produce null.
+func queryIndex(value any, key any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if name, ok := key.(string); ok {
+		if dict, ok := asDict(value); ok {
+			return dict[name], nil
+		}
+		return nil, fmt.Errorf("cannot index %s with %q", queryType(value), name)
+	}
+
+	list, isList := queryList(value)
+	index, isNumber := queryNumber(key)
+	if !isList || !isNumber {
+		return nil, fmt.Errorf("cannot index %s with %s", queryType(value), queryType(key))
+	}
+	i := int(math.Floor(index))
+	if i < 0 {
+		i += len(list)
+	}
+	if i < 0 || i >= len(list) {
+		return nil, nil
+	}
+	return list[i], nil
+}
+
+// querySlice returns the elements of a list, or the characters of a string,
+// from 'from' included to 'to' excluded. Null bounds are open.
+func querySlice(value, from, to any) (any, error) {
+	var length int
+	var runes []rune
+	list, isList := queryList(value)
+	switch {
+	case value == nil:
+		return nil, nil
+	case isList:
+		length = len(list)
+	default:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot slice %s", queryType(value))
+		}
+		runes = []rune(str)
+		length = len(runes)
+	}
+
+	bound := func(bound any, fallback int) (int, error) {
+		if bound == nil {
+			return fallback, nil
+		}
+		number, ok := queryNumber(bound)
+		if !ok {
+			return 0, fmt.Errorf("slice bounds must be numbers, not %s", queryType(bound))
+		}
+		i := int(math.Floor(number))
+		if i < 0 {
+			i += length
+		}
+		return max(0, min(i, length)), nil
+	}
+	start, err := bound(from, 0)
+	if err != nil {
+		return nil, err
+	}
+	end, err := bound(to, length)
+	if err != nil {
+		return nil, err
+	}
+	end = max(start, end)
+
+	if isList {
+		return append([]any{}, list[start:end]...), nil
+	}
+	return string(runes[start:end]), nil
+}
+
+// queryIterate produces the elements of a list, or the values of an object
+// in the order of their keys.
+func queryIterate(input any) ([]any, error) {
+	if list, ok := queryList(input); ok {
+		return list, nil
+	}
+	if dict, ok := asDict(input); ok {
+		result := make([]any, 0, len(dict))
+		for _, key := range sortedKeys(dict) {
+			result = append(result, dict[key])
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("cannot iterate over %s", queryType(input))
+}
+
+// queryRecurse produces the input followed by all the values nested in it,
+// depth first.
+func queryRecurse(input any) ([]any, error) {
+	result := []any{input}
+	if _, isString := input.(string); isString {
+		return result, nil
+	}
+	children, err := queryIterate(input)
+	if err != nil {
+		return result, nil
+	}
+	for _, child := range children {
+		nested, _ := queryRecurse(child)
+		result = append(result, nested...)
+	}
+	return result, nil
+}
+
+// queryLength returns the number of elements of a list or an object, the
+// number of characters of a string, or the absolute value of a number.
+func queryLength(input any) (any, error) {
+	if input == nil {
+		return 0, nil
+	}
+	if str, ok := input.(string); ok {
+		return utf8.RuneCountInString(str), nil
+	}
+	if number, ok := queryNumber(input); ok {
+		return math.Abs(number), nil
+	}
+	if list, ok := queryList(input); ok {
+		return len(list), nil
+	}
+	if dict, ok := asDict(input); ok {
+		return len(dict), nil
+	}
+	return nil, fmt.Errorf("%s has no length", queryType(input))
+}
+
+// queryKeys returns the sorted keys of an object, or the indices of a list.
+func queryKeys(input any) (any, error) {
+	if dict, ok := asDict(input); ok {
+		keys := sortedKeys(dict)
+		result := make([]any, len(keys))
+		for i, key := range keys {
+			result[i] = key
+		}
+		return result, nil
+	}
+	if list, ok := queryList(input); ok {
+		result := make([]any, len(list))
+		for i := range list {
+			result[i] = i
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("%s has no keys", queryType(input))
+}
+
+// queryHas reports whether an object has a key or a list has an index.
+func queryHas(input, key any) (any, error) {
+	if name, ok := key.(string); ok {
+		if dict, ok := asDict(input); ok {
+			_, exists := dict[name]
+			return exists, nil
+		}
+	}
+	if index, ok := queryNumber(key); ok {
+		if list, ok := queryList(input); ok {
+			return index >= 0 && index < float64(len(list)), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot check whether %s has a key of type %s", queryType(input), queryType(key))
+}
+
+// queryList converts lists of any element type to a list.
+func queryList(value any) ([]any, bool) {
+	if list, ok := value.([]any); ok {
+		return list, true
+	}
+	if _, isBytes := value.([]byte); isBytes || value == nil {
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// queryNumber converts numbers of any type to a float64.
+func queryNumber(value any) (float64, bool) {
+	switch rv := reflect.ValueOf(value); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// queryTruthy reports whether a value is neither null nor false.
+func queryTruthy(value any) bool {
+	if value == nil {
+		return false
+	}
+	if b, ok := value.(bool); ok {
+		return b
+	}
+	return true
+}
+
+// queryType returns the jq name of the type of a value.
+func queryType(value any) string {
+	if value == nil {
+		return "null"
+	}
+	if _, ok := value.(bool); ok {
+		return "boolean"
+	}
+	if _, ok := value.(string); ok {
+		return "string"
+	}
+	if _, ok := queryNumber(value); ok {
+		return "number"
+	}
+	if _, ok := queryList(value); ok {
+		return "array"
+	}
+	if _, ok := asDict(value); ok {
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// queryTypeOrder ranks types in the jq order: null, false, true, numbers,
+// strings, arrays and objects.
+var queryTypeOrder = map[string]int{"null": 0, "boolean": 1, "number": 2, "string": 3, "array": 4, "object": 5}
+
+// queryCompare compares two values in the jq order, returning -1, 0 or 1.
+// Numbers of any type are compared by value, lists element by element and
+// objects by their sorted keys, then by their values.
+func queryCompare(a, b any) int {
+	typeA, typeB := queryType(a), queryType(b)
+	if typeA != typeB {
+		rankA, knownA := queryTypeOrder[typeA]
+		rankB, knownB := queryTypeOrder[typeB]
+		if knownA && knownB {
+			return cmp.Compare(rankA, rankB)
+		}
+		return strings.Compare(typeA, typeB)
+	}
+
+	switch typeA {
+	case "null":
+		return 0
+	case "boolean":
+		x, y := a.(bool), b.(bool)
+		if x == y {
+			return 0
+		} else if y {
+			return -1
+		}
+		return 1
+	case "number":
+		x, _ := queryNumber(a)
+		y, _ := queryNumber(b)
+		return cmp.Compare(x, y)
+	case "string":
+		return strings.Compare(a.(string), b.(string))
+	case "array":
+		x, _ := queryList(a)
+		y, _ := queryList(b)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := queryCompare(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(len(x), len(y))
+	case "object":
+		x, _ := asDict(a)
+		y, _ := asDict(b)
+		keysX, keysY := sortedKeys(x), sortedKeys(y)
+		if c := queryCompare(stringsToAny(keysX), stringsToAny(keysY)); c != 0 {
+			return c
+		}
+		for _, key := range keysX {
+			if c := queryCompare(x[key], y[key]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func stringsToAny(strs []string) []any {
+	result := make([]any, len(strs))
+	for i, str := range strs {
+		result[i] = str
+	}
+	return result
+}
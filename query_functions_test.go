@@ -0,0 +1,129 @@
+package sprout
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const queryTestManifest = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"kind": "Service", "metadata": {"name": "frontend", "labels": {"tier": "web"}}, "spec": {"ports": [{"port": 80}, {"port": 443}]}},
+    {"kind": "Deployment", "metadata": {"name": "frontend"}, "spec": {"replicas": 3}},
+    {"kind": "Service", "metadata": {"name": "backend"}, "spec": {"ports": [{"port": 8080}]}}
+  ]
+}`
+
+func TestQuery(t *testing.T) {
+	data := map[string]any{"M": queryTestManifest}
+	tests := testCases{
+		{"TestIdentity", `{{ query "." 1 }}`, "[1]", nil},
+		{"TestField", `{{ .M | fromJson | query ".kind" }}`, "[List]", data},
+		{"TestNestedFields", `{{ .M | fromJson | query ".items[0].metadata.name" }}`, "[frontend]", data},
+		{"TestQuotedField", `{{ dict "a b" 1 | query ".\"a b\"" }}`, "[1]", nil},
+		{"TestBracketField", `{{ dict "a-b" 1 | query ".[\"a-b\"]" }}`, "[1]", nil},
+		{"TestMissingField", `{{ dict "a" 1 | query ".b.c" }}`, "[<nil>]", nil},
+		{"TestNegativeIndex", `{{ list 1 2 3 | query ".[-1]" }}`, "[3]", nil},
+		{"TestIndexOutOfRange", `{{ list 1 2 3 | query ".[5]" }}`, "[<nil>]", nil},
+		{"TestSlice", `{{ list 1 2 3 4 | query ".[1:3]" }}`, "[[2 3]]", nil},
+		{"TestOpenSlices", `{{ list 1 2 3 4 | query ".[:1], .[-2:]" }}`, "[[1] [3 4]]", nil},
+		{"TestStringSlice", `{{ "héllo" | query ".[1:3]" }}`, "[él]", nil},
+		{"TestIterate", `{{ .M | fromJson | query ".items[].kind" }}`, "[Service Deployment Service]", data},
+		{"TestIterateObject", `{{ dict "b" 2 "a" 1 | query ".[]" }}`, "[1 2]", nil},
+		{"TestSelect", `{{ .M | fromJson | query ".items[] | select(.kind == \"Service\") | .metadata.name" }}`, "[frontend backend]", data},
+		{"TestSelectNumber", `{{ .M | fromJson | query ".items[].spec.ports[]? | select(.port >= 443) | .port" }}`, "[443 8080]", data},
+		{"TestSelectAndOr", `{{ .M | fromJson | query ".items[] | select(.kind == \"Service\" and (.metadata.labels.tier == \"web\" or .spec.replicas > 1)) | .metadata.name" }}`, "[frontend]", data},
+		{"TestSelectNot", `{{ .M | fromJson | query ".items[] | select(.metadata | has(\"labels\") | not) | .kind" }}`, "[Deployment Service]", data},
+		{"TestRecursiveDescent", `{{ .M | fromJson | query "[.. | .port? // empty]" }}`, "[[80 443 8080]]", data},
+		{"TestRecursiveNames", `{{ .M | fromJson | query "[.. | select(type == \"object\" and has(\"name\")) | .name]" }}`, "[[frontend frontend backend]]", data},
+		{"TestArrayProjection", `{{ .M | fromJson | query "[.items[] | .metadata.name]" }}`, "[[frontend frontend backend]]", data},
+		{"TestObjectProjection", `{{ .M | fromJson | query ".items[] | select(.spec.replicas) | {name: .metadata.name, kind, \"count\": .spec.replicas}" }}`, "[map[count:3 kind:Deployment name:frontend]]", data},
+		{"TestObjectCartesian", `{{ dict "a" (list 1 2) | query "{x: .a[]}" }}`, "[map[x:1] map[x:2]]", nil},
+		{"TestMap", `{{ .M | fromJson | query ".items | map(.kind)" }}`, "[[Service Deployment Service]]", data},
+		{"TestAlternative", `{{ dict "a" nil "b" false | query ".a // .b // \"default\"" }}`, "[default]", nil},
+		{"TestLength", `{{ .M | fromJson | query ".items | length" }}`, "[3]", data},
+		{"TestKeys", `{{ dict "b" 1 "a" 2 | query "keys" }}`, "[[a b]]", nil},
+		{"TestComparisonOrder", `{{ query "null < false, false < true, true < 0, 1 < \"a\", [1] < {}, [1, 2] < [1, 3]" nil }}`, "[true true true true true true]", nil},
+		{"TestNumberTypes", `{{ .V | query ".a == 1, .b == 1.5" }}`, "[true true]", map[string]any{"V": map[string]any{"a": int64(1), "b": float32(1.5)}}},
+		{"TestTypedValues", `{{ .V | query ".a.b[1]" }}`, "[y]", map[string]any{"V": map[string]map[string][]string{"a": {"b": {"x", "y"}}}}},
+		{"TestYamlInput", `{{ .V | fromYaml | query ".servers[] | select(.port > 80) | .host" }}`, "[beta]", map[string]any{"V": "servers:\n  - host: alpha\n    port: 80\n  - host: beta\n    port: 8080\n"}},
+		{"TestEmptyResult", `{{ list | query ".[]" }}`, "[]", nil},
+		{"TestOptional", `{{ list 1 (dict "a" 2) | query ".[] | .a?" }}`, "[2]", nil},
+		{"TestInvalidExpression", `{{ query ".a[" (dict) }}`, "[]", nil},
+		{"TestRuntimeError", `{{ query ".a" 1 }}`, "[]", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestQueryFirst(t *testing.T) {
+	tests := testCases{
+		{"TestFirst", `{{ list 1 2 3 | queryFirst ".[]" }}`, "1", nil},
+		{"TestNoResult", `{{ list | queryFirst ".[]" }}`, "<no value>", nil},
+		{"TestYamlInput", `{{ (.V | fromYaml | queryFirst ".servers[0]").host }}`, "alpha", map[string]any{"V": "servers:\n  - host: alpha\n"}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustQuery(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ dict "a" (list 1 2 3) | mustQuery ".a[1:]" }}`, "[[2 3]]", nil}, ""},
+		{testCase{"TestUnterminatedBracket", `{{ mustQuery ".a[" (dict) }}`, "", nil}, `invalid query ".a[": unexpected end of query`},
+		{testCase{"TestUnexpectedToken", `{{ mustQuery ".a )" (dict) }}`, "", nil}, `invalid query ".a )": unexpected ) at offset 3`},
+		{testCase{"TestUnknownFunction", `{{ mustQuery ".a | sortBy(.b)" (dict) }}`, "", nil}, `invalid query ".a | sortBy(.b)": unknown function sortBy at offset 5`},
+		{testCase{"TestUnterminatedString", `{{ mustQuery "select(.a == \"b)" (dict) }}`, "", nil}, `unterminated string at offset 13`},
+		{testCase{"TestUnexpectedCharacter", `{{ mustQuery ".a + 1" (dict) }}`, "", nil}, `invalid query ".a + 1": unexpected character '+' at offset 3`},
+		{testCase{"TestMissingParenthesis", `{{ mustQuery "select(.a" (dict) }}`, "", nil}, `expected ")", unexpected end of query`},
+		{testCase{"TestIndexString", `{{ mustQuery ".a.b" (dict "a" "x") }}`, "", nil}, `query ".a.b": cannot index string with "b"`},
+		{testCase{"TestIndexObjectWithNumber", `{{ mustQuery ".[0]" (dict) }}`, "", nil}, `cannot index object with number`},
+		{testCase{"TestIterateNumber", `{{ mustQuery ".[]" 1 }}`, "", nil}, `cannot iterate over number`},
+		{testCase{"TestLengthBoolean", `{{ mustQuery "length" true }}`, "", nil}, `boolean has no length`},
+		{testCase{"TestObjectKey", `{{ mustQuery "{(1): 2}" nil }}`, "", nil}, `object keys must be strings, not number`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustQueryFirst(t *testing.T) {
+	tests := mustTestCases{
+		{testCase{"TestValid", `{{ dict "a" (dict "b" 1) | mustQueryFirst ".a.b" }}`, "1", nil}, ""},
+		{testCase{"TestError", `{{ mustQueryFirst ".a" (list) }}`, "", nil}, `cannot index array with "a"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestQueryCache(t *testing.T) {
+	handler := NewFunctionHandler()
+
+	first, err := handler.compileQuery(".a")
+	assert.NoError(t, err)
+	second, err := handler.compileQuery(".a")
+	assert.NoError(t, err)
+	assert.Len(t, handler.queries.queries, 1)
+	assert.Equal(t, reflectPointer(first), reflectPointer(second))
+
+	_, err = handler.compileQuery(".a[")
+	assert.Error(t, err)
+	assert.Len(t, handler.queries.queries, 1)
+
+	for i := 0; i < maxCachedQueries; i++ {
+		_, err = handler.compileQuery(".[" + strconv.Itoa(i) + "]")
+		assert.NoError(t, err)
+	}
+	assert.LessOrEqual(t, len(handler.queries.queries), maxCachedQueries)
+
+	// Handlers built without NewFunctionHandler compile queries without cache.
+	result, err := (&FunctionHandler{}).MustQuery(".a", map[string]any{"a": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1}, result)
+}
+
+func reflectPointer(fn queryFunc) uintptr {
+	return reflect.ValueOf(fn).Pointer()
+}
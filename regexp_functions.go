@@ -1,6 +1,10 @@
 package sprout
 
-import "regexp"
+import (
+	"path"
+	"regexp"
+	"strings"
+)
 
 // RegexFind returns the first match of the regex pattern in the string.
 //
@@ -140,6 +144,47 @@ func (fh *FunctionHandler) RegexQuoteMeta(s string) string {
 	return regexp.QuoteMeta(s)
 }
 
+// GlobMatch reports whether the string matches the shell-style glob pattern.
+// Patterns follow the path.Match syntax and additionally support `**` to
+// match any number of path segments and brace expansion such as `*.{yml,yaml}`.
+//
+// Parameters:
+//
+//	pattern string - the glob pattern to match against.
+//	s string - the string to check.
+//
+// Returns:
+//
+//	bool - true if the string matches the pattern, otherwise false.
+//
+// Example:
+//
+//	{{ globMatch "configs/**/*.{yml,yaml}" "configs/prod/app.yaml" }} // Output: true
+func (fh *FunctionHandler) GlobMatch(pattern string, s string) bool {
+	result, _ := fh.MustGlobMatch(pattern, s)
+	return result
+}
+
+// GlobFilter returns the elements of the list matching the glob pattern,
+// keeping their original order.
+//
+// Parameters:
+//
+//	pattern string - the glob pattern to match against.
+//	list any - the list of elements to filter, converted to strings.
+//
+// Returns:
+//
+//	[]string - the elements matching the pattern.
+//
+// Example:
+//
+//	{{ list "app.yaml" "app.json" "db.yml" | globFilter "*.{yml,yaml}" }} // Output: [app.yaml db.yml]
+func (fh *FunctionHandler) GlobFilter(pattern string, list any) []string {
+	result, _ := fh.MustGlobFilter(pattern, list)
+	return result
+}
+
 // MustRegexFind searches for the first match of a regex pattern in a string
 // and returns it, with error handling.
 //
@@ -282,3 +327,178 @@ func (fh *FunctionHandler) MustRegexReplaceAllLiteral(regex string, s string, re
 	}
 	return r.ReplaceAllLiteralString(s, repl), nil
 }
+
+// MustGlobMatch reports whether the string matches the glob pattern, with
+// error handling.
+//
+// Parameters:
+//
+//	pattern string - the glob pattern to match against.
+//	s string - the string to check.
+//
+// Returns:
+//
+//	bool - true if the string matches the pattern, otherwise false.
+//	error - path.ErrBadPattern if the pattern is malformed.
+//
+// Example:
+//
+//	{{ mustGlobMatch "*.{yml,yaml}" "values.yaml" }} // Output: true, nil
+func (fh *FunctionHandler) MustGlobMatch(pattern string, s string) (bool, error) {
+	patterns, err := compileGlob(pattern)
+	if err != nil {
+		return false, err
+	}
+	return matchGlob(patterns, s), nil
+}
+
+// MustGlobFilter returns the elements of the list matching the glob pattern,
+// with error handling.
+//
+// Parameters:
+//
+//	pattern string - the glob pattern to match against.
+//	list any - the list of elements to filter, converted to strings.
+//
+// Returns:
+//
+//	[]string - the elements matching the pattern.
+//	error - path.ErrBadPattern if the pattern is malformed.
+//
+// Example:
+//
+//	{{ list "a.go" "b.md" | mustGlobFilter "*.go" }} // Output: [a.go], nil
+func (fh *FunctionHandler) MustGlobFilter(pattern string, list any) ([]string, error) {
+	patterns, err := compileGlob(pattern)
+	if err != nil {
+		return []string{}, err
+	}
+
+	result := []string{}
+	for _, s := range fh.StrSlice(list) {
+		if matchGlob(patterns, s) {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+// compileGlob expands the braces of a glob pattern and splits every resulting
+// pattern into its path segments. Each segment is validated with path.Match so
+// malformed patterns are reported even if they would never be reached while
+// matching.
+func compileGlob(pattern string) ([][]string, error) {
+	expanded, err := expandGlobBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := make([][]string, 0, len(expanded))
+	for _, p := range expanded {
+		segments := strings.Split(p, "/")
+		for _, segment := range segments {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, err
+			}
+		}
+		patterns = append(patterns, segments)
+	}
+	return patterns, nil
+}
+
+// matchGlob reports whether s matches any of the compiled glob patterns.
+func matchGlob(patterns [][]string, s string) bool {
+	names := strings.Split(s, "/")
+	for _, segments := range patterns {
+		if matchGlobSegments(segments, names) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlobSegments matches path segments one by one, a `**` segment
+// consuming zero or more segments of the name.
+func matchGlobSegments(segments []string, names []string) bool {
+	for len(segments) > 0 {
+		if segments[0] == "**" {
+			// Collapse consecutive `**` segments, they are equivalent to one.
+			for len(segments) > 0 && segments[0] == "**" {
+				segments = segments[1:]
+			}
+			if len(segments) == 0 {
+				return true
+			}
+			for i := range names {
+				if matchGlobSegments(segments, names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(names) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(segments[0], names[0]); !ok {
+			return false
+		}
+		segments, names = segments[1:], names[1:]
+	}
+	return len(names) == 0
+}
+
+// expandGlobBraces expands every `{a,b}` alternative of the pattern into a
+// distinct pattern. Nested braces are supported and escaped braces, as well as
+// braces inside character classes, are kept literally.
+func expandGlobBraces(pattern string) ([]string, error) {
+	open := -1
+	depth := 0
+	inClass := false
+	var commas []int
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+		case c == '{':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case c == ',' && depth == 1:
+			commas = append(commas, i)
+		case c == '}':
+			if depth == 0 {
+				return nil, path.ErrBadPattern
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+
+			prefix, suffix := pattern[:open], pattern[i+1:]
+			bounds := append(append([]int{open}, commas...), i)
+			var result []string
+			for j := 0; j < len(bounds)-1; j++ {
+				alternatives, err := expandGlobBraces(prefix + pattern[bounds[j]+1:bounds[j+1]] + suffix)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, alternatives...)
+			}
+			return result, nil
+		}
+	}
+
+	if depth > 0 {
+		return nil, path.ErrBadPattern
+	}
+	return []string{pattern}, nil
+}
@@ -143,3 +143,55 @@ func TestMustRegexReplaceAllLiteral(t *testing.T) {
 
 	runMustTestCases(t, tests)
 }
+
+func TestGlobMatch(t *testing.T) {
+	var tests = testCases{
+		{"TestGlobMatchStar", `{{ globMatch "*.yaml" "values.yaml" }}`, "true", nil},
+		{"TestGlobMatchStarDoesNotCrossSeparator", `{{ globMatch "*.yaml" "charts/values.yaml" }}`, "false", nil},
+		{"TestGlobMatchQuestionMark", `{{ globMatch "web-?" "web-1" }}`, "true", nil},
+		{"TestGlobMatchCharacterClass", `{{ globMatch "web-[0-9]" "web-a" }}`, "false", nil},
+		{"TestGlobMatchNegatedClass", `{{ globMatch "web-[^0-9]" "web-a" }}`, "true", nil},
+		{"TestGlobMatchDoubleStar", `{{ globMatch "configs/**/*.yaml" "configs/prod/eu/app.yaml" }}`, "true", nil},
+		{"TestGlobMatchDoubleStarZeroSegments", `{{ globMatch "configs/**/*.yaml" "configs/app.yaml" }}`, "true", nil},
+		{"TestGlobMatchDoubleStarTrailing", `{{ globMatch "configs/**" "configs/prod/app.yaml" }}`, "true", nil},
+		{"TestGlobMatchBraces", `{{ globMatch "*.{yml,yaml}" "values.yml" }}`, "true", nil},
+		{"TestGlobMatchNestedBraces", `{{ globMatch "{api,web-{eu,us}}.example.com" "web-us.example.com" }}`, "true", nil},
+		{"TestGlobMatchEscapedBrace", `{{ globMatch "\\{a,b\\}" "{a,b}" }}`, "true", nil},
+		{"TestGlobMatchNoMatch", `{{ globMatch "*.{yml,yaml}" "values.json" }}`, "false", nil},
+		{"TestGlobMatchInvalidPattern", `{{ globMatch "[a-" "a" }}`, "false", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestGlobFilter(t *testing.T) {
+	var tests = testCases{
+		{"TestGlobFilter", `{{ list "app.yaml" "app.json" "db.yml" | globFilter "*.{yml,yaml}" }}`, "[app.yaml db.yml]", nil},
+		{"TestGlobFilterNoMatch", `{{ list "app.json" | globFilter "*.yaml" }}`, "[]", nil},
+		{"TestGlobFilterFromVariable", `{{ .V | globFilter "*.example.com" }}`, "[api.example.com]", map[string]any{"V": []string{"api.example.com", "example.org"}}},
+		{"TestGlobFilterInvalidPattern", `{{ list "a" | globFilter "{a" }}`, "[]", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustGlobMatch(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestMustGlobMatchValid", `{{ mustGlobMatch "**/*.go" "cmd/main.go" }}`, "true", nil}, ""},
+		{testCase{"TestMustGlobMatchInvalidClass", `{{ mustGlobMatch "[a-" "a" }}`, "", nil}, "syntax error in pattern"},
+		{testCase{"TestMustGlobMatchUnclosedBrace", `{{ mustGlobMatch "*.{yml" "a.yml" }}`, "", nil}, "syntax error in pattern"},
+		{testCase{"TestMustGlobMatchUnexpectedBrace", `{{ mustGlobMatch "*.yml}" "a.yml" }}`, "", nil}, "syntax error in pattern"},
+		{testCase{"TestMustGlobMatchUnreachedInvalidSegment", `{{ mustGlobMatch "b/[" "a/b" }}`, "", nil}, "syntax error in pattern"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustGlobFilter(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestMustGlobFilterValid", `{{ list "a.go" "b.md" | mustGlobFilter "*.go" }}`, "[a.go]", nil}, ""},
+		{testCase{"TestMustGlobFilterInvalidPattern", `{{ list "a.go" | mustGlobFilter "[" }}`, "", nil}, "syntax error in pattern"},
+	}
+
+	runMustTestCases(t, tests)
+}
@@ -47,7 +47,6 @@ import (
 	"hash/adler32"
 	"io"
 	"math/big"
-	mathrand "math/rand"
 	"net"
 	"net/url"
 	"reflect"
@@ -55,15 +54,51 @@ import (
 	"time"
 
 	sv2 "github.com/Masterminds/semver/v3"
+	"github.com/spf13/cast"
 	bcrypt_lib "golang.org/x/crypto/bcrypt"
 	"golang.org/x/crypto/scrypt"
 )
 
+// UrlParse splits a URL into a dict of its parts: "scheme", "host",
+// "hostname", "path", "query", "opaque", "fragment" and "userinfo". Invalid
+// URLs produce an empty dict.
+//
+// Parameters:
+//
+//	v string - the URL to parse.
+//
+// Returns:
+//
+//	map[string]any - the parts of the URL.
+//
+// Example:
+//
+//	{{ (urlParse "https://example.com/docs?page=2").path }} // Output: "/docs"
 func (fh *FunctionHandler) UrlParse(v string) map[string]any {
+	result, _ := fh.MustUrlParse(v)
+	return result
+}
+
+// MustUrlParse splits a URL into a dict of its parts, returning an error if
+// the URL is invalid.
+//
+// Parameters:
+//
+//	v string - the URL to parse.
+//
+// Returns:
+//
+//	map[string]any - the parts of the URL.
+//	error - error if the URL is malformed.
+//
+// Example:
+//
+//	{{ (mustUrlParse "https://example.com/docs?page=2").query }} // Output: "page=2", nil
+func (fh *FunctionHandler) MustUrlParse(v string) (map[string]any, error) {
 	dict := map[string]any{}
 	parsedURL, err := url.Parse(v)
 	if err != nil {
-		panic(fmt.Sprintf("unable to parse url: %s", err))
+		return dict, fmt.Errorf("unable to parse url: %w", err)
 	}
 	dict["scheme"] = parsedURL.Scheme
 	dict["host"] = parsedURL.Host
@@ -78,36 +113,92 @@ func (fh *FunctionHandler) UrlParse(v string) map[string]any {
 		dict["userinfo"] = ""
 	}
 
-	return dict
+	return dict, nil
 }
 
+// UrlJoin builds a URL from a dict of its parts, as returned by urlParse.
+// Missing parts are left empty and invalid parts produce an empty string.
+//
+// Parameters:
+//
+//	d map[string]any - the parts of the URL.
+//
+// Returns:
+//
+//	string - the URL.
+//
+// Example:
+//
+//	{{ urlJoin (dict "scheme" "https" "host" "example.com" "path" "/docs") }} // Output: "https://example.com/docs"
 func (fh *FunctionHandler) UrlJoin(d map[string]any) string {
-	resURL := url.URL{
-		Scheme:   fh.Get(d, "scheme").(string),
-		Host:     fh.Get(d, "host").(string),
-		Path:     fh.Get(d, "path").(string),
-		RawQuery: fh.Get(d, "query").(string),
-		Opaque:   fh.Get(d, "opaque").(string),
-		Fragment: fh.Get(d, "fragment").(string),
-	}
-	userinfo := fh.Get(d, "userinfo").(string)
-	var user *url.Userinfo
-	if userinfo != "" {
-		tempURL, err := url.Parse(fmt.Sprintf("proto://%s@host", userinfo))
+	result, _ := fh.MustUrlJoin(d)
+	return result
+}
+
+// MustUrlJoin builds a URL from a dict of its parts, returning an error if a
+// part cannot be used.
+//
+// Parameters:
+//
+//	d map[string]any - the parts of the URL.
+//
+// Returns:
+//
+//	string - the URL.
+//	error - error if a part is not a string or the userinfo is malformed.
+//
+// Example:
+//
+//	{{ mustUrlJoin (dict "scheme" "https" "host" "example.com" "query" "q=1") }} // Output: "https://example.com?q=1", nil
+func (fh *FunctionHandler) MustUrlJoin(d map[string]any) (string, error) {
+	parts := make(map[string]string)
+	for _, key := range []string{"scheme", "host", "path", "query", "opaque", "fragment", "userinfo"} {
+		value, err := cast.ToStringE(d[key])
 		if err != nil {
-			panic(fmt.Sprintf("unable to parse userinfo in dict: %s", err))
+			return "", fmt.Errorf("invalid url %s: %w", key, err)
 		}
-		user = tempURL.User
+		parts[key] = value
 	}
 
-	resURL.User = user
-	return resURL.String()
-}
-
+	resURL := url.URL{
+		Scheme:   parts["scheme"],
+		Host:     parts["host"],
+		Path:     parts["path"],
+		RawQuery: parts["query"],
+		Opaque:   parts["opaque"],
+		Fragment: parts["fragment"],
+	}
+	if parts["userinfo"] != "" {
+		tempURL, err := url.Parse(fmt.Sprintf("proto://%s@host", parts["userinfo"]))
+		if err != nil {
+			return "", fmt.Errorf("unable to parse userinfo in dict: %w", err)
+		}
+		resURL.User = tempURL.User
+	}
+
+	return resURL.String(), nil
+}
+
+// GetHostByName resolves a host name with the handler's resolver and returns
+// its first address. Names that cannot be resolved produce an empty string.
+//
+// Parameters:
+//
+//	name string - the host name to resolve.
+//
+// Returns:
+//
+//	string - the first address of the host.
+//
+// Example:
+//
+//	{{ getHostByName "localhost" }} // Output: "127.0.0.1"
 func (fh *FunctionHandler) GetHostByName(name string) string {
-	addrs, _ := net.LookupHost(name)
-	//TODO: add error handing when release v3 comes out
-	return addrs[mathrand.Intn(len(addrs))]
+	addrs := fh.LookupHost(name)
+	if len(addrs) == 0 {
+		return ""
+	}
+	return addrs[0]
 }
 
 func (fh *FunctionHandler) InList(haystack []any, needle any) bool {
@@ -1,8 +1,10 @@
 package sprout
 
 import (
+	"context"
 	"log/slog"
 	"text/template"
+	"time"
 )
 
 // ErrHandling defines the strategy for handling errors within FunctionHandler.
@@ -29,8 +31,23 @@ type FunctionHandler struct {
 	Logger      *slog.Logger
 	funcMap     template.FuncMap
 	funcsAlias  FunctionAliasMap
+	acronyms    map[string]string
+	caseStyles  map[string]CaseStyle
+	inflections inflectionRules
+	environment map[string]string
+	resolver    Resolver
+	offline     bool
+	context     context.Context
+	timeout     time.Duration
+	queries     *queryCache
+
+	maxDecompressedSize int64
 }
 
+// defaultMaxDecompressedSize is the number of bytes decompression functions
+// may produce unless WithMaxDecompressedSize is used.
+const defaultMaxDecompressedSize = 32 << 20
+
 // FunctionHandlerOption defines a type for functional options that configure
 // FunctionHandler.
 type FunctionHandlerOption func(*FunctionHandler)
@@ -43,6 +60,9 @@ func NewFunctionHandler(opts ...FunctionHandlerOption) *FunctionHandler {
 		Logger:      slog.New(&slog.TextHandler{}),
 		funcMap:     make(template.FuncMap),
 		funcsAlias:  make(FunctionAliasMap),
+		acronyms:    make(map[string]string),
+		caseStyles:  make(map[string]CaseStyle),
+		queries:     &queryCache{queries: make(map[string]queryFunc)},
 	}
 
 	for _, opt := range opts {
@@ -73,6 +93,31 @@ func WithErrorChannel(ec chan error) FunctionHandlerOption {
 	}
 }
 
+// WithContext sets the context used by functions performing I/O, such as DNS
+// lookups. Cancelling it aborts the operations in progress.
+func WithContext(ctx context.Context) FunctionHandlerOption {
+	return func(p *FunctionHandler) {
+		p.context = ctx
+	}
+}
+
+// WithTimeout limits the duration of each I/O operation performed by a
+// FunctionHandler, such as a DNS lookup. A zero duration disables the limit.
+func WithTimeout(d time.Duration) FunctionHandlerOption {
+	return func(p *FunctionHandler) {
+		p.timeout = d
+	}
+}
+
+// WithMaxDecompressedSize limits the number of bytes that decompression
+// functions may produce, protecting templates against decompression bombs. A
+// zero or negative size restores the default limit of 32 MiB.
+func WithMaxDecompressedSize(size int64) FunctionHandlerOption {
+	return func(p *FunctionHandler) {
+		p.maxDecompressedSize = size
+	}
+}
+
 // WithFunctionHandler updates a FunctionHandler with settings from another FunctionHandler.
 // This is useful for copying configurations between handlers.
 func WithFunctionHandler(new *FunctionHandler) FunctionHandlerOption {
@@ -81,6 +126,28 @@ func WithFunctionHandler(new *FunctionHandler) FunctionHandlerOption {
 	}
 }
 
+// operationContext returns the context of an I/O operation, derived from the
+// handler's context and bounded by its timeout.
+func (fh *FunctionHandler) operationContext() (context.Context, context.CancelFunc) {
+	ctx := fh.context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if fh.timeout > 0 {
+		return context.WithTimeout(ctx, fh.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// decompressionLimit returns the maximum number of bytes a decompression
+// function may produce.
+func (fh *FunctionHandler) decompressionLimit() int64 {
+	if fh.maxDecompressedSize > 0 {
+		return fh.maxDecompressedSize
+	}
+	return defaultMaxDecompressedSize
+}
+
 // FuncMap returns a template.FuncMap for use with text/template or html/template.
 // It provides backward compatibility with sprig.FuncMap and integrates
 // additional configured functions.
@@ -113,6 +180,19 @@ func FuncMap(opts ...FunctionHandlerOption) template.FuncMap {
 	fnHandler.funcMap["substr"] = fnHandler.Substring
 	fnHandler.funcMap["repeat"] = fnHandler.Repeat
 	fnHandler.funcMap["trunc"] = fnHandler.Trunc
+	fnHandler.funcMap["truncGraphemes"] = fnHandler.TruncGraphemes
+	fnHandler.funcMap["truncWidth"] = fnHandler.TruncWidth
+	fnHandler.funcMap["ellipsisGraphemes"] = fnHandler.EllipsisGraphemes
+	fnHandler.funcMap["ellipsisWidth"] = fnHandler.EllipsisWidth
+	fnHandler.funcMap["ellipsisBothGraphemes"] = fnHandler.EllipsisBothGraphemes
+	fnHandler.funcMap["ellipsisBothWidth"] = fnHandler.EllipsisBothWidth
+	fnHandler.funcMap["substrGraphemes"] = fnHandler.SubstringGraphemes
+	fnHandler.funcMap["substrWidth"] = fnHandler.SubstringWidth
+	fnHandler.funcMap["graphemeCount"] = fnHandler.GraphemeCount
+	fnHandler.funcMap["displayWidth"] = fnHandler.DisplayWidth
+	fnHandler.funcMap["padLeft"] = fnHandler.PadLeft
+	fnHandler.funcMap["padRight"] = fnHandler.PadRight
+	fnHandler.funcMap["center"] = fnHandler.Center
 	fnHandler.funcMap["trim"] = fnHandler.Trim
 	fnHandler.funcMap["trimAll"] = fnHandler.TrimAll
 	fnHandler.funcMap["trimPrefix"] = fnHandler.TrimPrefix
@@ -133,21 +213,68 @@ func FuncMap(opts ...FunctionHandlerOption) template.FuncMap {
 	fnHandler.funcMap["toDotCase"] = fnHandler.ToDotCase
 	fnHandler.funcMap["toPathCase"] = fnHandler.ToPathCase
 	fnHandler.funcMap["toConstantCase"] = fnHandler.ToConstantCase
+	fnHandler.funcMap["toCase"] = fnHandler.ToCase
+	fnHandler.funcMap["mustToCase"] = fnHandler.MustToCase
+	fnHandler.funcMap["slugify"] = fnHandler.Slugify
+	fnHandler.funcMap["slugifyWith"] = fnHandler.SlugifyWith
+	fnHandler.funcMap["mustSlugifyWith"] = fnHandler.MustSlugifyWith
 	fnHandler.funcMap["wrap"] = fnHandler.Wrap
 	fnHandler.funcMap["wrapWith"] = fnHandler.WrapWith
+	fnHandler.funcMap["wrapGraphemes"] = fnHandler.WrapGraphemes
+	fnHandler.funcMap["wrapWidth"] = fnHandler.WrapWidth
+	fnHandler.funcMap["wordWrapGraphemes"] = fnHandler.WordWrapGraphemes
+	fnHandler.funcMap["wordWrapWidth"] = fnHandler.WordWrapWidth
 	fnHandler.funcMap["contains"] = fnHandler.Contains
 	fnHandler.funcMap["hasPrefix"] = fnHandler.HasPrefix
 	fnHandler.funcMap["hasSuffix"] = fnHandler.HasSuffix
 	fnHandler.funcMap["quote"] = fnHandler.Quote
 	fnHandler.funcMap["squote"] = fnHandler.Squote
+	fnHandler.funcMap["shellQuote"] = fnHandler.ShellQuote
+	fnHandler.funcMap["powershellQuote"] = fnHandler.PowerShellQuote
+	fnHandler.funcMap["goQuote"] = fnHandler.GoQuote
+	fnHandler.funcMap["jsQuote"] = fnHandler.JsQuote
+	fnHandler.funcMap["sqlQuote"] = fnHandler.SqlQuote
+	fnHandler.funcMap["csvQuote"] = fnHandler.CsvQuote
+	fnHandler.funcMap["xmlEscape"] = fnHandler.XmlEscape
+	fnHandler.funcMap["htmlEscape"] = fnHandler.HtmlEscape
 	fnHandler.funcMap["cat"] = fnHandler.Cat
 	fnHandler.funcMap["indent"] = fnHandler.Indent
 	fnHandler.funcMap["nindent"] = fnHandler.Nindent
 	fnHandler.funcMap["replace"] = fnHandler.Replace
 	fnHandler.funcMap["plural"] = fnHandler.Plural
+	fnHandler.funcMap["pluralize"] = fnHandler.Pluralize
+	fnHandler.funcMap["singularize"] = fnHandler.Singularize
+	fnHandler.funcMap["pluralizeCount"] = fnHandler.PluralizeCount
+	fnHandler.funcMap["ordinalize"] = fnHandler.Ordinalize
+	fnHandler.funcMap["numberToWords"] = fnHandler.NumberToWords
+	fnHandler.funcMap["humanJoin"] = fnHandler.HumanJoin
+	fnHandler.funcMap["humanJoinWith"] = fnHandler.HumanJoinWith
+	fnHandler.funcMap["levenshteinDistance"] = fnHandler.LevenshteinDistance
+	fnHandler.funcMap["levenshteinSimilarity"] = fnHandler.LevenshteinSimilarity
+	fnHandler.funcMap["damerauLevenshteinDistance"] = fnHandler.DamerauLevenshteinDistance
+	fnHandler.funcMap["damerauLevenshteinSimilarity"] = fnHandler.DamerauLevenshteinSimilarity
+	fnHandler.funcMap["jaroWinklerDistance"] = fnHandler.JaroWinklerDistance
+	fnHandler.funcMap["jaroWinklerSimilarity"] = fnHandler.JaroWinklerSimilarity
+	fnHandler.funcMap["soundex"] = fnHandler.Soundex
+	fnHandler.funcMap["metaphone"] = fnHandler.Metaphone
+	fnHandler.funcMap["closestMatch"] = fnHandler.ClosestMatch
+	fnHandler.funcMap["unifiedDiff"] = fnHandler.UnifiedDiff
+	fnHandler.funcMap["unifiedDiffWith"] = fnHandler.UnifiedDiffWith
+	fnHandler.funcMap["mustUnifiedDiffWith"] = fnHandler.MustUnifiedDiffWith
+	fnHandler.funcMap["lineDiff"] = fnHandler.LineDiff
+	fnHandler.funcMap["ansiStyle"] = fnHandler.AnsiStyle
+	fnHandler.funcMap["ansiColor"] = fnHandler.AnsiColor
+	fnHandler.funcMap["ansiBgColor"] = fnHandler.AnsiBgColor
+	fnHandler.funcMap["mustAnsiStyle"] = fnHandler.MustAnsiStyle
+	fnHandler.funcMap["mustAnsiColor"] = fnHandler.MustAnsiColor
+	fnHandler.funcMap["mustAnsiBgColor"] = fnHandler.MustAnsiBgColor
+	fnHandler.funcMap["stripAnsi"] = fnHandler.StripAnsi
 	fnHandler.funcMap["sha1sum"] = fnHandler.Sha1sum
 	fnHandler.funcMap["sha256sum"] = fnHandler.Sha256sum
 	fnHandler.funcMap["adler32sum"] = fnHandler.Adler32sum
+	fnHandler.funcMap["hashValue"] = fnHandler.HashValue
+	fnHandler.funcMap["hashValueWith"] = fnHandler.HashValueWith
+	fnHandler.funcMap["mustHashValueWith"] = fnHandler.MustHashValueWith
 	fnHandler.funcMap["toString"] = fnHandler.ToString
 	fnHandler.funcMap["toInt"] = fnHandler.ToInt
 	fnHandler.funcMap["toInt64"] = fnHandler.ToInt64
@@ -180,6 +307,12 @@ func FuncMap(opts ...FunctionHandlerOption) template.FuncMap {
 	fnHandler.funcMap["ceil"] = fnHandler.Ceil
 	fnHandler.funcMap["floor"] = fnHandler.Floor
 	fnHandler.funcMap["round"] = fnHandler.Round
+	fnHandler.funcMap["formatBytes"] = fnHandler.FormatBytes
+	fnHandler.funcMap["formatBytesSI"] = fnHandler.FormatBytesSI
+	fnHandler.funcMap["parseBytes"] = fnHandler.ParseBytes
+	fnHandler.funcMap["mustParseBytes"] = fnHandler.MustParseBytes
+	fnHandler.funcMap["formatSI"] = fnHandler.FormatSI
+	fnHandler.funcMap["groupThousands"] = fnHandler.GroupThousands
 	fnHandler.funcMap["join"] = fnHandler.Join
 	fnHandler.funcMap["sortAlpha"] = fnHandler.SortAlpha
 	fnHandler.funcMap["default"] = fnHandler.Default
@@ -193,14 +326,52 @@ func FuncMap(opts ...FunctionHandlerOption) template.FuncMap {
 	fnHandler.funcMap["toJson"] = fnHandler.ToJson
 	fnHandler.funcMap["toPrettyJson"] = fnHandler.ToPrettyJson
 	fnHandler.funcMap["toRawJson"] = fnHandler.ToRawJson
+	fnHandler.funcMap["toCanonicalJson"] = fnHandler.ToCanonicalJson
 	fnHandler.funcMap["fromYaml"] = fnHandler.FromYAML
 	fnHandler.funcMap["toYaml"] = fnHandler.ToYAML
+	fnHandler.funcMap["fromToml"] = fnHandler.FromTOML
+	fnHandler.funcMap["toToml"] = fnHandler.ToTOML
+	fnHandler.funcMap["fromCsv"] = fnHandler.FromCsv
+	fnHandler.funcMap["fromTsv"] = fnHandler.FromTsv
+	fnHandler.funcMap["fromCsvWith"] = fnHandler.FromCsvWith
+	fnHandler.funcMap["toCsv"] = fnHandler.ToCsv
+	fnHandler.funcMap["toTsv"] = fnHandler.ToTsv
+	fnHandler.funcMap["toCsvWith"] = fnHandler.ToCsvWith
+	fnHandler.funcMap["fromXml"] = fnHandler.FromXML
+	fnHandler.funcMap["fromXmlWith"] = fnHandler.FromXMLWith
+	fnHandler.funcMap["toXml"] = fnHandler.ToXML
+	fnHandler.funcMap["toXmlWith"] = fnHandler.ToXMLWith
+	fnHandler.funcMap["fromDotenv"] = fnHandler.FromDotenv
+	fnHandler.funcMap["toDotenv"] = fnHandler.ToDotenv
+	fnHandler.funcMap["fromIni"] = fnHandler.FromINI
+	fnHandler.funcMap["toIni"] = fnHandler.ToINI
+	fnHandler.funcMap["fromProperties"] = fnHandler.FromProperties
+	fnHandler.funcMap["toProperties"] = fnHandler.ToProperties
 	fnHandler.funcMap["mustFromJson"] = fnHandler.MustFromJson
 	fnHandler.funcMap["mustToJson"] = fnHandler.MustToJson
 	fnHandler.funcMap["mustToPrettyJson"] = fnHandler.MustToPrettyJson
 	fnHandler.funcMap["mustToRawJson"] = fnHandler.MustToRawJson
+	fnHandler.funcMap["mustToCanonicalJson"] = fnHandler.MustToCanonicalJson
 	fnHandler.funcMap["mustFromYaml"] = fnHandler.MustFromYAML
 	fnHandler.funcMap["mustToYaml"] = fnHandler.MustToYAML
+	fnHandler.funcMap["mustFromToml"] = fnHandler.MustFromTOML
+	fnHandler.funcMap["mustToToml"] = fnHandler.MustToTOML
+	fnHandler.funcMap["mustFromCsv"] = fnHandler.MustFromCsv
+	fnHandler.funcMap["mustFromTsv"] = fnHandler.MustFromTsv
+	fnHandler.funcMap["mustFromCsvWith"] = fnHandler.MustFromCsvWith
+	fnHandler.funcMap["mustToCsv"] = fnHandler.MustToCsv
+	fnHandler.funcMap["mustToTsv"] = fnHandler.MustToTsv
+	fnHandler.funcMap["mustToCsvWith"] = fnHandler.MustToCsvWith
+	fnHandler.funcMap["mustFromXml"] = fnHandler.MustFromXML
+	fnHandler.funcMap["mustFromXmlWith"] = fnHandler.MustFromXMLWith
+	fnHandler.funcMap["mustToXml"] = fnHandler.MustToXML
+	fnHandler.funcMap["mustToXmlWith"] = fnHandler.MustToXMLWith
+	fnHandler.funcMap["mustFromDotenv"] = fnHandler.MustFromDotenv
+	fnHandler.funcMap["mustToDotenv"] = fnHandler.MustToDotenv
+	fnHandler.funcMap["mustFromIni"] = fnHandler.MustFromINI
+	fnHandler.funcMap["mustToIni"] = fnHandler.MustToINI
+	fnHandler.funcMap["mustFromProperties"] = fnHandler.MustFromProperties
+	fnHandler.funcMap["mustToProperties"] = fnHandler.MustToProperties
 	fnHandler.funcMap["ternary"] = fnHandler.Ternary
 	fnHandler.funcMap["deepCopy"] = fnHandler.DeepCopy
 	fnHandler.funcMap["mustDeepCopy"] = fnHandler.MustDeepCopy
@@ -223,6 +394,10 @@ func FuncMap(opts ...FunctionHandlerOption) template.FuncMap {
 	fnHandler.funcMap["regexSplit"] = fnHandler.RegexSplit
 	fnHandler.funcMap["mustRegexSplit"] = fnHandler.MustRegexSplit
 	fnHandler.funcMap["regexQuoteMeta"] = fnHandler.RegexQuoteMeta
+	fnHandler.funcMap["globMatch"] = fnHandler.GlobMatch
+	fnHandler.funcMap["mustGlobMatch"] = fnHandler.MustGlobMatch
+	fnHandler.funcMap["globFilter"] = fnHandler.GlobFilter
+	fnHandler.funcMap["mustGlobFilter"] = fnHandler.MustGlobFilter
 	fnHandler.funcMap["append"] = fnHandler.Append
 	fnHandler.funcMap["mustAppend"] = fnHandler.MustAppend
 	fnHandler.funcMap["prepend"] = fnHandler.Prepend
@@ -247,6 +422,10 @@ func FuncMap(opts ...FunctionHandlerOption) template.FuncMap {
 	fnHandler.funcMap["mustSlice"] = fnHandler.MustSlice
 	fnHandler.funcMap["concat"] = fnHandler.Concat
 	fnHandler.funcMap["dig"] = fnHandler.Dig
+	fnHandler.funcMap["query"] = fnHandler.Query
+	fnHandler.funcMap["queryFirst"] = fnHandler.QueryFirst
+	fnHandler.funcMap["mustQuery"] = fnHandler.MustQuery
+	fnHandler.funcMap["mustQueryFirst"] = fnHandler.MustQueryFirst
 	fnHandler.funcMap["chunk"] = fnHandler.Chunk
 	fnHandler.funcMap["mustChunk"] = fnHandler.MustChunk
 	fnHandler.funcMap["list"] = fnHandler.List
@@ -261,6 +440,16 @@ func FuncMap(opts ...FunctionHandlerOption) template.FuncMap {
 	fnHandler.funcMap["omit"] = fnHandler.Omit
 	fnHandler.funcMap["merge"] = fnHandler.Merge
 	fnHandler.funcMap["mergeOverwrite"] = fnHandler.MergeOverwrite
+	fnHandler.funcMap["jsonPatch"] = fnHandler.JsonPatch
+	fnHandler.funcMap["mustJsonPatch"] = fnHandler.MustJsonPatch
+	fnHandler.funcMap["jsonMergePatch"] = fnHandler.JsonMergePatch
+	fnHandler.funcMap["mustJsonMergePatch"] = fnHandler.MustJsonMergePatch
+	fnHandler.funcMap["jsonPatchDiff"] = fnHandler.JsonPatchDiff
+	fnHandler.funcMap["mustJsonPatchDiff"] = fnHandler.MustJsonPatchDiff
+	fnHandler.funcMap["jsonMergePatchDiff"] = fnHandler.JsonMergePatchDiff
+	fnHandler.funcMap["mustJsonMergePatchDiff"] = fnHandler.MustJsonMergePatchDiff
+	fnHandler.funcMap["validateSchema"] = fnHandler.ValidateSchema
+	fnHandler.funcMap["mustValidateSchema"] = fnHandler.MustValidateSchema
 	fnHandler.funcMap["mustMerge"] = fnHandler.MustMerge
 	fnHandler.funcMap["mustMergeOverwrite"] = fnHandler.MustMergeOverwrite
 	fnHandler.funcMap["values"] = fnHandler.Values
@@ -291,16 +480,124 @@ func FuncMap(opts ...FunctionHandlerOption) template.FuncMap {
 	fnHandler.funcMap["env"] = fnHandler.Env
 	fnHandler.funcMap["expandEnv"] = fnHandler.ExpandEnv
 	fnHandler.funcMap["getHostByName"] = fnHandler.GetHostByName
+	fnHandler.funcMap["lookupHost"] = fnHandler.LookupHost
+	fnHandler.funcMap["mustLookupHost"] = fnHandler.MustLookupHost
+	fnHandler.funcMap["lookupCname"] = fnHandler.LookupCname
+	fnHandler.funcMap["mustLookupCname"] = fnHandler.MustLookupCname
+	fnHandler.funcMap["lookupSrv"] = fnHandler.LookupSrv
+	fnHandler.funcMap["mustLookupSrv"] = fnHandler.MustLookupSrv
+	fnHandler.funcMap["lookupTxt"] = fnHandler.LookupTxt
+	fnHandler.funcMap["mustLookupTxt"] = fnHandler.MustLookupTxt
+	fnHandler.funcMap["lookupMx"] = fnHandler.LookupMx
+	fnHandler.funcMap["mustLookupMx"] = fnHandler.MustLookupMx
+	fnHandler.funcMap["isIPv4"] = fnHandler.IsIPv4
+	fnHandler.funcMap["isIPv6"] = fnHandler.IsIPv6
+	fnHandler.funcMap["ipNormalize"] = fnHandler.IpNormalize
+	fnHandler.funcMap["mustIpNormalize"] = fnHandler.MustIpNormalize
+	fnHandler.funcMap["cidrContains"] = fnHandler.CidrContains
+	fnHandler.funcMap["mustCidrContains"] = fnHandler.MustCidrContains
+	fnHandler.funcMap["cidrNetwork"] = fnHandler.CidrNetwork
+	fnHandler.funcMap["mustCidrNetwork"] = fnHandler.MustCidrNetwork
+	fnHandler.funcMap["cidrBroadcast"] = fnHandler.CidrBroadcast
+	fnHandler.funcMap["mustCidrBroadcast"] = fnHandler.MustCidrBroadcast
+	fnHandler.funcMap["cidrHost"] = fnHandler.CidrHost
+	fnHandler.funcMap["mustCidrHost"] = fnHandler.MustCidrHost
+	fnHandler.funcMap["cidrSubnet"] = fnHandler.CidrSubnet
+	fnHandler.funcMap["mustCidrSubnet"] = fnHandler.MustCidrSubnet
+	fnHandler.funcMap["cidrHosts"] = fnHandler.CidrHosts
+	fnHandler.funcMap["mustCidrHosts"] = fnHandler.MustCidrHosts
 	fnHandler.funcMap["uuidv4"] = fnHandler.Uuidv4
 	fnHandler.funcMap["semver"] = fnHandler.Semver
 	fnHandler.funcMap["semverCompare"] = fnHandler.SemverCompare
 	fnHandler.funcMap["fail"] = fnHandler.Fail
 	fnHandler.funcMap["urlParse"] = fnHandler.UrlParse
 	fnHandler.funcMap["urlJoin"] = fnHandler.UrlJoin
+	fnHandler.funcMap["mustUrlParse"] = fnHandler.MustUrlParse
+	fnHandler.funcMap["mustUrlJoin"] = fnHandler.MustUrlJoin
+	fnHandler.funcMap["urlQuery"] = fnHandler.UrlQuery
+	fnHandler.funcMap["mustUrlQuery"] = fnHandler.MustUrlQuery
+	fnHandler.funcMap["urlQueryGet"] = fnHandler.UrlQueryGet
+	fnHandler.funcMap["mustUrlQueryGet"] = fnHandler.MustUrlQueryGet
+	fnHandler.funcMap["urlQuerySet"] = fnHandler.UrlQuerySet
+	fnHandler.funcMap["mustUrlQuerySet"] = fnHandler.MustUrlQuerySet
+	fnHandler.funcMap["urlQueryAdd"] = fnHandler.UrlQueryAdd
+	fnHandler.funcMap["mustUrlQueryAdd"] = fnHandler.MustUrlQueryAdd
+	fnHandler.funcMap["urlQueryDel"] = fnHandler.UrlQueryDel
+	fnHandler.funcMap["mustUrlQueryDel"] = fnHandler.MustUrlQueryDel
+	fnHandler.funcMap["urlJoinPath"] = fnHandler.UrlJoinPath
+	fnHandler.funcMap["mustUrlJoinPath"] = fnHandler.MustUrlJoinPath
+	fnHandler.funcMap["urlResolve"] = fnHandler.UrlResolve
+	fnHandler.funcMap["mustUrlResolve"] = fnHandler.MustUrlResolve
+	fnHandler.funcMap["urlPathEscape"] = fnHandler.UrlPathEscape
+	fnHandler.funcMap["urlPathUnescape"] = fnHandler.UrlPathUnescape
+	fnHandler.funcMap["mustUrlPathUnescape"] = fnHandler.MustUrlPathUnescape
+	fnHandler.funcMap["urlQueryEscape"] = fnHandler.UrlQueryEscape
+	fnHandler.funcMap["urlQueryUnescape"] = fnHandler.UrlQueryUnescape
+	fnHandler.funcMap["mustUrlQueryUnescape"] = fnHandler.MustUrlQueryUnescape
 	fnHandler.funcMap["base64Encode"] = fnHandler.Base64Encode
 	fnHandler.funcMap["base64Decode"] = fnHandler.Base64Decode
 	fnHandler.funcMap["base32Encode"] = fnHandler.Base32Encode
 	fnHandler.funcMap["base32Decode"] = fnHandler.Base32Decode
+	fnHandler.funcMap["mustBase64Decode"] = fnHandler.MustBase64Decode
+	fnHandler.funcMap["mustBase32Decode"] = fnHandler.MustBase32Decode
+	fnHandler.funcMap["base64RawEncode"] = fnHandler.Base64RawEncode
+	fnHandler.funcMap["base64RawDecode"] = fnHandler.Base64RawDecode
+	fnHandler.funcMap["mustBase64RawDecode"] = fnHandler.MustBase64RawDecode
+	fnHandler.funcMap["base64UrlEncode"] = fnHandler.Base64UrlEncode
+	fnHandler.funcMap["base64UrlDecode"] = fnHandler.Base64UrlDecode
+	fnHandler.funcMap["mustBase64UrlDecode"] = fnHandler.MustBase64UrlDecode
+	fnHandler.funcMap["base64RawUrlEncode"] = fnHandler.Base64RawUrlEncode
+	fnHandler.funcMap["base64RawUrlDecode"] = fnHandler.Base64RawUrlDecode
+	fnHandler.funcMap["mustBase64RawUrlDecode"] = fnHandler.MustBase64RawUrlDecode
+	fnHandler.funcMap["hexEncode"] = fnHandler.HexEncode
+	fnHandler.funcMap["hexDecode"] = fnHandler.HexDecode
+	fnHandler.funcMap["mustHexDecode"] = fnHandler.MustHexDecode
+	fnHandler.funcMap["base58Encode"] = fnHandler.Base58Encode
+	fnHandler.funcMap["base58Decode"] = fnHandler.Base58Decode
+	fnHandler.funcMap["mustBase58Decode"] = fnHandler.MustBase58Decode
+	fnHandler.funcMap["ascii85Encode"] = fnHandler.Ascii85Encode
+	fnHandler.funcMap["ascii85Decode"] = fnHandler.Ascii85Decode
+	fnHandler.funcMap["mustAscii85Decode"] = fnHandler.MustAscii85Decode
+	fnHandler.funcMap["percentEncode"] = fnHandler.PercentEncode
+	fnHandler.funcMap["percentDecode"] = fnHandler.UrlPathUnescape
+	fnHandler.funcMap["mustPercentDecode"] = fnHandler.MustUrlPathUnescape
+	fnHandler.funcMap["gzipCompress"] = fnHandler.GzipCompress
+	fnHandler.funcMap["gzipCompressWith"] = fnHandler.GzipCompressWith
+	fnHandler.funcMap["mustGzipCompressWith"] = fnHandler.MustGzipCompressWith
+	fnHandler.funcMap["gzipDecompress"] = fnHandler.GzipDecompress
+	fnHandler.funcMap["mustGzipDecompress"] = fnHandler.MustGzipDecompress
+	fnHandler.funcMap["zlibCompress"] = fnHandler.ZlibCompress
+	fnHandler.funcMap["zlibCompressWith"] = fnHandler.ZlibCompressWith
+	fnHandler.funcMap["mustZlibCompressWith"] = fnHandler.MustZlibCompressWith
+	fnHandler.funcMap["zlibDecompress"] = fnHandler.ZlibDecompress
+	fnHandler.funcMap["mustZlibDecompress"] = fnHandler.MustZlibDecompress
+	fnHandler.funcMap["parseQuantity"] = fnHandler.ParseQuantity
+	fnHandler.funcMap["mustParseQuantity"] = fnHandler.MustParseQuantity
+	fnHandler.funcMap["formatQuantity"] = fnHandler.FormatQuantity
+	fnHandler.funcMap["mustFormatQuantity"] = fnHandler.MustFormatQuantity
+	fnHandler.funcMap["addQuantity"] = fnHandler.AddQuantity
+	fnHandler.funcMap["mustAddQuantity"] = fnHandler.MustAddQuantity
+	fnHandler.funcMap["subQuantity"] = fnHandler.SubQuantity
+	fnHandler.funcMap["mustSubQuantity"] = fnHandler.MustSubQuantity
+	fnHandler.funcMap["mulQuantity"] = fnHandler.MulQuantity
+	fnHandler.funcMap["mustMulQuantity"] = fnHandler.MustMulQuantity
+	fnHandler.funcMap["cmpQuantity"] = fnHandler.CmpQuantity
+	fnHandler.funcMap["mustCmpQuantity"] = fnHandler.MustCmpQuantity
+	fnHandler.funcMap["dns1123Label"] = fnHandler.Dns1123Label
+	fnHandler.funcMap["mustDns1123Label"] = fnHandler.MustDns1123Label
+	fnHandler.funcMap["dns1123Subdomain"] = fnHandler.Dns1123Subdomain
+	fnHandler.funcMap["mustDns1123Subdomain"] = fnHandler.MustDns1123Subdomain
+	fnHandler.funcMap["truncWithHash"] = fnHandler.TruncWithHash
+	fnHandler.funcMap["imageParse"] = fnHandler.ImageParse
+	fnHandler.funcMap["mustImageParse"] = fnHandler.MustImageParse
+	fnHandler.funcMap["imageNormalize"] = fnHandler.ImageNormalize
+	fnHandler.funcMap["mustImageNormalize"] = fnHandler.MustImageNormalize
+	fnHandler.funcMap["imageBuild"] = fnHandler.ImageBuild
+	fnHandler.funcMap["mustImageBuild"] = fnHandler.MustImageBuild
+	fnHandler.funcMap["imageWithTag"] = fnHandler.ImageWithTag
+	fnHandler.funcMap["mustImageWithTag"] = fnHandler.MustImageWithTag
+	fnHandler.funcMap["imageWithRegistry"] = fnHandler.ImageWithRegistry
+	fnHandler.funcMap["mustImageWithRegistry"] = fnHandler.MustImageWithRegistry
 
 	// Register aliases for functions
 	fnHandler.registerAliases()
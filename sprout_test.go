@@ -1,8 +1,10 @@
 package sprout
 
 import (
+	"context"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -60,6 +62,42 @@ func TestWithErrorChannel(t *testing.T) {
 	assert.Equal(t, errChan, handler.errChan)
 }
 
+func TestWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	option := WithContext(ctx)
+
+	handler := NewFunctionHandler()
+	option(handler) // Apply the option
+
+	assert.Equal(t, ctx, handler.context)
+}
+
+func TestWithTimeout(t *testing.T) {
+	option := WithTimeout(2 * time.Second)
+
+	handler := NewFunctionHandler()
+	option(handler) // Apply the option
+
+	assert.Equal(t, 2*time.Second, handler.timeout)
+
+	ctx, cancel := handler.operationContext()
+	defer cancel()
+	_, hasDeadline := ctx.Deadline()
+	assert.True(t, hasDeadline)
+}
+
+func TestWithMaxDecompressedSize(t *testing.T) {
+	handler := NewFunctionHandler()
+	assert.Equal(t, int64(defaultMaxDecompressedSize), handler.decompressionLimit())
+
+	option := WithMaxDecompressedSize(1024)
+	option(handler) // Apply the option
+
+	assert.Equal(t, int64(1024), handler.maxDecompressedSize)
+	assert.Equal(t, int64(1024), handler.decompressionLimit())
+}
+
 func TestWithParser(t *testing.T) {
 	fnHandler := &FunctionHandler{
 		ErrHandling: ErrHandlingErrorChannel,
@@ -1,14 +1,25 @@
 package sprout
 
 import (
+	"encoding/xml"
 	"fmt"
+	"html"
+	"math"
 	mathrand "math/rand"
+	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
 	"unicode"
 	"unicode/utf8"
 
+	"github.com/spf13/cast"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
 )
 
 // caseStyle defines the rules for transforming strings based on capitalization,
@@ -302,7 +313,8 @@ func (fh *FunctionHandler) Join(sep string, v any) string {
 }
 
 // Trunc truncates 's' to a maximum length 'count'. If 'count' is negative, it removes
-// '-count' characters from the beginning of the string.
+// '-count' characters from the beginning of the string. ANSI escape sequences
+// are not counted and never cut.
 //
 // Parameters:
 //
@@ -319,6 +331,10 @@ func (fh *FunctionHandler) Join(sep string, v any) string {
 //	{{ "Hello World" | trunc 5 }} // Output: "Hello"
 //	{{ "Hello World" | trunc -1 }} // Output: "World"
 func (fh *FunctionHandler) Trunc(count int, str string) string {
+	if strings.Contains(str, "\x1b") {
+		return truncAnsi(count, str)
+	}
+
 	length := len(str)
 
 	if count < 0 && length+count > 0 {
@@ -354,7 +370,8 @@ func (fh *FunctionHandler) Shuffle(str string) string {
 }
 
 // ellipsis truncates 'str' from both ends, preserving the middle part of
-// the string and appending ellipses to both ends if needed.
+// the string and appending ellipses to both ends if needed. ANSI escape
+// sequences are not counted and never cut.
 //
 // Parameters:
 //
@@ -366,6 +383,10 @@ func (fh *FunctionHandler) Shuffle(str string) string {
 //
 //	string - the possibly truncated string with an ellipsis.
 func (fh *FunctionHandler) ellipsis(str string, offset int, maxWidth int) string {
+	if strings.Contains(str, "\x1b") {
+		return ellipsisClusters(offset, maxWidth, ansiRunes(str), clusterCount)
+	}
+
 	ellipsis := "..."
 	// Return the original string if maxWidth is less than 4, or the offset
 	// create exclusive dot string,  it's not possible to add an ellipsis.
@@ -576,7 +597,7 @@ func (fh *FunctionHandler) WordWrap(wrapLength int, newLineCharacter string, wra
 	var currentLineLength int
 
 	for _, word := range strings.Fields(str) {
-		wordLength := utf8.RuneCountInString(word)
+		wordLength := utf8.RuneCountInString(fh.StripAnsi(word))
 
 		// If the word is too long and should be wrapped, or it fits in the remaining line length
 		if currentLineLength > 0 && (currentLineLength+1+wordLength > wrapLength && !wrapLongWords || currentLineLength+1+wordLength > wrapLength) {
@@ -585,11 +606,22 @@ func (fh *FunctionHandler) WordWrap(wrapLength int, newLineCharacter string, wra
 		}
 
 		if wrapLongWords && wordLength > wrapLength {
-			for i, r := range word {
+			written := 0
+			for i := 0; i < len(word); {
+				// Escape sequences are written whole and do not use any space
+				if length := ansiSequenceLength(word[i:]); length > 0 {
+					resultBuilder.WriteString(word[i : i+length])
+					i += length
+					continue
+				}
+
+				r, size := utf8.DecodeRuneInString(word[i:])
 				resultBuilder.WriteRune(r)
 				currentLineLength++
+				written++
+				i += size
 				// Avoid adding a new line immediately after wrapping a long word
-				if i < len(word)-1 && currentLineLength == wrapLength {
+				if written < wordLength && currentLineLength == wrapLength {
 					resultBuilder.WriteString(newLineCharacter)
 					currentLineLength = 0
 				}
@@ -669,6 +701,205 @@ func (fh *FunctionHandler) Squote(elements ...any) string {
 	return builder.String()
 }
 
+// ShellQuote quotes each element in 'elements' for a POSIX shell and
+// separates them with spaces. Elements made only of characters that are never
+// special to the shell are left as is, others are wrapped in single quotes.
+//
+// Parameters:
+//
+//	elements ...any - the elements to quote.
+//
+// Returns:
+//
+//	string - the quoted elements, safe to use as shell words.
+//
+// Example:
+//
+//	{{ shellQuote "echo" "it's $HOME" }} // Output: echo 'it'"'"'s $HOME'
+func (fh *FunctionHandler) ShellQuote(elements ...any) string {
+	return quoteElements(elements, " ", func(str string) string {
+		if str != "" && strings.IndexFunc(str, isShellUnsafe) == -1 {
+			return str
+		}
+		return "'" + strings.ReplaceAll(str, "'", `'"'"'`) + "'"
+	})
+}
+
+// PowerShellQuote wraps each element in 'elements' in PowerShell single
+// quotes and separates them with spaces. Single quotes, including the
+// typographic ones PowerShell also accepts, are doubled.
+//
+// Parameters:
+//
+//	elements ...any - the elements to quote.
+//
+// Returns:
+//
+//	string - the quoted elements, safe to use as PowerShell arguments.
+//
+// Example:
+//
+//	{{ powershellQuote "it's $env:PATH" }} // Output: 'it''s $env:PATH'
+func (fh *FunctionHandler) PowerShellQuote(elements ...any) string {
+	return quoteElements(elements, " ", func(str string) string {
+		var builder strings.Builder
+		builder.WriteRune('\'')
+		for _, r := range str {
+			switch r {
+			case '\'', '‘', '’', '‚', '‛':
+				builder.WriteRune(r)
+			}
+			builder.WriteRune(r)
+		}
+		builder.WriteRune('\'')
+		return builder.String()
+	})
+}
+
+// GoQuote converts each element in 'elements' into a Go interpreted string
+// literal and separates them with spaces.
+//
+// Parameters:
+//
+//	elements ...any - the elements to quote.
+//
+// Returns:
+//
+//	string - the Go string literals.
+//
+// Example:
+//
+//	{{ goQuote "tab\there" }} // Output: "tab\there"
+func (fh *FunctionHandler) GoQuote(elements ...any) string {
+	return quoteElements(elements, " ", strconv.Quote)
+}
+
+// JsQuote converts each element in 'elements' into a double quoted
+// JavaScript string literal and separates them with spaces. HTML special
+// characters are escaped too, so the literal can be embedded in a script
+// element.
+//
+// Parameters:
+//
+//	elements ...any - the elements to quote.
+//
+// Returns:
+//
+//	string - the JavaScript string literals.
+//
+// Example:
+//
+//	{{ jsQuote "</script>" }} // Output: "\u003C/script\u003E"
+func (fh *FunctionHandler) JsQuote(elements ...any) string {
+	return quoteElements(elements, " ", func(str string) string {
+		return `"` + template.JSEscapeString(str) + `"`
+	})
+}
+
+// SqlQuote converts each element in 'elements' into a standard SQL string
+// literal, doubling single quotes, and separates them with spaces.
+// Backslashes are kept as is, as they are not special in standard SQL.
+//
+// Parameters:
+//
+//	elements ...any - the elements to quote.
+//
+// Returns:
+//
+//	string - the SQL string literals.
+//
+// Example:
+//
+//	{{ sqlQuote "O'Brien" }} // Output: 'O''Brien'
+func (fh *FunctionHandler) SqlQuote(elements ...any) string {
+	return quoteElements(elements, " ", func(str string) string {
+		return "'" + strings.ReplaceAll(str, "'", "''") + "'"
+	})
+}
+
+// CsvQuote converts each element in 'elements' into a CSV field, following
+// RFC 4180, and separates them with commas to form a record. Fields are only
+// quoted when they contain a comma, a double quote, a line break or start
+// with a space.
+//
+// Parameters:
+//
+//	elements ...any - the elements to quote.
+//
+// Returns:
+//
+//	string - the CSV record, without a trailing line break.
+//
+// Example:
+//
+//	{{ csvQuote "name" "Doe, John" `say "hi"` }} // Output: name,"Doe, John","say ""hi"""
+func (fh *FunctionHandler) CsvQuote(elements ...any) string {
+	return quoteElements(elements, ",", func(str string) string {
+		if str == "" || !strings.ContainsAny(str, ",\"\r\n") && !strings.HasPrefix(str, " ") {
+			return str
+		}
+		return `"` + strings.ReplaceAll(str, `"`, `""`) + `"`
+	})
+}
+
+// XmlEscape escapes the XML special characters of each element in 'elements'
+// with entities and separates them with spaces.
+//
+// Parameters:
+//
+//	elements ...any - the elements to escape.
+//
+// Returns:
+//
+//	string - the escaped elements, safe to use as XML text or attribute value.
+//
+// Example:
+//
+//	{{ xmlEscape "Fish & Chips <\"fresh\">" }} // Output: Fish &amp; Chips &lt;&#34;fresh&#34;&gt;
+func (fh *FunctionHandler) XmlEscape(elements ...any) string {
+	return quoteElements(elements, " ", func(str string) string {
+		var builder strings.Builder
+		_ = xml.EscapeText(&builder, []byte(str))
+		return builder.String()
+	})
+}
+
+// HtmlEscape escapes the HTML special characters of each element in
+// 'elements' with entities and separates them with spaces.
+//
+// Parameters:
+//
+//	elements ...any - the elements to escape.
+//
+// Returns:
+//
+//	string - the escaped elements, safe to use as HTML text or attribute value.
+//
+// Example:
+//
+//	{{ htmlEscape "<b>Tom & Jerry's</b>" }} // Output: &lt;b&gt;Tom &amp; Jerry&#39;s&lt;/b&gt;
+func (fh *FunctionHandler) HtmlEscape(elements ...any) string {
+	return quoteElements(elements, " ", html.EscapeString)
+}
+
+// quoteElements applies 'quote' to the string form of each non-nil element
+// and joins the results with 'separator'.
+func quoteElements(elements []any, separator string, quote func(string) string) string {
+	quoted := make([]string, 0, len(elements))
+	for _, elem := range elements {
+		if elem == nil {
+			continue
+		}
+		quoted = append(quoted, quote(fmt.Sprint(elem)))
+	}
+	return strings.Join(quoted, separator)
+}
+
+// isShellUnsafe reports whether 'r' may need quoting in a POSIX shell word.
+func isShellUnsafe(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./_-", r))
+}
+
 // transformString modifies the string 'str' based on various case styling rules
 // specified in the 'style' parameter. It can capitalize, lowercase, and insert
 // separators according to the rules provided.
@@ -894,6 +1125,375 @@ func (fh *FunctionHandler) ToTitleCase(str string) string {
 	return cases.Title(language.English).String(str)
 }
 
+// WordCase defines the casing applied to a word by the case conversion engine.
+type WordCase int
+
+const (
+	// WordCasePreserve keeps the word as it appears in the input (default).
+	WordCasePreserve WordCase = iota
+	// WordCaseLower converts the word to lowercase.
+	WordCaseLower
+	// WordCaseUpper converts the word to uppercase.
+	WordCaseUpper
+	// WordCaseTitle capitalizes the first letter of the word and lowercases the
+	// rest. Known acronyms are written in their registered form instead.
+	WordCaseTitle
+)
+
+// NumberBoundary defines how digits delimit words when a string is split by
+// the case conversion engine.
+type NumberBoundary int
+
+const (
+	// NumberBoundaryNone keeps digits attached to the surrounding letters, only
+	// an uppercase letter following a digit starts a new word (default).
+	// Example: "v2Api" is split into "v2" and "Api".
+	NumberBoundaryNone NumberBoundary = iota
+	// NumberBoundaryBefore starts a new word at the first digit following a
+	// letter. Example: "http2xx" is split into "http" and "2xx".
+	NumberBoundaryBefore
+	// NumberBoundaryBoth starts a new word at every transition between letters
+	// and digits. Example: "v2Api" is split into "v", "2" and "Api".
+	NumberBoundaryBoth
+)
+
+// CaseStyle defines how the case conversion engine splits a string into words
+// and joins them back together.
+//
+// Words are delimited by any rune that is neither a letter nor a digit, by
+// case transitions ("httpServer", "HTTPServer") and by digits according to
+// the NumberBoundary rule. Acronyms registered with WithAcronyms are kept
+// intact while splitting and written in their registered form when a word is
+// title cased.
+//
+// Example:
+//
+//	style := CaseStyle{Separator: "_", FirstWord: WordCaseLower, OtherWords: WordCaseLower}
+//	fh.ConvertCase(style, "HTTPServerID") // Output: "http_server_id"
+type CaseStyle struct {
+	Separator      string         // String inserted between words.
+	FirstWord      WordCase       // Casing applied to the first word.
+	OtherWords     WordCase       // Casing applied to every following word.
+	NumberBoundary NumberBoundary // How digits delimit words.
+}
+
+var caseStylePresets = map[string]CaseStyle{
+	"camel":    {FirstWord: WordCaseLower, OtherWords: WordCaseTitle},
+	"pascal":   {FirstWord: WordCaseTitle, OtherWords: WordCaseTitle},
+	"snake":    {Separator: "_", FirstWord: WordCaseLower, OtherWords: WordCaseLower},
+	"kebab":    {Separator: "-", FirstWord: WordCaseLower, OtherWords: WordCaseLower},
+	"constant": {Separator: "_", FirstWord: WordCaseUpper, OtherWords: WordCaseUpper},
+	"dot":      {Separator: ".", FirstWord: WordCaseLower, OtherWords: WordCaseLower},
+	"path":     {Separator: "/", FirstWord: WordCaseLower, OtherWords: WordCaseLower},
+	"title":    {Separator: " ", FirstWord: WordCaseTitle, OtherWords: WordCaseTitle},
+	"train":    {Separator: "-", FirstWord: WordCaseTitle, OtherWords: WordCaseTitle},
+}
+
+// WithAcronyms registers acronyms recognized by the case conversion engine.
+// Acronyms are matched case-insensitively and written in the given form when
+// a word is title cased, e.g. "ID", "URL" or "GraphQL".
+//
+// Example:
+//
+//	handler := NewFunctionHandler(WithAcronyms("ID", "HTTP", "GraphQL"))
+func WithAcronyms(acronyms ...string) FunctionHandlerOption {
+	return func(p *FunctionHandler) {
+		if p.acronyms == nil {
+			p.acronyms = make(map[string]string, len(acronyms))
+		}
+
+		for _, acronym := range acronyms {
+			if acronym != "" {
+				p.acronyms[strings.ToUpper(acronym)] = acronym
+			}
+		}
+	}
+}
+
+// WithCaseStyle registers a named case style usable with the toCase function.
+// A style registered with the name of a built-in style overrides it.
+//
+// Example:
+//
+//	handler := NewFunctionHandler(WithCaseStyle("env", CaseStyle{
+//	    Separator: "__", FirstWord: WordCaseUpper, OtherWords: WordCaseUpper,
+//	}))
+func WithCaseStyle(name string, style CaseStyle) FunctionHandlerOption {
+	return func(p *FunctionHandler) {
+		if p.caseStyles == nil {
+			p.caseStyles = make(map[string]CaseStyle)
+		}
+
+		p.caseStyles[name] = style
+	}
+}
+
+// ConvertCase splits 'str' into words and joins them back following 'style'.
+// Unlike the toCamelCase family, kept as is for backward compatibility, it
+// handles acronyms registered on the handler and configurable digit rules.
+//
+// Parameters:
+//
+//	style CaseStyle - the style describing how to split and join the words.
+//	str string - the string to convert.
+//
+// Returns:
+//
+//	string - the converted string.
+//
+// Example:
+//
+//	fh.ConvertCase(CaseStyle{FirstWord: WordCaseLower, OtherWords: WordCaseTitle}, "HTTPServerID")
+//	// Output: "httpServerId", or "httpServerID" with the ID acronym registered
+func (fh *FunctionHandler) ConvertCase(style CaseStyle, str string) string {
+	var result strings.Builder
+	result.Grow(len(str) + 10) // Allocate a bit more for potential separators
+
+	for i, word := range fh.splitWords(str, style.NumberBoundary) {
+		wordCase := style.OtherWords
+		if i == 0 {
+			wordCase = style.FirstWord
+		} else {
+			result.WriteString(style.Separator)
+		}
+		result.WriteString(fh.applyWordCase(wordCase, word))
+	}
+
+	return result.String()
+}
+
+// ToCase converts a string using a named case style or a style described by
+// a dict. See MustToCase for the accepted styles.
+//
+// Parameters:
+//
+//	style any - the name of the style or a dict describing it.
+//	str string - the string to convert.
+//
+// Returns:
+//
+//	string - the converted string, or an empty string if the style is invalid.
+//
+// Example:
+//
+//	{{ "HTTPServerID" | toCase "snake" }} // Output: "http_server_id"
+func (fh *FunctionHandler) ToCase(style any, str string) string {
+	result, _ := fh.MustToCase(style, str)
+	return result
+}
+
+// MustToCase converts a string using a named case style or a style described
+// by a dict, returning an error if the style is invalid.
+//
+// The built-in styles are "camel", "pascal", "snake", "kebab", "constant",
+// "dot", "path", "title" and "train", additional ones can be registered with
+// WithCaseStyle. A dict accepts the keys "separator", "firstWord" and
+// "otherWords" ("lower", "upper", "title" or "preserve") and
+// "numberBoundary" ("none", "before" or "both").
+//
+// Parameters:
+//
+//	style any - the name of the style or a dict describing it.
+//	str string - the string to convert.
+//
+// Returns:
+//
+//	string - the converted string.
+//	error - error if the style is unknown or malformed.
+//
+// Example:
+//
+//	{{ "v2Api" | mustToCase (dict "separator" "_" "otherWords" "lower" "numberBoundary" "both") }} // Output: "v_2_api", nil
+func (fh *FunctionHandler) MustToCase(style any, str string) (string, error) {
+	caseStyle, err := fh.parseCaseStyle(style)
+	if err != nil {
+		return "", err
+	}
+	return fh.ConvertCase(caseStyle, str), nil
+}
+
+// parseCaseStyle resolves the style given to the toCase function, either a
+// style name or a dict describing the style.
+func (fh *FunctionHandler) parseCaseStyle(style any) (CaseStyle, error) {
+	switch s := style.(type) {
+	case CaseStyle:
+		return s, nil
+	case string:
+		if caseStyle, ok := fh.caseStyles[s]; ok {
+			return caseStyle, nil
+		}
+		if caseStyle, ok := caseStylePresets[s]; ok {
+			return caseStyle, nil
+		}
+		return CaseStyle{}, fmt.Errorf("unknown case style: %s", s)
+	case map[string]any:
+		var caseStyle CaseStyle
+		var err error
+		for key, value := range s {
+			str := fh.ToString(value)
+			switch key {
+			case "separator":
+				caseStyle.Separator = str
+			case "firstWord":
+				caseStyle.FirstWord, err = parseWordCase(str)
+			case "otherWords":
+				caseStyle.OtherWords, err = parseWordCase(str)
+			case "numberBoundary":
+				caseStyle.NumberBoundary, err = parseNumberBoundary(str)
+			default:
+				err = fmt.Errorf("unknown case style key: %s", key)
+			}
+			if err != nil {
+				return CaseStyle{}, err
+			}
+		}
+		return caseStyle, nil
+	default:
+		return CaseStyle{}, fmt.Errorf("cannot use %T as case style", style)
+	}
+}
+
+func parseWordCase(s string) (WordCase, error) {
+	switch s {
+	case "preserve":
+		return WordCasePreserve, nil
+	case "lower":
+		return WordCaseLower, nil
+	case "upper":
+		return WordCaseUpper, nil
+	case "title":
+		return WordCaseTitle, nil
+	default:
+		return WordCasePreserve, fmt.Errorf("unknown word case: %s", s)
+	}
+}
+
+func parseNumberBoundary(s string) (NumberBoundary, error) {
+	switch s {
+	case "none":
+		return NumberBoundaryNone, nil
+	case "before":
+		return NumberBoundaryBefore, nil
+	case "both":
+		return NumberBoundaryBoth, nil
+	default:
+		return NumberBoundaryNone, fmt.Errorf("unknown number boundary: %s", s)
+	}
+}
+
+// applyWordCase applies the casing to a single word, using the registered
+// form of the word when it is a known acronym and the word is title cased.
+func (fh *FunctionHandler) applyWordCase(wordCase WordCase, word string) string {
+	switch wordCase {
+	case WordCaseLower:
+		return strings.ToLower(word)
+	case WordCaseUpper:
+		return strings.ToUpper(word)
+	case WordCaseTitle:
+		if acronym, ok := fh.acronyms[strings.ToUpper(word)]; ok {
+			return acronym
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		return string(unicode.ToTitle(r)) + strings.ToLower(word[size:])
+	default:
+		return word
+	}
+}
+
+// splitWords splits 'str' into words on non alphanumeric runes, case
+// transitions, digits and registered acronyms.
+func (fh *FunctionHandler) splitWords(str string, numberBoundary NumberBoundary) []string {
+	var words []string
+	chunks := strings.FieldsFunc(str, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, chunk := range chunks {
+		runes := []rune(chunk)
+		start := 0
+		for i := 0; i < len(runes); {
+			if i == start {
+				if n := fh.matchAcronym(runes[i:], numberBoundary); n > 0 {
+					words = append(words, string(runes[i:i+n]))
+					i += n
+					start = i
+					continue
+				}
+			} else if isWordBoundary(runes, i, numberBoundary) {
+				words = append(words, string(runes[start:i]))
+				start = i
+				continue
+			}
+			i++
+		}
+		if start < len(runes) {
+			words = append(words, string(runes[start:]))
+		}
+	}
+
+	return words
+}
+
+// matchAcronym returns the length of the longest registered acronym found at
+// the start of 'runes', written either in its registered form or in
+// uppercase, or 0 if none matches. An acronym only matches if it ends where a
+// new word starts: a capitalized word, another acronym, or a digit when
+// digits start new words.
+func (fh *FunctionHandler) matchAcronym(runes []rune, numberBoundary NumberBoundary) int {
+	longest := 0
+	for upper, acronym := range fh.acronyms {
+		n := utf8.RuneCountInString(acronym)
+		if n <= longest || n > len(runes) {
+			continue
+		}
+
+		candidate := string(runes[:n])
+		if candidate != acronym && candidate != upper {
+			continue
+		}
+		if n < len(runes) && !fh.isAcronymEnd(runes, n, numberBoundary) {
+			continue
+		}
+		longest = n
+	}
+	return longest
+}
+
+// isAcronymEnd reports whether an acronym matched on the first 'n' runes is
+// followed by the start of a new word.
+func (fh *FunctionHandler) isAcronymEnd(runes []rune, n int, numberBoundary NumberBoundary) bool {
+	next := runes[n]
+	switch {
+	case unicode.IsDigit(next):
+		return numberBoundary != NumberBoundaryNone
+	case unicode.IsUpper(next):
+		// Avoid splitting a longer uppercase run, "IDE" must not match "ID".
+		return n+1 < len(runes) && unicode.IsLower(runes[n+1]) || fh.matchAcronym(runes[n:], numberBoundary) > 0
+	default:
+		return false
+	}
+}
+
+// isWordBoundary reports whether a new word starts at the rune at index 'i'.
+func isWordBoundary(runes []rune, i int, numberBoundary NumberBoundary) bool {
+	prev, curr := runes[i-1], runes[i]
+
+	switch {
+	case unicode.IsDigit(curr) && unicode.IsLetter(prev):
+		return numberBoundary != NumberBoundaryNone
+	case unicode.IsLetter(curr) && unicode.IsDigit(prev):
+		return numberBoundary == NumberBoundaryBoth || unicode.IsUpper(curr)
+	case unicode.IsUpper(curr) && unicode.IsLower(prev):
+		return true
+	case unicode.IsUpper(curr) && unicode.IsUpper(prev):
+		// The last capital of an uppercase run starts a new word when followed by
+		// a lowercase letter, e.g. "HTTPServer" is split into "HTTP" and "Server".
+		return i+1 < len(runes) && unicode.IsLower(runes[i+1])
+	default:
+		return false
+	}
+}
+
 // Untitle converts the first letter of each word in 'str' to lowercase.
 //
 // Parameters:
@@ -1018,7 +1618,7 @@ func (fh *FunctionHandler) populateMapWithParts(parts []string) map[string]strin
 
 // Substring extracts a substring from 's' starting at 'start' and ending at 'end'.
 // Negative values for 'start' or 'end' are interpreted as positions from the end
-// of the string.
+// of the string. ANSI escape sequences are not counted and never cut.
 //
 // Parameters:
 //
@@ -1034,6 +1634,10 @@ func (fh *FunctionHandler) populateMapWithParts(parts []string) map[string]strin
 //
 //	{{ "Hello World" | substring 0 5 }} // Output: "Hello"
 func (fh *FunctionHandler) Substring(start, end int, str string) string {
+	if strings.Contains(str, "\x1b") {
+		return substringAnsi(start, end, str)
+	}
+
 	if start < 0 {
 		start = len(str) + start
 	}
@@ -1170,3 +1774,2587 @@ func (fh *FunctionHandler) Seq(params ...int) string {
 func (fh *FunctionHandler) convertIntArrayToString(slice []int, delimeter string) string {
 	return strings.Trim(strings.Join(strings.Fields(fmt.Sprint(slice)), delimeter), "[]")
 }
+
+// GraphemeCount returns the number of user-perceived characters (extended
+// grapheme clusters) in 'str'. Combining marks, emoji ZWJ sequences and flags
+// are counted as a single character.
+//
+// Parameters:
+//
+//	str string - the string to measure.
+//
+// Returns:
+//
+//	int - the number of grapheme clusters.
+//
+// Example:
+//
+//	{{ "été" | graphemeCount }} // Output: 3
+func (fh *FunctionHandler) GraphemeCount(str string) int {
+	return measureClusters(graphemeClusters(str), clusterCount)
+}
+
+// DisplayWidth returns the number of terminal columns needed to display
+// 'str'. East Asian wide and fullwidth characters as well as emoji take two
+// columns, combining marks, control characters and ANSI escape sequences take
+// none.
+//
+// Parameters:
+//
+//	str string - the string to measure.
+//
+// Returns:
+//
+//	int - the display width of the string.
+//
+// Example:
+//
+//	{{ "日本語" | displayWidth }} // Output: 6
+func (fh *FunctionHandler) DisplayWidth(str string) int {
+	return measureClusters(graphemeClusters(str), clusterWidth)
+}
+
+// TruncGraphemes truncates 'str' to a maximum of 'count' grapheme clusters.
+// If 'count' is negative, it keeps the last '-count' grapheme clusters.
+//
+// Parameters:
+//
+//	count int - the number of grapheme clusters to keep. Negative values
+//	            indicate truncation from the beginning.
+//	str string - the string to truncate.
+//
+// Returns:
+//
+//	string - the truncated string.
+//
+// Example:
+//
+//	{{ "👩‍👩‍👧 family" | truncGraphemes 1 }} // Output: "👩‍👩‍👧"
+func (fh *FunctionHandler) TruncGraphemes(count int, str string) string {
+	return truncClusters(count, graphemeClusters(str), clusterCount)
+}
+
+// TruncWidth truncates 'str' to a maximum display width of 'width' columns,
+// without splitting grapheme clusters. If 'width' is negative, it keeps the
+// last '-width' columns.
+//
+// Parameters:
+//
+//	width int - the number of columns to keep. Negative values indicate
+//	            truncation from the beginning.
+//	str string - the string to truncate.
+//
+// Returns:
+//
+//	string - the truncated string.
+//
+// Example:
+//
+//	{{ "日本語テキスト" | truncWidth 5 }} // Output: "日本"
+func (fh *FunctionHandler) TruncWidth(width int, str string) string {
+	return truncClusters(width, graphemeClusters(str), clusterWidth)
+}
+
+// EllipsisGraphemes truncates 'str' to 'maxWidth' grapheme clusters and
+// appends an ellipsis if the string is longer than 'maxWidth'.
+//
+// Parameters:
+//
+//	maxWidth int - the maximum number of grapheme clusters including the ellipsis.
+//	str string - the string to truncate.
+//
+// Returns:
+//
+//	string - the possibly truncated string with an ellipsis.
+//
+// Example:
+//
+//	{{ "Crème brûlée" | ellipsisGraphemes 8 }} // Output: "Crème..."
+func (fh *FunctionHandler) EllipsisGraphemes(maxWidth int, str string) string {
+	return ellipsisClusters(0, maxWidth, graphemeClusters(str), clusterCount)
+}
+
+// EllipsisWidth truncates 'str' to a display width of 'maxWidth' columns and
+// appends an ellipsis if the string is wider than 'maxWidth'.
+//
+// Parameters:
+//
+//	maxWidth int - the maximum display width including the ellipsis.
+//	str string - the string to truncate.
+//
+// Returns:
+//
+//	string - the possibly truncated string with an ellipsis.
+//
+// Example:
+//
+//	{{ "日本語テキスト" | ellipsisWidth 8 }} // Output: "日本..."
+func (fh *FunctionHandler) EllipsisWidth(maxWidth int, str string) string {
+	return ellipsisClusters(0, maxWidth, graphemeClusters(str), clusterWidth)
+}
+
+// EllipsisBothGraphemes truncates 'str' from both ends like EllipsisBoth,
+// counting grapheme clusters instead of runes.
+//
+// Parameters:
+//
+//	offset int - the grapheme cluster index where the preserved text starts.
+//	maxWidth int - the maximum number of grapheme clusters including ellipses.
+//	str string - the string to truncate.
+//
+// Returns:
+//
+//	string - the truncated string with ellipses on both ends.
+//
+// Example:
+//
+//	{{ "Crème brûlée au café" | ellipsisBothGraphemes 6 12 }} // Output: "...brûlée..."
+func (fh *FunctionHandler) EllipsisBothGraphemes(offset int, maxWidth int, str string) string {
+	return ellipsisClusters(offset, maxWidth, graphemeClusters(str), clusterCount)
+}
+
+// EllipsisBothWidth truncates 'str' from both ends like EllipsisBoth, keeping
+// the part displayed from the column 'offset' and measuring the result in
+// display columns. Wide characters crossing a bound are left out.
+//
+// Parameters:
+//
+//	offset int - the column where the preserved text starts.
+//	maxWidth int - the maximum display width including ellipses.
+//	str string - the string to truncate.
+//
+// Returns:
+//
+//	string - the truncated string with ellipses on both ends.
+//
+// Example:
+//
+//	{{ "日本語のテキスト" | ellipsisBothWidth 4 10 }} // Output: "...語の..."
+func (fh *FunctionHandler) EllipsisBothWidth(offset int, maxWidth int, str string) string {
+	return ellipsisClusters(offset, maxWidth, graphemeClusters(str), clusterWidth)
+}
+
+// SubstringGraphemes extracts the grapheme clusters of 'str' from 'start' to
+// 'end'. Negative values for 'start' or 'end' are interpreted as positions
+// from the end of the string.
+//
+// Parameters:
+//
+//	start int - the starting grapheme cluster index.
+//	end int - the ending grapheme cluster index, exclusive.
+//	str string - the source string.
+//
+// Returns:
+//
+//	string - the extracted substring.
+//
+// Example:
+//
+//	{{ "🇫🇷🇩🇪🇮🇹" | substrGraphemes 1 2 }} // Output: "🇩🇪"
+func (fh *FunctionHandler) SubstringGraphemes(start, end int, str string) string {
+	return substringClusters(start, end, graphemeClusters(str), clusterCount)
+}
+
+// SubstringWidth extracts the part of 'str' displayed between the columns
+// 'start' and 'end'. Negative values for 'start' or 'end' are interpreted as
+// columns from the end of the string. Wide characters crossing a bound are
+// left out.
+//
+// Parameters:
+//
+//	start int - the starting column.
+//	end int - the ending column, exclusive.
+//	str string - the source string.
+//
+// Returns:
+//
+//	string - the extracted substring.
+//
+// Example:
+//
+//	{{ "ab日本語" | substrWidth 2 6 }} // Output: "日本"
+func (fh *FunctionHandler) SubstringWidth(start, end int, str string) string {
+	return substringClusters(start, end, graphemeClusters(str), clusterWidth)
+}
+
+// WrapGraphemes breaks 'str' into lines of at most 'length' grapheme
+// clusters. Words longer than a line are split between grapheme clusters.
+//
+// Parameters:
+//
+//	length int - the maximum number of grapheme clusters of each line.
+//	str string - the string to be wrapped.
+//
+// Returns:
+//
+//	string - the wrapped string using newline characters to separate lines.
+//
+// Example:
+//
+//	{{ "Crème brûlée au café" | wrapGraphemes 12 }} // Output: "Crème brûlée\nau café"
+func (fh *FunctionHandler) WrapGraphemes(length int, str string) string {
+	return wrapClusters(length, "\n", true, str, clusterCount)
+}
+
+// WrapWidth breaks 'str' into lines with a display width of at most 'width'
+// columns. Words wider than a line, such as CJK text without spaces, are split
+// between grapheme clusters.
+//
+// Parameters:
+//
+//	width int - the maximum display width of each line.
+//	str string - the string to be wrapped.
+//
+// Returns:
+//
+//	string - the wrapped string using newline characters to separate lines.
+//
+// Example:
+//
+//	{{ "日本語のテキスト" | wrapWidth 6 }} // Output: "日本語\nのテキ\nスト"
+func (fh *FunctionHandler) WrapWidth(width int, str string) string {
+	return wrapClusters(width, "\n", true, str, clusterWidth)
+}
+
+// WordWrapGraphemes formats 'str' like WordWrap into lines of at most
+// 'wrapLength' grapheme clusters. Long words are split between grapheme
+// clusters when 'wrapLongWords' is true.
+//
+// Parameters:
+//
+//	wrapLength int - the maximum number of grapheme clusters of each line.
+//	newLineCharacter string - the string used to denote new lines.
+//	wrapLongWords bool - true to wrap long words that exceed the line length.
+//	str string - the string to wrap.
+//
+// Returns:
+//
+//	string - the wrapped string.
+//
+// Example:
+//
+//	{{ "Crème brûlée au café" | wordWrapGraphemes 8 "<br>" false }} // Output: "Crème<br>brûlée<br>au café"
+func (fh *FunctionHandler) WordWrapGraphemes(wrapLength int, newLineCharacter string, wrapLongWords bool, str string) string {
+	return wrapClusters(wrapLength, newLineCharacter, wrapLongWords, str, clusterCount)
+}
+
+// WordWrapWidth formats 'str' like WordWrap into lines with a display width of
+// at most 'wrapLength' columns. Long words are split between grapheme clusters
+// when 'wrapLongWords' is true.
+//
+// Parameters:
+//
+//	wrapLength int - the maximum display width of each line.
+//	newLineCharacter string - the string used to denote new lines.
+//	wrapLongWords bool - true to wrap long words that exceed the line length.
+//	str string - the string to wrap.
+//
+// Returns:
+//
+//	string - the wrapped string.
+//
+// Example:
+//
+//	{{ "日本語 のテキスト" | wordWrapWidth 6 "\n" false }} // Output: "日本語\nのテキスト"
+func (fh *FunctionHandler) WordWrapWidth(wrapLength int, newLineCharacter string, wrapLongWords bool, str string) string {
+	return wrapClusters(wrapLength, newLineCharacter, wrapLongWords, str, clusterWidth)
+}
+
+// PadLeft pads 'str' with spaces on the left up to a display width of
+// 'width' columns. Strings already wider are returned unchanged.
+//
+// Parameters:
+//
+//	width int - the display width to reach.
+//	str string - the string to pad.
+//
+// Returns:
+//
+//	string - the padded string.
+//
+// Example:
+//
+//	{{ "日本" | padLeft 6 }} // Output: "  日本"
+func (fh *FunctionHandler) PadLeft(width int, str string) string {
+	return strings.Repeat(" ", fh.paddingWidth(width, str)) + str
+}
+
+// PadRight pads 'str' with spaces on the right up to a display width of
+// 'width' columns. Strings already wider are returned unchanged.
+//
+// Parameters:
+//
+//	width int - the display width to reach.
+//	str string - the string to pad.
+//
+// Returns:
+//
+//	string - the padded string.
+//
+// Example:
+//
+//	{{ "日本" | padRight 6 }} // Output: "日本  "
+func (fh *FunctionHandler) PadRight(width int, str string) string {
+	return str + strings.Repeat(" ", fh.paddingWidth(width, str))
+}
+
+// Center pads 'str' with spaces on both sides up to a display width of
+// 'width' columns. When the padding cannot be split evenly, the extra space
+// is added on the right.
+//
+// Parameters:
+//
+//	width int - the display width to reach.
+//	str string - the string to center.
+//
+// Returns:
+//
+//	string - the centered string.
+//
+// Example:
+//
+//	{{ "日本" | center 9 }} // Output: "  日本   "
+func (fh *FunctionHandler) Center(width int, str string) string {
+	padding := fh.paddingWidth(width, str)
+	return strings.Repeat(" ", padding/2) + str + strings.Repeat(" ", padding-padding/2)
+}
+
+// paddingWidth returns the number of columns missing for 'str' to be displayed
+// on 'width' columns.
+func (fh *FunctionHandler) paddingWidth(width int, str string) int {
+	if padding := width - fh.DisplayWidth(str); padding > 0 {
+		return padding
+	}
+	return 0
+}
+
+// clusterMeasure returns the size of a grapheme cluster, either as a count or
+// as a display width.
+type clusterMeasure func(cluster string) int
+
+// clusterCount measures every grapheme cluster as a single character, and
+// ANSI escape sequences as none.
+func clusterCount(cluster string) int {
+	if isAnsiSequence(cluster) {
+		return 0
+	}
+	return 1
+}
+
+// clusterWidth measures the number of terminal columns used by a grapheme
+// cluster. The width of a cluster is the width of its base character, unless
+// an emoji presentation selector or a regional indicator pair makes it wide.
+func clusterWidth(cluster string) int {
+	r, size := utf8.DecodeRuneInString(cluster)
+	switch {
+	case unicode.IsControl(r):
+		return 0
+	case isRegionalIndicator(r) || strings.ContainsRune(cluster[size:], '\uFE0F'):
+		return 2
+	default:
+		return runeWidth(r)
+	}
+}
+
+// runeWidth returns the number of terminal columns used by a single rune.
+func runeWidth(r rune) int {
+	if r == 0 || unicode.IsControl(r) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// measureClusters returns the total size of the grapheme clusters.
+func measureClusters(clusters []string, measure clusterMeasure) int {
+	total := 0
+	for _, cluster := range clusters {
+		total += measure(cluster)
+	}
+	return total
+}
+
+// truncClusters keeps the grapheme clusters fitting in 'count', from the start
+// of the string, or from the end when 'count' is negative. ANSI escape
+// sequences of the removed part are kept so styles are still closed.
+func truncClusters(count int, clusters []string, measure clusterMeasure) string {
+	var builder strings.Builder
+	if count >= 0 {
+		size, truncated := 0, false
+		for _, cluster := range clusters {
+			size += measure(cluster)
+			truncated = truncated || size > count
+			if !truncated || isAnsiSequence(cluster) {
+				builder.WriteString(cluster)
+			}
+		}
+		return builder.String()
+	}
+
+	size, first := 0, len(clusters)
+	for first > 0 && size+measure(clusters[first-1]) <= -count {
+		first--
+		size += measure(clusters[first])
+	}
+	for i, cluster := range clusters {
+		if i >= first || isAnsiSequence(cluster) {
+			builder.WriteString(cluster)
+		}
+	}
+	return builder.String()
+}
+
+// ellipsisClusters truncates the grapheme clusters to 'maxWidth' including the
+// ellipses, keeping the part starting at 'offset'. Like EllipsisBoth, the
+// string is returned unchanged when 'maxWidth' cannot hold the ellipses and a
+// character.
+func ellipsisClusters(offset, maxWidth int, clusters []string, measure clusterMeasure) string {
+	if maxWidth < 4 || offset > 0 && maxWidth < 7 {
+		return strings.Join(clusters, "")
+	}
+
+	total := measureClusters(clusters, measure)
+	if total <= maxWidth || total <= offset {
+		return substringClusters(offset, total, clusters, measure)
+	}
+	if offset == 0 {
+		return truncClusters(maxWidth-3, clusters, measure) + "..."
+	}
+
+	// The right ellipsis is only added when the end of the string is cut.
+	end := offset + maxWidth - 6
+	if end >= total {
+		return "..." + substringClusters(offset, total, clusters, measure)
+	}
+	return "..." + substringClusters(offset, end, clusters, measure) + "..."
+}
+
+// substringClusters extracts the grapheme clusters located entirely between the
+// positions 'start' and 'end', negative positions being relative to the end.
+func substringClusters(start, end int, clusters []string, measure clusterMeasure) string {
+	total := measureClusters(clusters, measure)
+	if start < 0 {
+		start = total + start
+	}
+	if end < 0 {
+		end = total + end
+	}
+
+	var builder strings.Builder
+	position := 0
+	for _, cluster := range clusters {
+		size := measure(cluster)
+		if position >= start && position+size <= end || isAnsiSequence(cluster) {
+			builder.WriteString(cluster)
+		}
+		position += size
+	}
+	return builder.String()
+}
+
+// wrapClusters breaks 'str' into lines of at most 'length' separated by
+// 'newLine'. Words too long to fit on a line are split between grapheme
+// clusters when 'wrapLongWords' is true, and put on their own line otherwise.
+func wrapClusters(length int, newLine string, wrapLongWords bool, str string, measure clusterMeasure) string {
+	if length < 1 {
+		length = 1
+	}
+	if newLine == "" {
+		newLine = "\n"
+	}
+
+	var resultBuilder strings.Builder
+	var currentLineLength int
+
+	for _, word := range strings.Fields(str) {
+		clusters := graphemeClusters(word)
+		wordLength := measureClusters(clusters, measure)
+
+		if currentLineLength > 0 && currentLineLength+1+wordLength > length {
+			resultBuilder.WriteString(newLine)
+			currentLineLength = 0
+		}
+		if currentLineLength > 0 {
+			resultBuilder.WriteRune(' ')
+			currentLineLength++
+		}
+
+		if !wrapLongWords {
+			resultBuilder.WriteString(word)
+			currentLineLength += wordLength
+			continue
+		}
+		for _, cluster := range clusters {
+			size := measure(cluster)
+			if currentLineLength > 0 && currentLineLength+size > length {
+				resultBuilder.WriteString(newLine)
+				currentLineLength = 0
+			}
+			resultBuilder.WriteString(cluster)
+			currentLineLength += size
+		}
+	}
+
+	return resultBuilder.String()
+}
+
+// graphemeClusters splits 's' into extended grapheme clusters following the
+// main rules of Unicode Standard Annex #29: CRLF, Hangul syllables, extending
+// and spacing marks, emoji modifiers and ZWJ sequences, and regional indicator
+// pairs. Prepend characters are not handled. ANSI escape sequences are kept
+// whole, each one forming a cluster of its own.
+func graphemeClusters(s string) []string {
+	var clusters []string
+	start := 0
+	var prev rune = -1
+	pictographic := false // The cluster started with an extended pictographic.
+	regionalIndicators := 0
+
+	for i := 0; i < len(s); {
+		if length := ansiSequenceLength(s[i:]); length > 0 {
+			if start < i {
+				clusters = append(clusters, s[start:i])
+			}
+			clusters = append(clusters, s[i:i+length])
+			i += length
+			start, prev = i, -1
+			pictographic, regionalIndicators = false, 0
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if prev >= 0 && isGraphemeBoundary(prev, r, pictographic, regionalIndicators) {
+			clusters = append(clusters, s[start:i])
+			start = i
+			pictographic, regionalIndicators = false, 0
+		}
+
+		if i == start {
+			pictographic = isExtendedPictographic(r)
+		}
+		if isRegionalIndicator(r) {
+			regionalIndicators++
+		}
+		prev = r
+		i += size
+	}
+
+	if start < len(s) {
+		clusters = append(clusters, s[start:])
+	}
+	return clusters
+}
+
+// isGraphemeBoundary reports whether a grapheme cluster boundary exists
+// between 'prev' and 'curr'.
+func isGraphemeBoundary(prev, curr rune, pictographic bool, regionalIndicators int) bool {
+	switch {
+	case prev == '\r' && curr == '\n':
+		return false
+	case unicode.IsControl(prev) || unicode.IsControl(curr):
+		return true
+	case isHangulJoined(prev, curr):
+		return false
+	case isGraphemeExtend(curr) || curr == '\u200D' || unicode.Is(unicode.Mc, curr):
+		return false
+	case prev == '\u200D' && pictographic && isExtendedPictographic(curr):
+		return false
+	case isRegionalIndicator(prev) && isRegionalIndicator(curr):
+		return regionalIndicators%2 == 0
+	default:
+		return true
+	}
+}
+
+// isGraphemeExtend reports whether 'r' extends the preceding grapheme cluster.
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Variation_Selector) ||
+		r >= 0x1F3FB && r <= 0x1F3FF || // Emoji skin tone modifiers
+		r >= 0xE0020 && r <= 0xE007F // Emoji tag sequences
+}
+
+// isRegionalIndicator reports whether 'r' is a regional indicator symbol, two
+// of them forming a flag.
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isExtendedPictographic reports whether 'r' is an emoji-like pictographic
+// character that can be joined with others by a zero width joiner.
+func isExtendedPictographic(r rune) bool {
+	return r == 0x00A9 || r == 0x00AE ||
+		r >= 0x2190 && r <= 0x21FF ||
+		r >= 0x2300 && r <= 0x23FF ||
+		r >= 0x2460 && r <= 0x27BF ||
+		r >= 0x2900 && r <= 0x297F ||
+		r >= 0x2B00 && r <= 0x2BFF ||
+		r >= 0x1F000 && r <= 0x1F1E5 ||
+		r >= 0x1F200 && r <= 0x1FAFF
+}
+
+// isHangulJoined reports whether two Hangul jamos or syllables belong to the
+// same syllable block.
+func isHangulJoined(prev, curr rune) bool {
+	isL := func(r rune) bool { return r >= 0x1100 && r <= 0x115F || r >= 0xA960 && r <= 0xA97C }
+	isV := func(r rune) bool { return r >= 0x1160 && r <= 0x11A7 || r >= 0xD7B0 && r <= 0xD7C6 }
+	isT := func(r rune) bool { return r >= 0x11A8 && r <= 0x11FF || r >= 0xD7CB && r <= 0xD7FB }
+	isSyllable := func(r rune) bool { return r >= 0xAC00 && r <= 0xD7A3 }
+	isLV := func(r rune) bool { return isSyllable(r) && (r-0xAC00)%28 == 0 }
+
+	switch {
+	case isL(prev):
+		return isL(curr) || isV(curr) || isSyllable(curr)
+	case isLV(prev) || isV(prev):
+		return isV(curr) || isT(curr)
+	case isSyllable(prev) || isT(prev):
+		return isT(curr)
+	default:
+		return false
+	}
+}
+
+// SlugOptions configures how a string is turned into a slug.
+//
+// Example:
+//
+//	options := SlugOptions{Separator: "_", MaxLength: 63, Lowercase: true}
+//	fh.SlugifyWithOptions(options, "Crème Brûlée") // Output: "creme_brulee"
+type SlugOptions struct {
+	Separator string // String replacing every run of disallowed characters.
+	MaxLength int    // Maximum length of the slug in bytes, 0 means no limit.
+	Lowercase bool   // Whether to lowercase the slug.
+	Allowed   string // Characters kept in addition to ASCII letters and digits.
+}
+
+// defaultSlugOptions are the options used by the slugify function, and the
+// base options overridden by the dict given to slugifyWith.
+var defaultSlugOptions = SlugOptions{Separator: "-", Lowercase: true}
+
+// slugTransliterations maps characters that are not decomposed into an ASCII
+// letter and a diacritic by Unicode normalization to their ASCII spelling.
+var slugTransliterations = map[rune]string{
+	// Latin
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'đ': "d", 'Đ': "D", 'ð': "d", 'Ð': "D", 'ł': "l", 'Ł': "L", 'þ': "th",
+	'Þ': "TH", 'ı': "i", 'ħ': "h", 'Ħ': "H",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+	'Α': "A", 'Β': "V", 'Γ': "G", 'Δ': "D", 'Ε': "E", 'Ζ': "Z", 'Η': "I",
+	'Θ': "Th", 'Ι': "I", 'Κ': "K", 'Λ': "L", 'Μ': "M", 'Ν': "N", 'Ξ': "X",
+	'Ο': "O", 'Π': "P", 'Ρ': "R", 'Σ': "S", 'Τ': "T", 'Υ': "Y", 'Φ': "F",
+	'Χ': "Ch", 'Ψ': "Ps", 'Ω': "O",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+	'А': "A", 'Б': "B", 'В': "V", 'Г': "G", 'Д': "D", 'Е': "E", 'Ё': "Yo",
+	'Ж': "Zh", 'З': "Z", 'И': "I", 'Й': "Y", 'К': "K", 'Л': "L", 'М': "M",
+	'Н': "N", 'О': "O", 'П': "P", 'Р': "R", 'С': "S", 'Т': "T", 'У': "U",
+	'Ф': "F", 'Х': "Kh", 'Ц': "Ts", 'Ч': "Ch", 'Ш': "Sh", 'Щ': "Shch",
+	'Ъ': "", 'Ы': "Y", 'Ь': "", 'Э': "E", 'Ю': "Yu", 'Я': "Ya",
+	'І': "I", 'Ї': "Yi", 'Є': "Ye", 'Ґ': "G", 'Ў': "U",
+}
+
+// Slugify converts 'str' into a lowercase, URL-friendly slug. Accents are
+// removed, common Greek and Cyrillic letters are transliterated and any run of
+// other characters is replaced by a hyphen.
+//
+// Parameters:
+//
+//	str string - the string to convert.
+//
+// Returns:
+//
+//	string - the slug.
+//
+// Example:
+//
+//	{{ "Crème Brûlée — Menu #2" | slugify }} // Output: "creme-brulee-menu-2"
+func (fh *FunctionHandler) Slugify(str string) string {
+	return fh.SlugifyWithOptions(defaultSlugOptions, str)
+}
+
+// SlugifyWith converts 'str' into a slug using the options described by a
+// dict. See MustSlugifyWith for the accepted options.
+//
+// Parameters:
+//
+//	options any - a dict of options overriding the slugify defaults.
+//	str string - the string to convert.
+//
+// Returns:
+//
+//	string - the slug, or an empty string if the options are invalid.
+//
+// Example:
+//
+//	{{ "Crème Brûlée" | slugifyWith (dict "separator" "_") }} // Output: "creme_brulee"
+func (fh *FunctionHandler) SlugifyWith(options any, str string) string {
+	result, _ := fh.MustSlugifyWith(options, str)
+	return result
+}
+
+// MustSlugifyWith converts 'str' into a slug using the options described by a
+// dict, returning an error if the options are invalid.
+//
+// The dict accepts the keys "separator" (default "-"), "maxLength" (default
+// 0, no limit), "lowercase" (default true) and "allowed", the characters kept
+// in addition to ASCII letters and digits.
+//
+// Parameters:
+//
+//	options any - a dict of options overriding the slugify defaults.
+//	str string - the string to convert.
+//
+// Returns:
+//
+//	string - the slug.
+//	error - error if an option is unknown or has an invalid value.
+//
+// Example:
+//
+//	{{ "Release Notes v1.2" | mustSlugifyWith (dict "allowed" "." "maxLength" 16) }} // Output: "release-notes-v1", nil
+func (fh *FunctionHandler) MustSlugifyWith(options any, str string) (string, error) {
+	slugOptions, err := fh.parseSlugOptions(options)
+	if err != nil {
+		return "", err
+	}
+	return fh.SlugifyWithOptions(slugOptions, str), nil
+}
+
+// SlugifyWithOptions converts 'str' into a slug following 'options'.
+//
+// Parameters:
+//
+//	options SlugOptions - the options to apply.
+//	str string - the string to convert.
+//
+// Returns:
+//
+//	string - the slug.
+//
+// Example:
+//
+//	fh.SlugifyWithOptions(SlugOptions{Separator: "."}, "Ελληνικά Νέα") // Output: "Ellinika.Nea"
+func (fh *FunctionHandler) SlugifyWithOptions(options SlugOptions, str string) string {
+	// Characters are looked up in the transliteration table before and after
+	// stripping their diacritics, as "й" must not be read as "и" but "έ" reads
+	// as "ε".
+	var transliterated strings.Builder
+	for _, r := range norm.NFC.String(str) {
+		if replacement, ok := slugTransliterations[r]; ok {
+			transliterated.WriteString(replacement)
+			continue
+		}
+
+		for _, base := range removeDiacritics(string(r)) {
+			if replacement, ok := slugTransliterations[base]; ok {
+				transliterated.WriteString(replacement)
+			} else {
+				transliterated.WriteRune(base)
+			}
+		}
+	}
+
+	normalized := transliterated.String()
+	if options.Lowercase {
+		normalized = strings.ToLower(normalized)
+	}
+
+	var slug strings.Builder
+	pendingSeparator := false
+	for _, r := range normalized {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) || strings.ContainsRune(options.Allowed, r) {
+			if pendingSeparator && slug.Len() > 0 {
+				slug.WriteString(options.Separator)
+			}
+			pendingSeparator = false
+			slug.WriteRune(r)
+			continue
+		}
+		pendingSeparator = true
+	}
+
+	result := slug.String()
+	if options.MaxLength > 0 && len(result) > options.MaxLength {
+		result = result[:options.MaxLength]
+		if options.Separator != "" {
+			for strings.HasSuffix(result, options.Separator) {
+				result = strings.TrimSuffix(result, options.Separator)
+			}
+		}
+		// Avoid leaving an allowed multi-byte character cut in half.
+		for !utf8.ValidString(result) {
+			result = result[:len(result)-1]
+		}
+	}
+	return result
+}
+
+// parseSlugOptions resolves the options given to the slugifyWith function,
+// overriding the default options with the keys of the dict.
+func (fh *FunctionHandler) parseSlugOptions(options any) (SlugOptions, error) {
+	switch o := options.(type) {
+	case SlugOptions:
+		return o, nil
+	case map[string]any:
+		slugOptions := defaultSlugOptions
+		for key, value := range o {
+			switch key {
+			case "separator":
+				slugOptions.Separator = fh.ToString(value)
+			case "maxLength":
+				maxLength, err := cast.ToIntE(value)
+				if err != nil {
+					return SlugOptions{}, fmt.Errorf("invalid slug maxLength: %w", err)
+				}
+				slugOptions.MaxLength = maxLength
+			case "lowercase":
+				lowercase, err := cast.ToBoolE(value)
+				if err != nil {
+					return SlugOptions{}, fmt.Errorf("invalid slug lowercase: %w", err)
+				}
+				slugOptions.Lowercase = lowercase
+			case "allowed":
+				slugOptions.Allowed = fh.ToString(value)
+			default:
+				return SlugOptions{}, fmt.Errorf("unknown slug option: %s", key)
+			}
+		}
+		return slugOptions, nil
+	default:
+		return SlugOptions{}, fmt.Errorf("cannot use %T as slug options", options)
+	}
+}
+
+// inflectionRule replaces the end of a word matching a pattern to change its
+// grammatical number.
+type inflectionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// inflectionRules holds the rules used to pluralize and singularize words.
+// Rules are checked in order and the first matching one is applied.
+type inflectionRules struct {
+	plurals      []inflectionRule
+	singulars    []inflectionRule
+	irregulars   map[string]string // Singular to plural.
+	uncountables map[string]struct{}
+}
+
+func newInflectionRule(pattern, replacement string) inflectionRule {
+	return inflectionRule{pattern: regexp.MustCompile("(?i)" + pattern), replacement: replacement}
+}
+
+// defaultInflections are the English inflection rules, checked after the
+// rules registered on the handler.
+var defaultInflections = inflectionRules{
+	plurals: []inflectionRule{
+		newInflectionRule(`(quiz)$`, "${1}zes"),
+		newInflectionRule(`^(oxen)$`, "${1}"),
+		newInflectionRule(`^(ox)$`, "${1}en"),
+		newInflectionRule(`^(m|l)ice$`, "${1}ice"),
+		newInflectionRule(`^(m|l)ouse$`, "${1}ice"),
+		newInflectionRule(`(matr|vert|ind)(?:ix|ex)$`, "${1}ices"),
+		newInflectionRule(`(x|ch|ss|sh)$`, "${1}es"),
+		newInflectionRule(`([^aeiouy]|qu)y$`, "${1}ies"),
+		newInflectionRule(`(hive)$`, "${1}s"),
+		newInflectionRule(`(?:([^f])fe|([lr])f)$`, "${1}${2}ves"),
+		newInflectionRule(`sis$`, "ses"),
+		newInflectionRule(`([ti])a$`, "${1}a"),
+		newInflectionRule(`([ti])um$`, "${1}a"),
+		newInflectionRule(`(buffal|tomat|potat|her|ech)o$`, "${1}oes"),
+		newInflectionRule(`(bu)s$`, "${1}ses"),
+		newInflectionRule(`(alias|status|campus)$`, "${1}es"),
+		newInflectionRule(`(octop|vir)(?:us|i)$`, "${1}i"),
+		newInflectionRule(`^(ax|test)is$`, "${1}es"),
+		newInflectionRule(`s$`, "s"),
+		newInflectionRule(`$`, "s"),
+	},
+	singulars: []inflectionRule{
+		newInflectionRule(`(database)s$`, "${1}"),
+		newInflectionRule(`(quiz)zes$`, "${1}"),
+		newInflectionRule(`(matr)ices$`, "${1}ix"),
+		newInflectionRule(`(vert|ind)ices$`, "${1}ex"),
+		newInflectionRule(`^(ox)en`, "${1}"),
+		newInflectionRule(`(alias|status|campus)(?:es)?$`, "${1}"),
+		newInflectionRule(`(octop|vir)(?:us|i)$`, "${1}us"),
+		newInflectionRule(`^(a)x[ie]s$`, "${1}xis"),
+		newInflectionRule(`(cris|test)(?:is|es)$`, "${1}is"),
+		newInflectionRule(`(shoe)s$`, "${1}"),
+		newInflectionRule(`(buffal|tomat|potat|her|ech)oes$`, "${1}o"),
+		newInflectionRule(`(bus)(?:es)?$`, "${1}"),
+		newInflectionRule(`^(m|l)ice$`, "${1}ouse"),
+		newInflectionRule(`(x|ch|ss|sh)es$`, "${1}"),
+		newInflectionRule(`(m)ovies$`, "${1}ovie"),
+		newInflectionRule(`(s)eries$`, "${1}eries"),
+		newInflectionRule(`([^aeiouy]|qu)ies$`, "${1}y"),
+		newInflectionRule(`([lr])ves$`, "${1}f"),
+		newInflectionRule(`(tive)s$`, "${1}"),
+		newInflectionRule(`(hive)s$`, "${1}"),
+		newInflectionRule(`([^f])ves$`, "${1}fe"),
+		newInflectionRule(`((a)naly|(b)a|(d)iagno|(p)arenthe|(p)rogno|(s)ynop|(t)he)(?:sis|ses)$`, "${1}sis"),
+		newInflectionRule(`([ti])a$`, "${1}um"),
+		newInflectionRule(`(n)ews$`, "${1}ews"),
+		newInflectionRule(`(ss)$`, "${1}"),
+		newInflectionRule(`s$`, ""),
+	},
+	irregulars: map[string]string{
+		"person":     "people",
+		"man":        "men",
+		"woman":      "women",
+		"child":      "children",
+		"foot":       "feet",
+		"tooth":      "teeth",
+		"goose":      "geese",
+		"sex":        "sexes",
+		"move":       "moves",
+		"zombie":     "zombies",
+		"cactus":     "cacti",
+		"criterion":  "criteria",
+		"phenomenon": "phenomena",
+	},
+	uncountables: map[string]struct{}{
+		"equipment": {}, "information": {}, "rice": {}, "money": {}, "species": {},
+		"series": {}, "fish": {}, "sheep": {}, "deer": {}, "jeans": {}, "police": {},
+		"news": {}, "metadata": {}, "software": {}, "hardware": {}, "feedback": {},
+	},
+}
+
+// WithPluralRule registers a rule used to pluralize words, checked before the
+// default English rules. The rule is a case-insensitive regular expression
+// matching the end of the singular word, and the replacement may reference
+// its capture groups. It panics if the rule is not a valid regular expression.
+//
+// Example:
+//
+//	handler := NewFunctionHandler(WithPluralRule(`(vertex)$`, "${1}es"))
+func WithPluralRule(rule, replacement string) FunctionHandlerOption {
+	inflection := newInflectionRule(rule, replacement)
+	return func(p *FunctionHandler) {
+		p.inflections.plurals = append(p.inflections.plurals, inflection)
+	}
+}
+
+// WithSingularRule registers a rule used to singularize words, checked before
+// the default English rules. The rule is a case-insensitive regular expression
+// matching the end of the plural word, and the replacement may reference its
+// capture groups. It panics if the rule is not a valid regular expression.
+//
+// Example:
+//
+//	handler := NewFunctionHandler(WithSingularRule(`(vertex)es$`, "${1}"))
+func WithSingularRule(rule, replacement string) FunctionHandlerOption {
+	inflection := newInflectionRule(rule, replacement)
+	return func(p *FunctionHandler) {
+		p.inflections.singulars = append(p.inflections.singulars, inflection)
+	}
+}
+
+// WithIrregular registers a word whose plural does not follow any rule.
+//
+// Example:
+//
+//	handler := NewFunctionHandler(WithIrregular("octopus", "octopodes"))
+func WithIrregular(singular, plural string) FunctionHandlerOption {
+	return func(p *FunctionHandler) {
+		if p.inflections.irregulars == nil {
+			p.inflections.irregulars = make(map[string]string)
+		}
+
+		p.inflections.irregulars[strings.ToLower(singular)] = strings.ToLower(plural)
+	}
+}
+
+// WithUncountable registers words having the same singular and plural form.
+//
+// Example:
+//
+//	handler := NewFunctionHandler(WithUncountable("firmware", "middleware"))
+func WithUncountable(words ...string) FunctionHandlerOption {
+	return func(p *FunctionHandler) {
+		if p.inflections.uncountables == nil {
+			p.inflections.uncountables = make(map[string]struct{}, len(words))
+		}
+
+		for _, word := range words {
+			p.inflections.uncountables[strings.ToLower(word)] = struct{}{}
+		}
+	}
+}
+
+// Pluralize returns the plural form of an English word. Only the last word of
+// 'str' is inflected and its capitalization is preserved.
+//
+// Parameters:
+//
+//	str string - the word to pluralize.
+//
+// Returns:
+//
+//	string - the plural form of the word.
+//
+// Example:
+//
+//	{{ "person" | pluralize }} // Output: "people"
+//	{{ "index" | pluralize }} // Output: "indices"
+func (fh *FunctionHandler) Pluralize(str string) string {
+	return fh.inflect(str, true)
+}
+
+// Singularize returns the singular form of an English word. Only the last word
+// of 'str' is inflected and its capitalization is preserved.
+//
+// Parameters:
+//
+//	str string - the word to singularize.
+//
+// Returns:
+//
+//	string - the singular form of the word.
+//
+// Example:
+//
+//	{{ "Categories" | singularize }} // Output: "Category"
+func (fh *FunctionHandler) Singularize(str string) string {
+	return fh.inflect(str, false)
+}
+
+// PluralizeCount returns a singular English word unchanged if 'count' is
+// exactly 1 or -1, and its plural form otherwise, so that 1.5 items are
+// plural. Unlike Plural, only the singular form of the word is needed.
+//
+// Parameters:
+//
+//	count any - the number of items, converted to a float.
+//	str string - the singular word to inflect.
+//
+// Returns:
+//
+//	string - the word in the form matching the count.
+//
+// Example:
+//
+//	{{ "person" | pluralizeCount 1 }} // Output: "person"
+//	{{ "person" | pluralizeCount 3 }} // Output: "people"
+func (fh *FunctionHandler) PluralizeCount(count any, str string) string {
+	if math.Abs(cast.ToFloat64(count)) == 1 {
+		return str
+	}
+	return fh.Pluralize(str)
+}
+
+// Ordinalize converts a number to its English ordinal form.
+//
+// Parameters:
+//
+//	number any - the number to convert, converted to an integer.
+//
+// Returns:
+//
+//	string - the ordinal form of the number.
+//
+// Example:
+//
+//	{{ 23 | ordinalize }} // Output: "23rd"
+func (fh *FunctionHandler) Ordinalize(number any) string {
+	n := cast.ToInt64(number)
+	lastDigits := n % 100
+	if lastDigits < 0 {
+		lastDigits = -lastDigits
+	}
+
+	suffix := "th"
+	if lastDigits < 11 || lastDigits > 13 {
+		switch lastDigits % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+var (
+	smallNumberWords = []string{
+		"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen",
+		"seventeen", "eighteen", "nineteen",
+	}
+	tensNumberWords  = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	scaleNumberWords = []string{"", "thousand", "million", "billion", "trillion", "quadrillion", "quintillion"}
+)
+
+// NumberToWords spells out an integer in English words.
+//
+// Parameters:
+//
+//	number any - the number to spell out, converted to an integer.
+//
+// Returns:
+//
+//	string - the number written in words.
+//
+// Example:
+//
+//	{{ 120 | numberToWords }} // Output: "one hundred twenty"
+//	{{ -1042 | numberToWords }} // Output: "minus one thousand forty-two"
+func (fh *FunctionHandler) NumberToWords(number any) string {
+	n := cast.ToInt64(number)
+	if n == 0 {
+		return smallNumberWords[0]
+	}
+
+	// Work on the magnitude as an unsigned integer to support math.MinInt64.
+	magnitude := uint64(n)
+	if n < 0 {
+		magnitude = -magnitude
+	}
+
+	var groups []string
+	for scale := 0; magnitude > 0; scale++ {
+		if group := magnitude % 1000; group > 0 {
+			words := hundredsToWords(group)
+			if scaleNumberWords[scale] != "" {
+				words += " " + scaleNumberWords[scale]
+			}
+			groups = append([]string{words}, groups...)
+		}
+		magnitude /= 1000
+	}
+
+	if n < 0 {
+		return "minus " + strings.Join(groups, " ")
+	}
+	return strings.Join(groups, " ")
+}
+
+// hundredsToWords spells out a number between 1 and 999.
+func hundredsToWords(n uint64) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, smallNumberWords[n/100], "hundred")
+		n %= 100
+	}
+
+	switch {
+	case n == 0:
+	case n < 20:
+		words = append(words, smallNumberWords[n])
+	case n%10 == 0:
+		words = append(words, tensNumberWords[n/10])
+	default:
+		words = append(words, tensNumberWords[n/10]+"-"+smallNumberWords[n%10])
+	}
+	return strings.Join(words, " ")
+}
+
+// HumanJoin joins the elements of a list into an English enumeration, using
+// commas and "and" before the last element.
+//
+// Parameters:
+//
+//	list any - the elements to join, converted to strings.
+//
+// Returns:
+//
+//	string - the enumeration.
+//
+// Example:
+//
+//	{{ list "a" "b" "c" | humanJoin }} // Output: "a, b and c"
+func (fh *FunctionHandler) HumanJoin(list any) string {
+	return fh.HumanJoinWith("and", list)
+}
+
+// HumanJoinWith joins the elements of a list into an English enumeration, using
+// commas and 'conjunction' before the last element.
+//
+// Parameters:
+//
+//	conjunction string - the word placed before the last element.
+//	list any - the elements to join, converted to strings.
+//
+// Returns:
+//
+//	string - the enumeration.
+//
+// Example:
+//
+//	{{ list "a" "b" "c" | humanJoinWith "or" }} // Output: "a, b or c"
+func (fh *FunctionHandler) HumanJoinWith(conjunction string, list any) string {
+	elements := fh.StrSlice(list)
+	if len(elements) < 2 {
+		return strings.Join(elements, "")
+	}
+
+	last := len(elements) - 1
+	return strings.Join(elements[:last], ", ") + " " + conjunction + " " + elements[last]
+}
+
+// inflect pluralizes or singularizes the last word of 'str', checking the
+// rules registered on the handler before the default ones.
+func (fh *FunctionHandler) inflect(str string, plural bool) string {
+	// Only the last word is inflected, "user account" becoming "user accounts".
+	start := strings.LastIndexFunc(str, func(r rune) bool { return !unicode.IsLetter(r) }) + 1
+	prefix, word := str[:start], str[start:]
+	if word == "" {
+		return str
+	}
+
+	lower := strings.ToLower(word)
+	for _, inflections := range []*inflectionRules{&fh.inflections, &defaultInflections} {
+		if _, ok := inflections.uncountables[lower]; ok {
+			return str
+		}
+
+		for singular, pluralForm := range inflections.irregulars {
+			if lower == singular || lower == pluralForm {
+				if plural {
+					return prefix + matchWordCase(word, pluralForm)
+				}
+				return prefix + matchWordCase(word, singular)
+			}
+		}
+
+		rules := inflections.singulars
+		if plural {
+			rules = inflections.plurals
+		}
+		for _, rule := range rules {
+			if rule.pattern.MatchString(word) {
+				return prefix + matchWordCase(word, rule.pattern.ReplaceAllString(word, rule.replacement))
+			}
+		}
+	}
+
+	return str
+}
+
+// matchWordCase applies the capitalization of 'original' to 'word', either
+// all uppercase or capitalized.
+func matchWordCase(original, word string) string {
+	if len(original) > 1 && original == strings.ToUpper(original) {
+		return strings.ToUpper(word)
+	}
+
+	r, _ := utf8.DecodeRuneInString(original)
+	if unicode.IsUpper(r) {
+		first, size := utf8.DecodeRuneInString(word)
+		return string(unicode.ToUpper(first)) + word[size:]
+	}
+	return word
+}
+
+// removeDiacritics decomposes the characters of 'str' to strip their
+// diacritics, "é" becoming "e".
+func removeDiacritics(str string) string {
+	stripDiacritics := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(stripDiacritics, str)
+	if err != nil {
+		return str
+	}
+	return result
+}
+
+// LevenshteinDistance returns the minimum number of single-character
+// insertions, deletions and substitutions needed to change 'a' into 'b'.
+//
+// Parameters:
+//
+//	a string - the first string.
+//	b string - the second string.
+//
+// Returns:
+//
+//	int - the Levenshtein distance between the strings.
+//
+// Example:
+//
+//	{{ levenshteinDistance "kitten" "sitting" }} // Output: 3
+func (fh *FunctionHandler) LevenshteinDistance(a, b string) int {
+	return levenshtein([]rune(a), []rune(b))
+}
+
+// LevenshteinSimilarity returns the Levenshtein distance between 'a' and 'b'
+// normalized as a similarity between 0 (completely different) and 1 (equal).
+//
+// Parameters:
+//
+//	a string - the first string.
+//	b string - the second string.
+//
+// Returns:
+//
+//	float64 - the similarity between the strings.
+//
+// Example:
+//
+//	{{ levenshteinSimilarity "staging" "stageing" }} // Output: 0.875
+func (fh *FunctionHandler) LevenshteinSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	return distanceToSimilarity(levenshtein(ra, rb), ra, rb)
+}
+
+// DamerauLevenshteinDistance returns the minimum number of single-character
+// insertions, deletions, substitutions and transpositions of adjacent
+// characters needed to change 'a' into 'b'.
+//
+// Parameters:
+//
+//	a string - the first string.
+//	b string - the second string.
+//
+// Returns:
+//
+//	int - the Damerau-Levenshtein distance between the strings.
+//
+// Example:
+//
+//	{{ damerauLevenshteinDistance "prodcution" "production" }} // Output: 1
+func (fh *FunctionHandler) DamerauLevenshteinDistance(a, b string) int {
+	return damerauLevenshtein([]rune(a), []rune(b))
+}
+
+// DamerauLevenshteinSimilarity returns the Damerau-Levenshtein distance
+// between 'a' and 'b' normalized as a similarity between 0 (completely
+// different) and 1 (equal).
+//
+// Parameters:
+//
+//	a string - the first string.
+//	b string - the second string.
+//
+// Returns:
+//
+//	float64 - the similarity between the strings.
+//
+// Example:
+//
+//	{{ damerauLevenshteinSimilarity "prodcution" "production" }} // Output: 0.9
+func (fh *FunctionHandler) DamerauLevenshteinSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	return distanceToSimilarity(damerauLevenshtein(ra, rb), ra, rb)
+}
+
+// JaroWinklerSimilarity returns the Jaro-Winkler similarity between 'a' and
+// 'b', between 0 (completely different) and 1 (equal). Strings sharing a
+// common prefix are considered more similar.
+//
+// Parameters:
+//
+//	a string - the first string.
+//	b string - the second string.
+//
+// Returns:
+//
+//	float64 - the similarity between the strings.
+//
+// Example:
+//
+//	{{ jaroWinklerSimilarity "MARTHA" "MARHTA" }} // Output: 0.9611111111111111
+func (fh *FunctionHandler) JaroWinklerSimilarity(a, b string) float64 {
+	return jaroWinkler([]rune(a), []rune(b))
+}
+
+// JaroWinklerDistance returns the Jaro-Winkler distance between 'a' and 'b',
+// defined as one minus their Jaro-Winkler similarity.
+//
+// Parameters:
+//
+//	a string - the first string.
+//	b string - the second string.
+//
+// Returns:
+//
+//	float64 - the distance between the strings.
+//
+// Example:
+//
+//	{{ jaroWinklerDistance "hello" "hello" }} // Output: 0
+func (fh *FunctionHandler) JaroWinklerDistance(a, b string) float64 {
+	return 1 - jaroWinkler([]rune(a), []rune(b))
+}
+
+// Soundex returns the American Soundex code of 'str', a letter followed by
+// three digits shared by names that sound alike. Diacritics are ignored and
+// other non ASCII letters are skipped.
+//
+// Parameters:
+//
+//	str string - the string to encode.
+//
+// Returns:
+//
+//	string - the Soundex code, or an empty string if 'str' has no letter.
+//
+// Example:
+//
+//	{{ "Robert" | soundex }} // Output: "R163"
+func (fh *FunctionHandler) Soundex(str string) string {
+	const codes = "01230120022455012623010202" // Digit of each letter from A to Z.
+
+	letters := phoneticLetters(str)
+	if len(letters) == 0 {
+		return ""
+	}
+
+	result := []byte{letters[0]}
+	last := codes[letters[0]-'A']
+	for _, letter := range letters[1:] {
+		code := codes[letter-'A']
+		switch {
+		case letter == 'H' || letter == 'W':
+			// H and W do not separate letters sharing the same code.
+			continue
+		case code == '0':
+			last = code
+		case code != last:
+			result = append(result, code)
+			last = code
+		}
+		if len(result) == 4 {
+			break
+		}
+	}
+
+	for len(result) < 4 {
+		result = append(result, '0')
+	}
+	return string(result)
+}
+
+// Metaphone returns the Metaphone key of 'str', a phonetic encoding of English
+// words more accurate than Soundex. Diacritics are ignored and other non ASCII
+// letters are skipped.
+//
+// Parameters:
+//
+//	str string - the string to encode.
+//
+// Returns:
+//
+//	string - the Metaphone key.
+//
+// Example:
+//
+//	{{ "Smith" | metaphone }} // Output: "SM0"
+func (fh *FunctionHandler) Metaphone(str string) string {
+	word := phoneticLetters(str)
+
+	switch {
+	case len(word) == 0:
+		return ""
+	case hasAnyPrefix(string(word), "KN", "GN", "PN", "AE", "WR"):
+		word = word[1:]
+	case word[0] == 'X':
+		word[0] = 'S'
+	case hasAnyPrefix(string(word), "WH"):
+		word = append([]byte{'W'}, word[2:]...)
+	}
+
+	at := func(i int) byte {
+		if i < 0 || i >= len(word) {
+			return 0
+		}
+		return word[i]
+	}
+	isVowel := func(c byte) bool { return c != 0 && strings.IndexByte("AEIOU", c) >= 0 }
+	isFrontVowel := func(c byte) bool { return c == 'E' || c == 'I' || c == 'Y' }
+
+	var key strings.Builder
+	for i, c := range word {
+		prev, next := at(i-1), at(i+1)
+		// Duplicate adjacent letters are encoded once, except C.
+		if c == prev && c != 'C' {
+			continue
+		}
+
+		switch c {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == 0 {
+				key.WriteByte(c)
+			}
+		case 'B':
+			if !(prev == 'M' && i == len(word)-1) {
+				key.WriteByte('B')
+			}
+		case 'C':
+			switch {
+			case next == 'I' && at(i+2) == 'A':
+				key.WriteByte('X')
+			case next == 'H' && prev == 'S':
+				key.WriteByte('K')
+			case next == 'H':
+				key.WriteByte('X')
+			case isFrontVowel(next):
+				if prev != 'S' {
+					key.WriteByte('S')
+				}
+			default:
+				key.WriteByte('K')
+			}
+		case 'D':
+			if next == 'G' && isFrontVowel(at(i+2)) {
+				key.WriteByte('J')
+			} else {
+				key.WriteByte('T')
+			}
+		case 'G':
+			switch {
+			case next == 'H' && !(i+2 >= len(word) || isVowel(at(i+2))):
+				// Silent in "GH" when not followed by a vowel, as in "night".
+			case next == 'N' && (i+2 == len(word) || string(word[i+1:]) == "NED"):
+				// Silent in a final "GN" or "GNED", as in "sign".
+			case isFrontVowel(next) && prev == 'D':
+				// Already encoded by the D in "DGE", "DGI" and "DGY".
+			case isFrontVowel(next) && prev != 'G':
+				key.WriteByte('J')
+			default:
+				key.WriteByte('K')
+			}
+		case 'H':
+			if isVowel(next) && strings.IndexByte("CSPTG", prev) < 0 {
+				key.WriteByte('H')
+			}
+		case 'K':
+			if prev != 'C' {
+				key.WriteByte('K')
+			}
+		case 'P':
+			if next == 'H' {
+				key.WriteByte('F')
+			} else {
+				key.WriteByte('P')
+			}
+		case 'Q':
+			key.WriteByte('K')
+		case 'S':
+			switch {
+			case next == 'H':
+				key.WriteByte('X')
+			case next == 'I' && (at(i+2) == 'O' || at(i+2) == 'A'):
+				key.WriteByte('X')
+			default:
+				key.WriteByte('S')
+			}
+		case 'T':
+			switch {
+			case next == 'I' && (at(i+2) == 'O' || at(i+2) == 'A'):
+				key.WriteByte('X')
+			case next == 'H':
+				key.WriteByte('0')
+			case next == 'C' && at(i+2) == 'H':
+				// Silent in "TCH", as in "watch".
+			default:
+				key.WriteByte('T')
+			}
+		case 'V':
+			key.WriteByte('F')
+		case 'W', 'Y':
+			if isVowel(next) {
+				key.WriteByte(c)
+			}
+		case 'X':
+			key.WriteString("KS")
+		case 'Z':
+			key.WriteByte('S')
+		default: // F, J, L, M, N, R
+			key.WriteByte(c)
+		}
+	}
+
+	return key.String()
+}
+
+// ClosestMatch returns the candidate most similar to 'str', as long as their
+// Damerau-Levenshtein similarity reaches 'threshold'. It is useful to suggest
+// a correction for a mistyped value.
+//
+// Parameters:
+//
+//	threshold any - the minimum similarity between 0 and 1, converted to a float.
+//	candidates any - the list of candidates, converted to strings.
+//	str string - the string to match.
+//
+// Returns:
+//
+//	string - the closest candidate, or an empty string if none is similar enough.
+//
+// Example:
+//
+//	{{ "prodution" | closestMatch 0.8 (list "production" "staging") }} // Output: "production"
+func (fh *FunctionHandler) ClosestMatch(threshold any, candidates any, str string) string {
+	minimum := cast.ToFloat64(threshold)
+	best, bestSimilarity := "", -1.0
+
+	for _, candidate := range fh.StrSlice(candidates) {
+		similarity := fh.DamerauLevenshteinSimilarity(str, candidate)
+		if similarity >= minimum && similarity > bestSimilarity {
+			best, bestSimilarity = candidate, similarity
+		}
+	}
+	return best
+}
+
+// levenshtein computes the Levenshtein distance using two rows of the
+// distance matrix.
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// damerauLevenshtein computes the unrestricted Damerau-Levenshtein distance,
+// allowing substrings to be edited after being transposed.
+func damerauLevenshtein(a, b []rune) int {
+	infinity := len(a) + len(b)
+	lastRow := make(map[rune]int)
+
+	// The matrix has an extra row and column holding the maximum distance.
+	d := make([][]int, len(a)+2)
+	for i := range d {
+		d[i] = make([]int, len(b)+2)
+	}
+	d[0][0] = infinity
+	for i := 0; i <= len(a); i++ {
+		d[i+1][0] = infinity
+		d[i+1][1] = i
+	}
+	for j := 0; j <= len(b); j++ {
+		d[0][j+1] = infinity
+		d[1][j+1] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		lastMatchColumn := 0
+		for j := 1; j <= len(b); j++ {
+			k := lastRow[b[j-1]]
+			l := lastMatchColumn
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+				lastMatchColumn = j
+			}
+			d[i+1][j+1] = min(
+				d[i][j]+cost,              // Substitution
+				d[i+1][j]+1,               // Insertion
+				d[i][j+1]+1,               // Deletion
+				d[k][l]+(i-k-1)+1+(j-l-1), // Transposition
+			)
+		}
+		lastRow[a[i-1]] = i
+	}
+
+	return d[len(a)+1][len(b)+1]
+}
+
+// distanceToSimilarity normalizes an edit distance by the length of the
+// longest string.
+func distanceToSimilarity(distance int, a, b []rune) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(distance)/float64(longest)
+}
+
+// jaroWinkler computes the Jaro similarity of the strings, boosted by up to
+// four characters of common prefix.
+func jaroWinkler(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	window := max(max(len(a), len(b))/2-1, 0)
+	matchedA := make([]bool, len(a))
+	matchedB := make([]bool, len(b))
+
+	matches := 0
+	for i := range a {
+		for j := max(0, i-window); j < min(len(b), i+window+1); j++ {
+			if !matchedB[j] && a[i] == b[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range a {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(a), len(b)) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// phoneticLetters returns the uppercase ASCII letters of 'str' once its
+// diacritics are removed, the input of the phonetic algorithms.
+func phoneticLetters(str string) []byte {
+	var letters []byte
+	for _, r := range strings.ToUpper(removeDiacritics(str)) {
+		if r >= 'A' && r <= 'Z' {
+			letters = append(letters, byte(r))
+		}
+	}
+	return letters
+}
+
+// hasAnyPrefix reports whether 'str' starts with one of the prefixes.
+func hasAnyPrefix(str string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(str, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffOptions configures how a unified diff is rendered.
+//
+// Example:
+//
+//	options := DiffOptions{Context: 1, FromLabel: "live", ToLabel: "desired"}
+//	fh.UnifiedDiffWithOptions(options, "a\nb\n", "a\nc\n")
+type DiffOptions struct {
+	Context   int    // Number of unchanged lines shown around each change.
+	FromLabel string // Label of the original text, written on the "---" line.
+	ToLabel   string // Label of the new text, written on the "+++" line.
+}
+
+// defaultDiffOptions are the options used by the unifiedDiff function, and
+// the base options overridden by the dict given to unifiedDiffWith.
+var defaultDiffOptions = DiffOptions{Context: 3, FromLabel: "a", ToLabel: "b"}
+
+// Operations reported by the line diff.
+const (
+	diffOpEqual  = "equal"
+	diffOpInsert = "insert"
+	diffOpDelete = "delete"
+)
+
+// lineEdit is a single step of the edit script turning a text into another.
+type lineEdit struct {
+	op   string
+	line string
+}
+
+// UnifiedDiff returns the unified diff between 'from' and 'to', with three
+// lines of context and the labels "a" and "b". Identical texts produce an
+// empty string.
+//
+// Parameters:
+//
+//	from string - the original text.
+//	to string - the new text.
+//
+// Returns:
+//
+//	string - the unified diff.
+//
+// Example:
+//
+//	{{ "replicas: 3\n" | unifiedDiff "replicas: 2\n" }} // Output: "--- a\n+++ b\n@@ -1 +1 @@\n-replicas: 2\n+replicas: 3"
+func (fh *FunctionHandler) UnifiedDiff(from string, to string) string {
+	return fh.UnifiedDiffWithOptions(defaultDiffOptions, from, to)
+}
+
+// UnifiedDiffWith returns the unified diff between 'from' and 'to' using the
+// options described by a dict. Invalid options produce an empty string.
+//
+// Parameters:
+//
+//	options any - a dict of options overriding the unifiedDiff defaults.
+//	from string - the original text.
+//	to string - the new text.
+//
+// Returns:
+//
+//	string - the unified diff.
+//
+// Example:
+//
+//	{{ unifiedDiffWith (dict "context" 0 "fromLabel" "live" "toLabel" "desired") "a\nb\n" "a\nc\n" }} // Output: "--- live\n+++ desired\n@@ -2 +2 @@\n-b\n+c"
+func (fh *FunctionHandler) UnifiedDiffWith(options any, from string, to string) string {
+	result, _ := fh.MustUnifiedDiffWith(options, from, to)
+	return result
+}
+
+// MustUnifiedDiffWith returns the unified diff between 'from' and 'to' using
+// the options described by a dict, returning an error if the options are
+// invalid.
+//
+// The dict accepts the keys "context" (default 3), "fromLabel" (default "a")
+// and "toLabel" (default "b").
+//
+// Parameters:
+//
+//	options any - a dict of options overriding the unifiedDiff defaults.
+//	from string - the original text.
+//	to string - the new text.
+//
+// Returns:
+//
+//	string - the unified diff.
+//	error - error if an option is unknown or has an invalid value.
+//
+// Example:
+//
+//	{{ mustUnifiedDiffWith (dict "context" 0) "a\nb\n" "a\nc\n" }} // Output: "--- a\n+++ b\n@@ -2 +2 @@\n-b\n+c", nil
+func (fh *FunctionHandler) MustUnifiedDiffWith(options any, from string, to string) (string, error) {
+	diffOptions, err := fh.parseDiffOptions(options)
+	if err != nil {
+		return "", err
+	}
+	return fh.UnifiedDiffWithOptions(diffOptions, from, to), nil
+}
+
+// UnifiedDiffWithOptions returns the unified diff between 'from' and 'to'
+// following 'options'. Lines missing their final newline are followed by the
+// "\ No newline at end of file" marker, as done by diff(1).
+//
+// Parameters:
+//
+//	options DiffOptions - the options to apply.
+//	from string - the original text.
+//	to string - the new text.
+//
+// Returns:
+//
+//	string - the unified diff, or an empty string if the texts are identical.
+//
+// Example:
+//
+//	fh.UnifiedDiffWithOptions(DiffOptions{FromLabel: "old", ToLabel: "new"}, "a", "b") // Output: "--- old\n+++ new\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+b\n\\ No newline at end of file"
+func (fh *FunctionHandler) UnifiedDiffWithOptions(options DiffOptions, from string, to string) string {
+	edits := diffLines(splitDiffLines(from), splitDiffLines(to))
+	context := max(options.Context, 0)
+
+	// fromLines[i] and toLines[i] count the lines of each text consumed before
+	// the i-th edit, giving the start of any hunk beginning at that edit.
+	fromLines := make([]int, len(edits)+1)
+	toLines := make([]int, len(edits)+1)
+	for i, edit := range edits {
+		fromLines[i+1], toLines[i+1] = fromLines[i], toLines[i]
+		if edit.op != diffOpInsert {
+			fromLines[i+1]++
+		}
+		if edit.op != diffOpDelete {
+			toLines[i+1]++
+		}
+	}
+
+	var diff strings.Builder
+	for i := 0; i < len(edits); {
+		if edits[i].op == diffOpEqual {
+			i++
+			continue
+		}
+
+		// Extend the hunk over every change separated from the previous one by
+		// at most twice the context, so their contexts would overlap.
+		lastChange := i
+		for j := i + 1; j < len(edits); j++ {
+			if edits[j].op != diffOpEqual {
+				lastChange = j
+			} else if j-lastChange > 2*context {
+				break
+			}
+		}
+		start := max(i-context, 0)
+		end := min(lastChange+context+1, len(edits))
+
+		if diff.Len() == 0 {
+			fmt.Fprintf(&diff, "--- %s\n+++ %s\n", options.FromLabel, options.ToLabel)
+		}
+		fmt.Fprintf(&diff, "@@ -%s +%s @@\n",
+			formatHunkRange(fromLines[start], fromLines[end]),
+			formatHunkRange(toLines[start], toLines[end]),
+		)
+		for _, edit := range edits[start:end] {
+			switch edit.op {
+			case diffOpInsert:
+				diff.WriteByte('+')
+			case diffOpDelete:
+				diff.WriteByte('-')
+			default:
+				diff.WriteByte(' ')
+			}
+			diff.WriteString(edit.line)
+			if !strings.HasSuffix(edit.line, "\n") {
+				diff.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+
+	return strings.TrimSuffix(diff.String(), "\n")
+}
+
+// LineDiff compares 'from' and 'to' line by line and returns the steps
+// turning the first into the second. Each step is a dict with the keys "op",
+// one of "equal", "insert" or "delete", and "line", the line without its
+// newline.
+//
+// Parameters:
+//
+//	from string - the original text.
+//	to string - the new text.
+//
+// Returns:
+//
+//	[]map[string]any - the list of steps, in the order of the texts.
+//
+// Example:
+//
+//	{{ range lineDiff "a\nb" "a\nc" }}{{ .op }}:{{ .line }} {{ end }} // Output: "equal:a delete:b insert:c "
+func (fh *FunctionHandler) LineDiff(from string, to string) []map[string]any {
+	edits := diffLines(splitDiffLines(from), splitDiffLines(to))
+
+	result := make([]map[string]any, 0, len(edits))
+	for _, edit := range edits {
+		result = append(result, map[string]any{
+			"op":   edit.op,
+			"line": strings.TrimSuffix(edit.line, "\n"),
+		})
+	}
+	return result
+}
+
+// parseDiffOptions reads the diff options from a DiffOptions value or from a
+// dict overriding the default options.
+func (fh *FunctionHandler) parseDiffOptions(options any) (DiffOptions, error) {
+	switch o := options.(type) {
+	case DiffOptions:
+		return o, nil
+	case map[string]any:
+		diffOptions := defaultDiffOptions
+		for key, value := range o {
+			switch key {
+			case "context":
+				context, err := cast.ToIntE(value)
+				if err != nil {
+					return DiffOptions{}, fmt.Errorf("invalid diff context: %w", err)
+				}
+				if context < 0 {
+					return DiffOptions{}, fmt.Errorf("invalid diff context: %d is negative", context)
+				}
+				diffOptions.Context = context
+			case "fromLabel":
+				diffOptions.FromLabel = fh.ToString(value)
+			case "toLabel":
+				diffOptions.ToLabel = fh.ToString(value)
+			default:
+				return DiffOptions{}, fmt.Errorf("unknown diff option: %s", key)
+			}
+		}
+		return diffOptions, nil
+	default:
+		return DiffOptions{}, fmt.Errorf("cannot use %T as diff options", options)
+	}
+}
+
+// splitDiffLines splits 'str' into lines keeping their trailing newline, so
+// a missing final newline shows up as a difference.
+func splitDiffLines(str string) []string {
+	if str == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(str, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// formatHunkRange formats the lines [start, end) of a hunk header the way
+// diff(1) does: the length is omitted when it is 1, and an empty range is
+// numbered after the line preceding it.
+func formatHunkRange(start, end int) string {
+	switch length := end - start; length {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, length)
+	}
+}
+
+// diffLines computes the shortest edit script turning 'a' into 'b' with the
+// linear space variant of the Myers algorithm, listing deletions before
+// insertions within a change.
+func diffLines(a, b []string) []lineEdit {
+	// Lines are compared through identifiers shared by identical lines.
+	ids := make(map[string]int)
+	identify := func(lines []string) []int {
+		result := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			result[i] = id
+		}
+		return result
+	}
+
+	size := len(a) + len(b) + 4
+	differ := &lineDiffer{
+		a:        identify(a),
+		b:        identify(b),
+		deleted:  make([]bool, len(a)),
+		inserted: make([]bool, len(b)),
+		forward:  make([]int, size),
+		backward: make([]int, size),
+	}
+	differ.compare(0, len(a), 0, len(b))
+
+	edits := make([]lineEdit, 0, len(a)+len(b))
+	for x, y := 0, 0; x < len(a) || y < len(b); {
+		switch {
+		case x < len(a) && differ.deleted[x]:
+			edits = append(edits, lineEdit{op: diffOpDelete, line: a[x]})
+			x++
+		case y < len(b) && differ.inserted[y]:
+			edits = append(edits, lineEdit{op: diffOpInsert, line: b[y]})
+			y++
+		default:
+			edits = append(edits, lineEdit{op: diffOpEqual, line: a[x]})
+			x++
+			y++
+		}
+	}
+	return edits
+}
+
+// lineDiffer holds the state of a line diff: the lines to compare, the lines
+// found to be deleted or inserted, and the furthest reaching paths of the
+// forward and backward searches, reused by every step of the recursion.
+type lineDiffer struct {
+	a, b              []int
+	deleted, inserted []bool
+	forward, backward []int
+}
+
+// compare marks the lines deleted from a[aLo:aHi] and inserted in b[bLo:bHi],
+// splitting the problem around the middle snake of a shortest edit script.
+func (d *lineDiffer) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.inserted[y] = true
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.deleted[x] = true
+		}
+	default:
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		d.compare(u, aHi, v, bHi)
+	}
+}
+
+// middleSnake runs the Myers search from both ends of a[aLo:aHi] and
+// b[bLo:bHi] until the paths overlap, and returns the start (x, y) and the
+// end (u, v) of the diagonal where they meet, which lies on a shortest edit
+// script.
+func (d *lineDiffer) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+
+	// forward[offset+k] is the furthest x reached on the diagonal x-y=k from
+	// the start, backward[offset+k] the same from the end, on reversed texts.
+	forward, backward := d.forward, d.backward
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	for depth := 0; depth <= maxD; depth++ {
+		for k := -depth; k <= depth; k += 2 {
+			x := forward[offset+k-1] + 1
+			if k == -depth || k != depth && forward[offset+k-1] < forward[offset+k+1] {
+				x = forward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			// The diagonal k from the start is the diagonal delta-k from the end.
+			if odd && delta-k >= -(depth-1) && delta-k <= depth-1 && x+backward[offset+delta-k] >= n {
+				return aLo + startX, bLo + startY, aLo + x, bLo + y
+			}
+		}
+
+		for k := -depth; k <= depth; k += 2 {
+			x := backward[offset+k-1] + 1
+			if k == -depth || k != depth && backward[offset+k-1] < backward[offset+k+1] {
+				x = backward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+
+			if !odd && delta-k >= -depth && delta-k <= depth && x+forward[offset+delta-k] >= n {
+				return aHi - x, bHi - y, aHi - startX, bHi - startY
+			}
+		}
+	}
+
+	// The searches always meet within maxD steps, this replacement of every
+	// line is only a safe fallback.
+	return aHi, bLo, aHi, bLo
+}
+
+// ansiReset is the escape sequence resetting every text attribute.
+const ansiReset = "\x1b[0m"
+
+// ansiAttributes maps the text attributes accepted in ANSI style specs to
+// their SGR parameter.
+var ansiAttributes = map[string]string{
+	"bold":          "1",
+	"dim":           "2",
+	"faint":         "2",
+	"italic":        "3",
+	"underline":     "4",
+	"blink":         "5",
+	"reverse":       "7",
+	"hidden":        "8",
+	"strikethrough": "9",
+}
+
+// ansiColors maps the named colors to their foreground SGR parameter, the
+// background parameter being 10 more.
+var ansiColors = map[string]int{
+	"black": 30, "red": 31, "green": 32, "yellow": 33,
+	"blue": 34, "magenta": 35, "cyan": 36, "white": 37,
+	"gray": 90, "grey": 90, "brightblack": 90, "brightred": 91,
+	"brightgreen": 92, "brightyellow": 93, "brightblue": 94,
+	"brightmagenta": 95, "brightcyan": 96, "brightwhite": 97,
+}
+
+// AnsiStyle styles 'str' with ANSI escape sequences following 'spec', a space
+// separated list of attributes and colors. Attributes are bold, dim, italic,
+// underline, blink, reverse, hidden and strikethrough. A color sets the
+// foreground, or the background when preceded by "on". Colors are either
+// named ("red", "brightBlue", "gray"), a 256-color palette index ("208") or
+// a truecolor hex code ("#ff8800" or "#f80"). Invalid specs produce an empty
+// string.
+//
+// Styling is disabled, and 'str' returned unchanged, when the NO_COLOR
+// environment variable is set to a non-empty value.
+//
+// Parameters:
+//
+//	spec string - the attributes and colors to apply.
+//	str string - the string to style.
+//
+// Returns:
+//
+//	string - the styled string.
+//
+// Example:
+//
+//	{{ "FAIL" | ansiStyle "bold white on red" }} // Output: "\x1b[1;37;41mFAIL\x1b[0m"
+func (fh *FunctionHandler) AnsiStyle(spec string, str string) string {
+	result, _ := fh.MustAnsiStyle(spec, str)
+	return result
+}
+
+// AnsiColor sets the foreground color of 'str' with ANSI escape sequences.
+// The color is named, a 256-color palette index or a truecolor hex code, as
+// in ansiStyle. Invalid colors produce an empty string.
+//
+// Parameters:
+//
+//	color any - the color to apply.
+//	str string - the string to color.
+//
+// Returns:
+//
+//	string - the colored string.
+//
+// Example:
+//
+//	{{ "ok" | ansiColor "green" }} // Output: "\x1b[32mok\x1b[0m"
+//	{{ "warn" | ansiColor 208 }} // Output: "\x1b[38;5;208mwarn\x1b[0m"
+func (fh *FunctionHandler) AnsiColor(color any, str string) string {
+	result, _ := fh.MustAnsiColor(color, str)
+	return result
+}
+
+// AnsiBgColor sets the background color of 'str' with ANSI escape sequences.
+// The color is named, a 256-color palette index or a truecolor hex code, as
+// in ansiStyle. Invalid colors produce an empty string.
+//
+// Parameters:
+//
+//	color any - the color to apply.
+//	str string - the string to color.
+//
+// Returns:
+//
+//	string - the colored string.
+//
+// Example:
+//
+//	{{ "note" | ansiBgColor "#003366" }} // Output: "\x1b[48;2;0;51;102mnote\x1b[0m"
+func (fh *FunctionHandler) AnsiBgColor(color any, str string) string {
+	result, _ := fh.MustAnsiBgColor(color, str)
+	return result
+}
+
+// MustAnsiStyle styles 'str' with ANSI escape sequences following 'spec', as
+// ansiStyle does, returning an error if the spec is invalid.
+//
+// Parameters:
+//
+//	spec string - the attributes and colors to apply.
+//	str string - the string to style.
+//
+// Returns:
+//
+//	string - the styled string.
+//	error - error if an attribute or a color is unknown.
+//
+// Example:
+//
+//	{{ "done" | mustAnsiStyle "italic #00ff00" }} // Output: "\x1b[3;38;2;0;255;0mdone\x1b[0m", nil
+func (fh *FunctionHandler) MustAnsiStyle(spec string, str string) (string, error) {
+	var params []string
+	background := false
+	for _, token := range strings.Fields(spec) {
+		if strings.EqualFold(token, "on") {
+			background = true
+			continue
+		}
+		if param, ok := ansiAttributes[strings.ToLower(token)]; ok && !background {
+			params = append(params, param)
+			continue
+		}
+
+		param, err := parseAnsiColor(token, background)
+		if err != nil {
+			return "", err
+		}
+		params = append(params, param)
+		background = false
+	}
+	if background {
+		return "", fmt.Errorf("missing background color in ANSI style: %q", spec)
+	}
+
+	return fh.ansiApply(params, str), nil
+}
+
+// MustAnsiColor sets the foreground color of 'str' with ANSI escape
+// sequences, returning an error if the color is invalid.
+//
+// Parameters:
+//
+//	color any - the color to apply.
+//	str string - the string to color.
+//
+// Returns:
+//
+//	string - the colored string.
+//	error - error if the color is unknown.
+//
+// Example:
+//
+//	{{ "ok" | mustAnsiColor "brightGreen" }} // Output: "\x1b[92mok\x1b[0m", nil
+func (fh *FunctionHandler) MustAnsiColor(color any, str string) (string, error) {
+	param, err := parseAnsiColor(fh.ToString(color), false)
+	if err != nil {
+		return "", err
+	}
+	return fh.ansiApply([]string{param}, str), nil
+}
+
+// MustAnsiBgColor sets the background color of 'str' with ANSI escape
+// sequences, returning an error if the color is invalid.
+//
+// Parameters:
+//
+//	color any - the color to apply.
+//	str string - the string to color.
+//
+// Returns:
+//
+//	string - the colored string.
+//	error - error if the color is unknown.
+//
+// Example:
+//
+//	{{ "note" | mustAnsiBgColor "blue" }} // Output: "\x1b[44mnote\x1b[0m", nil
+func (fh *FunctionHandler) MustAnsiBgColor(color any, str string) (string, error) {
+	param, err := parseAnsiColor(fh.ToString(color), true)
+	if err != nil {
+		return "", err
+	}
+	return fh.ansiApply([]string{param}, str), nil
+}
+
+// StripAnsi removes every ANSI escape sequence from 'str'.
+//
+// Parameters:
+//
+//	str string - the string to clean.
+//
+// Returns:
+//
+//	string - the string without escape sequences.
+//
+// Example:
+//
+//	{{ "\x1b[1;31mFAIL\x1b[0m" | stripAnsi }} // Output: "FAIL"
+func (fh *FunctionHandler) StripAnsi(str string) string {
+	if !strings.Contains(str, "\x1b") {
+		return str
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(str); {
+		if length := ansiSequenceLength(str[i:]); length > 0 {
+			i += length
+			continue
+		}
+		builder.WriteByte(str[i])
+		i++
+	}
+	return builder.String()
+}
+
+// ansiApply wraps 'str' between the SGR sequence made of 'params' and a
+// reset. Resets already contained in 'str', closing nested styles, are
+// followed by the sequence again so the style applies up to the end.
+func (fh *FunctionHandler) ansiApply(params []string, str string) string {
+	if len(params) == 0 || fh.colorDisabled() {
+		return str
+	}
+
+	sequence := "\x1b[" + strings.Join(params, ";") + "m"
+	str = strings.ReplaceAll(str, ansiReset, ansiReset+sequence)
+	return sequence + str + ansiReset
+}
+
+// colorDisabled reports whether ANSI styling is turned off by the NO_COLOR
+// environment variable, following https://no-color.org.
+func (fh *FunctionHandler) colorDisabled() bool {
+	value, _ := fh.lookupEnv("NO_COLOR")
+	return value != ""
+}
+
+// parseAnsiColor returns the SGR parameter setting the foreground or the
+// background to 'color'.
+func parseAnsiColor(color string, background bool) (string, error) {
+	base := 38
+	if background {
+		base = 48
+	}
+
+	switch {
+	case strings.HasPrefix(color, "#"):
+		hex := color[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 6 || err != nil {
+			return "", fmt.Errorf("invalid hex color: %q", color)
+		}
+		return fmt.Sprintf("%d;2;%d;%d;%d", base, rgb>>16, rgb>>8&0xFF, rgb&0xFF), nil
+	case color != "" && strings.Trim(color, "0123456789") == "":
+		index, err := strconv.Atoi(color)
+		if err != nil || index > 255 {
+			return "", fmt.Errorf("invalid 256-color index: %q", color)
+		}
+		return fmt.Sprintf("%d;5;%d", base, index), nil
+	}
+
+	name := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(color))
+	code, ok := ansiColors[name]
+	if !ok {
+		return "", fmt.Errorf("unknown ANSI color: %q", color)
+	}
+	if background {
+		code += 10
+	}
+	return strconv.Itoa(code), nil
+}
+
+// ansiSequenceLength returns the length in bytes of the ANSI escape sequence
+// starting 'str', or 0 if 'str' does not start with one. Control sequences
+// (ESC [), operating system commands (ESC ], terminated by BEL or ESC \) and
+// two-byte escapes are recognized.
+func ansiSequenceLength(str string) int {
+	if len(str) < 2 || str[0] != '\x1b' {
+		return 0
+	}
+
+	switch str[1] {
+	case '[':
+		for i := 2; i < len(str); i++ {
+			if str[i] >= 0x40 && str[i] <= 0x7E {
+				return i + 1
+			}
+			if str[i] < 0x20 || str[i] > 0x3F {
+				return 0
+			}
+		}
+		return 0
+	case ']':
+		for i := 2; i < len(str); i++ {
+			if str[i] == '\a' {
+				return i + 1
+			}
+			if str[i] == '\x1b' && i+1 < len(str) && str[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return 0
+	default:
+		if str[1] >= 0x40 && str[1] <= 0x5F {
+			return 2
+		}
+		return 0
+	}
+}
+
+// isAnsiSequence reports whether 'str' is exactly one ANSI escape sequence.
+func isAnsiSequence(str string) bool {
+	return str != "" && ansiSequenceLength(str) == len(str)
+}
+
+// truncAnsi truncates 'str' like Trunc, counting only the bytes outside of ANSI
+// escape sequences and keeping every escape sequence.
+func truncAnsi(count int, str string) string {
+	if count < 0 {
+		return substringAnsi(count, math.MaxInt, str)
+	}
+	return substringAnsi(0, count, str)
+}
+
+// substringAnsi extracts the bytes of 'str' from 'start' to 'end' like
+// Substring, counting only the bytes outside of ANSI escape sequences and
+// keeping every escape sequence.
+func substringAnsi(start, end int, str string) string {
+	visible := 0
+	for i := 0; i < len(str); i++ {
+		if length := ansiSequenceLength(str[i:]); length > 0 {
+			i += length - 1
+			continue
+		}
+		visible++
+	}
+	if start < 0 {
+		start += visible
+	}
+	if end < 0 {
+		end += visible
+	}
+
+	var builder strings.Builder
+	position := 0
+	for i := 0; i < len(str); {
+		if length := ansiSequenceLength(str[i:]); length > 0 {
+			builder.WriteString(str[i : i+length])
+			i += length
+			continue
+		}
+		if position >= start && position < end {
+			builder.WriteByte(str[i])
+		}
+		position++
+		i++
+	}
+	return builder.String()
+}
+
+// ansiRunes splits 'str' into runes, keeping each ANSI escape sequence whole.
+func ansiRunes(str string) []string {
+	var parts []string
+	for i := 0; i < len(str); {
+		length := ansiSequenceLength(str[i:])
+		if length == 0 {
+			_, length = utf8.DecodeRuneInString(str[i:])
+		}
+		parts = append(parts, str[i:i+length])
+		i += length
+	}
+	return parts
+}
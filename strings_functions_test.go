@@ -1,8 +1,12 @@
 package sprout
 
 import (
+	"fmt"
 	mathrand "math/rand"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNoSpace(t *testing.T) {
@@ -276,6 +280,119 @@ func TestSquote(t *testing.T) {
 	runTestCases(t, tests)
 }
 
+func TestShellQuote(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | shellQuote }}`, "''", nil},
+		{"TestNil", `{{ shellQuote .nil }}`, "", map[string]any{"nil": nil}},
+		{"TestSafe", `{{ "./bin/app-v1.2_linux" | shellQuote }}`, "./bin/app-v1.2_linux", nil},
+		{"TestSpace", `{{ "foo bar" | shellQuote }}`, "'foo bar'", nil},
+		{"TestVariable", `{{ "$HOME" | shellQuote }}`, "'$HOME'", nil},
+		{"TestSingleQuote", `{{ "it's" | shellQuote }}`, `'it'"'"'s'`, nil},
+		{"TestSubstitution", `{{ "$(rm -rf /); echo" | shellQuote }}`, "'$(rm -rf /); echo'", nil},
+		{"TestNewline", `{{ "foo\nbar" | shellQuote }}`, "'foo\nbar'", nil},
+		{"TestMultiple", `{{ shellQuote "echo" "it's $HOME" 42 }}`, `echo 'it'"'"'s $HOME' 42`, nil},
+		{"TestSkipNil", `{{ shellQuote .nil "a b" }}`, "'a b'", map[string]any{"nil": nil}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestPowerShellQuote(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | powershellQuote }}`, "''", nil},
+		{"TestNil", `{{ powershellQuote .nil }}`, "", map[string]any{"nil": nil}},
+		{"TestSimple", `{{ "foo" | powershellQuote }}`, "'foo'", nil},
+		{"TestVariable", `{{ "$env:PATH" | powershellQuote }}`, "'$env:PATH'", nil},
+		{"TestSingleQuote", `{{ "it's" | powershellQuote }}`, "'it''s'", nil},
+		{"TestTypographicQuote", `{{ "it’s" | powershellQuote }}`, "'it’’s'", nil},
+		{"TestBacktick", "{{ \"a`nb\" | powershellQuote }}", "'a`nb'", nil},
+		{"TestMultiple", `{{ powershellQuote "Write-Host" "it's" }}`, "'Write-Host' 'it''s'", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestGoQuote(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | goQuote }}`, `""`, nil},
+		{"TestNil", `{{ goQuote .nil }}`, "", map[string]any{"nil": nil}},
+		{"TestEscapes", `{{ "tab\there \"quoted\"\n" | goQuote }}`, `"tab\there \"quoted\"\n"`, nil},
+		{"TestControl", `{{ .V | goQuote }}`, `"a\x00b"`, map[string]any{"V": "a\x00b"}},
+		{"TestUnicode", `{{ "héllo 👍" | goQuote }}`, `"héllo 👍"`, nil},
+		{"TestMultiple", `{{ goQuote "a" 1 true }}`, `"a" "1" "true"`, nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestJsQuote(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | jsQuote }}`, `""`, nil},
+		{"TestNil", `{{ jsQuote .nil }}`, "", map[string]any{"nil": nil}},
+		{"TestQuotes", `{{ .V | jsQuote }}`, `"it\'s \"here\""`, map[string]any{"V": `it's "here"`}},
+		{"TestScript", `{{ "</script>" | jsQuote }}`, `"\u003C/script\u003E"`, nil},
+		{"TestNewline", `{{ "a\nb" | jsQuote }}`, `"a\u000Ab"`, nil},
+		{"TestLineSeparator", `{{ .V | jsQuote }}`, `"a\u2028b"`, map[string]any{"V": "a\u2028b"}},
+		{"TestBackslash", `{{ "a\\b" | jsQuote }}`, `"a\\b"`, nil},
+		{"TestMultiple", `{{ jsQuote "a" 1 }}`, `"a" "1"`, nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestSqlQuote(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | sqlQuote }}`, "''", nil},
+		{"TestNil", `{{ sqlQuote .nil }}`, "", map[string]any{"nil": nil}},
+		{"TestSingleQuote", `{{ "O'Brien" | sqlQuote }}`, "'O''Brien'", nil},
+		{"TestInjection", `{{ "'; DROP TABLE users; --" | sqlQuote }}`, "'''; DROP TABLE users; --'", nil},
+		{"TestBackslash", `{{ "a\\b" | sqlQuote }}`, `'a\b'`, nil},
+		{"TestMultiple", `{{ sqlQuote "a" 1 }}`, "'a' '1'", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestCsvQuote(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | csvQuote }}`, "", nil},
+		{"TestNil", `{{ csvQuote .nil }}`, "", map[string]any{"nil": nil}},
+		{"TestSimple", `{{ "foo" | csvQuote }}`, "foo", nil},
+		{"TestComma", `{{ "Doe, John" | csvQuote }}`, `"Doe, John"`, nil},
+		{"TestDoubleQuote", `{{ .V | csvQuote }}`, `"say ""hi"""`, map[string]any{"V": `say "hi"`}},
+		{"TestNewline", `{{ "a\nb" | csvQuote }}`, "\"a\nb\"", nil},
+		{"TestLeadingSpace", `{{ " a" | csvQuote }}`, `" a"`, nil},
+		{"TestRecord", `{{ csvQuote "name" "Doe, John" "" 42 }}`, `name,"Doe, John",,42`, nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestXmlEscape(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | xmlEscape }}`, "", nil},
+		{"TestNil", `{{ xmlEscape .nil }}`, "", map[string]any{"nil": nil}},
+		{"TestSpecialCharacters", `{{ .V | xmlEscape }}`, "Fish &amp; Chips &lt;&#34;fresh&#34;&gt; &#39;now&#39;", map[string]any{"V": `Fish & Chips <"fresh"> 'now'`}},
+		{"TestNewline", `{{ "a\nb" | xmlEscape }}`, "a&#xA;b", nil},
+		{"TestUnicode", `{{ "héllo" | xmlEscape }}`, "héllo", nil},
+		{"TestMultiple", `{{ xmlEscape "a&b" "<c>" }}`, "a&amp;b &lt;c&gt;", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestHtmlEscape(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | htmlEscape }}`, "", nil},
+		{"TestNil", `{{ htmlEscape .nil }}`, "", map[string]any{"nil": nil}},
+		{"TestSpecialCharacters", `{{ .V | htmlEscape }}`, "&lt;b&gt;Tom &amp; Jerry&#39;s &#34;show&#34;&lt;/b&gt;", map[string]any{"V": `<b>Tom & Jerry's "show"</b>`}},
+		{"TestNewline", `{{ "a\nb" | htmlEscape }}`, "a\nb", nil},
+		{"TestMultiple", `{{ htmlEscape "a&b" "<c>" }}`, "a&amp;b &lt;c&gt;", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
 func TestToCamelCase(t *testing.T) {
 	var tests = testCases{
 		{"TestEmpty", `{{ "" | toCamelCase }}`, "", nil},
@@ -389,6 +506,68 @@ func TestToSnakeCase(t *testing.T) {
 	runTestCases(t, tests)
 }
 
+func TestToCase(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | toCase "snake" }}`, "", nil},
+		{"TestCamel", `{{ "HTTPServerID" | toCase "camel" }}`, "httpServerId", nil},
+		{"TestPascal", `{{ "http_server_id" | toCase "pascal" }}`, "HttpServerId", nil},
+		{"TestSnake", `{{ "HTTPServerID" | toCase "snake" }}`, "http_server_id", nil},
+		{"TestKebab", `{{ "myXMLParser" | toCase "kebab" }}`, "my-xml-parser", nil},
+		{"TestConstant", `{{ "foo.bar-baz" | toCase "constant" }}`, "FOO_BAR_BAZ", nil},
+		{"TestDot", `{{ "fooBar" | toCase "dot" }}`, "foo.bar", nil},
+		{"TestPath", `{{ "fooBar" | toCase "path" }}`, "foo/bar", nil},
+		{"TestTitle", `{{ "foo_bar" | toCase "title" }}`, "Foo Bar", nil},
+		{"TestTrain", `{{ "foo_bar" | toCase "train" }}`, "Foo-Bar", nil},
+		{"TestUnicode", `{{ "crème brûlée" | toCase "pascal" }}`, "CrèmeBrûlée", nil},
+		{"TestNumberBoundaryNone", `{{ "v2Api http2xx" | toCase "snake" }}`, "v2_api_http2xx", nil},
+		{"TestNumberBoundaryBefore", `{{ "v2Api http2xx" | toCase (dict "separator" "_" "firstWord" "lower" "otherWords" "lower" "numberBoundary" "before") }}`, "v_2_api_http_2xx", nil},
+		{"TestNumberBoundaryBoth", `{{ "v2Api http2xx" | toCase (dict "separator" "_" "firstWord" "lower" "otherWords" "lower" "numberBoundary" "both") }}`, "v_2_api_http_2_xx", nil},
+		{"TestCustomSeparator", `{{ "fooBarBaz" | toCase (dict "separator" "::" "otherWords" "upper") }}`, "foo::BAR::BAZ", nil},
+		{"TestUnknownStyle", `{{ "fooBar" | toCase "unknown" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestToCaseWithAcronyms(t *testing.T) {
+	var tests = testCases{
+		{"TestCamel", `{{ "HTTPServerID" | toCase "camel" }}`, "httpServerID", nil},
+		{"TestPascal", `{{ "http_server_id" | toCase "pascal" }}`, "HTTPServerID", nil},
+		{"TestConsecutiveAcronyms", `{{ "XMLHTTPRequest" | toCase "snake" }}`, "xml_http_request", nil},
+		{"TestMixedCaseAcronym", `{{ "GraphQLServer" | toCase "kebab" }}`, "graphql-server", nil},
+		{"TestMixedCaseAcronymTitle", `{{ "graphql_api" | toCase "pascal" }}`, "GraphQLAPI", nil},
+		{"TestLongerUppercaseRun", `{{ "IDEConfig" | toCase "snake" }}`, "ide_config", nil},
+		{"TestTitle", `{{ "user_id" | toCase "title" }}`, "User ID", nil},
+		{"TestCustomStyle", `{{ "serverUrl" | toCase "env" }}`, "SERVER__URL", nil},
+		{"TestLegacyPresetUnchanged", `{{ "http_server_id" | toPascalCase }}`, "HttpServerId", nil},
+	}
+
+	handler := NewFunctionHandler(
+		WithAcronyms("ID", "HTTP", "XML", "API", "GraphQL"),
+		WithCaseStyle("env", CaseStyle{Separator: "__", FirstWord: WordCaseUpper, OtherWords: WordCaseUpper}),
+	)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmplResponse, err := runTemplate(t, handler, test.input, test.data)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, tmplResponse)
+		})
+	}
+}
+
+func TestMustToCase(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "fooBar" | mustToCase "snake" }}`, "foo_bar", nil}, ""},
+		{testCase{"TestUnknownStyle", `{{ "fooBar" | mustToCase "unknown" }}`, "", nil}, "unknown case style: unknown"},
+		{testCase{"TestUnknownKey", `{{ "fooBar" | mustToCase (dict "sep" "_") }}`, "", nil}, "unknown case style key: sep"},
+		{testCase{"TestUnknownWordCase", `{{ "fooBar" | mustToCase (dict "firstWord" "shout") }}`, "", nil}, "unknown word case: shout"},
+		{testCase{"TestUnknownNumberBoundary", `{{ "fooBar" | mustToCase (dict "numberBoundary" "after") }}`, "", nil}, "unknown number boundary: after"},
+		{testCase{"TestInvalidType", `{{ "fooBar" | mustToCase 42 }}`, "", nil}, "cannot use int as case style"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
 func TestToTitleCase(t *testing.T) {
 	var tests = testCases{
 		{"TestEmpty", `{{ "" | toTitleCase }}`, "", nil},
@@ -501,3 +680,714 @@ func TestSeq(t *testing.T) {
 
 	runTestCases(t, tests)
 }
+
+func TestGraphemeCount(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | graphemeCount }}`, "0", nil},
+		{"TestASCII", `{{ "hello" | graphemeCount }}`, "5", nil},
+		{"TestCombiningMarks", `{{ .V | graphemeCount }}`, "3", map[string]any{"V": "e\u0301te\u0301"}},
+		{"TestZWJSequence", `{{ .V | graphemeCount }}`, "2", map[string]any{"V": "\U0001F469\u200D\U0001F469\u200D\U0001F467!"}},
+		{"TestSkinTone", `{{ .V | graphemeCount }}`, "1", map[string]any{"V": "\U0001F44D\U0001F3FD"}},
+		{"TestFlags", `{{ "🇫🇷🇩🇪" | graphemeCount }}`, "2", nil},
+		{"TestHangulJamo", `{{ .V | graphemeCount }}`, "1", map[string]any{"V": "\u1112\u1161\u11AB"}},
+		{"TestCRLF", `{{ .V | graphemeCount }}`, "3", map[string]any{"V": "a\r\nb"}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestDisplayWidth(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | displayWidth }}`, "0", nil},
+		{"TestASCII", `{{ "hello" | displayWidth }}`, "5", nil},
+		{"TestCJK", `{{ "日本語" | displayWidth }}`, "6", nil},
+		{"TestFullwidth", `{{ "ＡＢ" | displayWidth }}`, "4", nil},
+		{"TestHalfwidthKatakana", `{{ "ｶﾀｶﾅ" | displayWidth }}`, "4", nil},
+		{"TestCombiningMarks", `{{ .V | displayWidth }}`, "2", map[string]any{"V": "e\u0301e\u0301"}},
+		{"TestEmoji", `{{ .V | displayWidth }}`, "2", map[string]any{"V": "\U0001F469\u200D\U0001F469\u200D\U0001F467"}},
+		{"TestEmojiPresentation", `{{ .V | displayWidth }}`, "2", map[string]any{"V": "\u2764\uFE0F"}},
+		{"TestFlag", `{{ "🇫🇷" | displayWidth }}`, "2", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestTruncGraphemes(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | truncGraphemes 3 }}`, "", nil},
+		{"TestShorter", `{{ "foo" | truncGraphemes 5 }}`, "foo", nil},
+		{"TestCombiningMarks", `{{ .V | truncGraphemes 2 }}`, "e\u0301t", map[string]any{"V": "e\u0301te\u0301"}},
+		{"TestZWJSequence", `{{ .V | truncGraphemes 1 }}`, "\U0001F469\u200D\U0001F467", map[string]any{"V": "\U0001F469\u200D\U0001F467 family"}},
+		{"TestNegative", `{{ "🇫🇷🇩🇪🇮🇹" | truncGraphemes -2 }}`, "🇩🇪🇮🇹", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestTruncWidth(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | truncWidth 3 }}`, "", nil},
+		{"TestASCII", `{{ "Hello World" | truncWidth 5 }}`, "Hello", nil},
+		{"TestCJK", `{{ "日本語テキスト" | truncWidth 5 }}`, "日本", nil},
+		{"TestMixed", `{{ "ab日本" | truncWidth 4 }}`, "ab日", nil},
+		{"TestNegative", `{{ "日本語" | truncWidth -4 }}`, "本語", nil},
+		{"TestNegativeOdd", `{{ "日本語" | truncWidth -3 }}`, "語", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestEllipsisGraphemes(t *testing.T) {
+	var tests = testCases{
+		{"TestShorter", `{{ "foo" | ellipsisGraphemes 5 }}`, "foo", nil},
+		{"TestTooSmall", `{{ "foobar" | ellipsisGraphemes 3 }}`, "foobar", nil},
+		{"TestCombiningMarks", `{{ .V | ellipsisGraphemes 6 }}`, "Cre\u0300...", map[string]any{"V": "Cre\u0300me brûlée"}},
+		{"TestEmoji", `{{ "🇫🇷🇩🇪🇮🇹🇪🇸🇵🇹" | ellipsisGraphemes 4 }}`, "🇫🇷...", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestEllipsisWidth(t *testing.T) {
+	var tests = testCases{
+		{"TestShorter", `{{ "日本" | ellipsisWidth 4 }}`, "日本", nil},
+		{"TestCJK", `{{ "日本語テキスト" | ellipsisWidth 8 }}`, "日本...", nil},
+		{"TestCJKOdd", `{{ "日本語テキスト" | ellipsisWidth 9 }}`, "日本語...", nil},
+		{"TestASCII", `{{ "Hello World" | ellipsisWidth 8 }}`, "Hello...", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestEllipsisBothGraphemes(t *testing.T) {
+	var tests = testCases{
+		{"TestShorter", `{{ "foo" | ellipsisBothGraphemes 0 5 }}`, "foo", nil},
+		{"TestTooSmall", `{{ "foobarbaz" | ellipsisBothGraphemes 2 6 }}`, "foobarbaz", nil},
+		{"TestTruncate", `{{ "foooboooooo" | ellipsisBothGraphemes 4 9 }}`, "...boo...", nil},
+		{"TestCombiningMarks", `{{ .V | ellipsisBothGraphemes 6 12 }}`, "...bru\u0302le\u0301e...", map[string]any{"V": "Cre\u0300me bru\u0302le\u0301e au cafe\u0301"}},
+		{"TestEmoji", `{{ "🇫🇷🇩🇪🇮🇹🇪🇸🇵🇹🇬🇧🇯🇵🇨🇭" | ellipsisBothGraphemes 6 7 }}`, "...🇯🇵...", nil},
+		{"TestEndReached", `{{ "🇫🇷🇩🇪🇮🇹🇪🇸🇵🇹🇬🇧🇯🇵🇨🇭" | ellipsisBothGraphemes 7 7 }}`, "...🇨🇭", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestEllipsisBothWidth(t *testing.T) {
+	var tests = testCases{
+		{"TestShorter", `{{ "日本" | ellipsisBothWidth 0 4 }}`, "日本", nil},
+		{"TestCJK", `{{ "日本語のテキスト" | ellipsisBothWidth 4 10 }}`, "...語の...", nil},
+		{"TestCrossingBound", `{{ "日本語のテキスト" | ellipsisBothWidth 3 10 }}`, "...語...", nil},
+		{"TestASCII", `{{ "foooboooooo" | ellipsisBothWidth 4 9 }}`, "...boo...", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestSubstringGraphemes(t *testing.T) {
+	var tests = testCases{
+		{"TestFlags", `{{ "🇫🇷🇩🇪🇮🇹" | substrGraphemes 1 2 }}`, "🇩🇪", nil},
+		{"TestNegative", `{{ "🇫🇷🇩🇪🇮🇹" | substrGraphemes -2 -1 }}`, "🇩🇪", nil},
+		{"TestCombiningMarks", `{{ .V | substrGraphemes 1 3 }}`, "te\u0301", map[string]any{"V": "e\u0301te\u0301"}},
+		{"TestOutOfRange", `{{ "abc" | substrGraphemes 1 10 }}`, "bc", nil},
+		{"TestInverted", `{{ "abc" | substrGraphemes 2 1 }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestSubstringWidth(t *testing.T) {
+	var tests = testCases{
+		{"TestCJK", `{{ "ab日本語" | substrWidth 2 6 }}`, "日本", nil},
+		{"TestCrossingBound", `{{ "ab日本語" | substrWidth 3 8 }}`, "本語", nil},
+		{"TestNegative", `{{ "日本語" | substrWidth -4 6 }}`, "本語", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestWrapGraphemes(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | wrapGraphemes 10 }}`, "", nil},
+		{"TestCombiningMarks", `{{ .V | wrapGraphemes 6 }}`, "Cre\u0300me\nbrûlée", map[string]any{"V": "Cre\u0300me brûlée"}},
+		{"TestLongWord", `{{ "🇫🇷🇩🇪🇮🇹" | wrapGraphemes 2 }}`, "🇫🇷🇩🇪\n🇮🇹", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestWrapWidth(t *testing.T) {
+	var tests = testCases{
+		{"TestASCII", `{{ "This is a long string" | wrapWidth 10 }}`, "This is a\nlong\nstring", nil},
+		{"TestCJK", `{{ "日本語のテキスト" | wrapWidth 6 }}`, "日本語\nのテキ\nスト", nil},
+		{"TestCJKOdd", `{{ "日本語" | wrapWidth 5 }}`, "日本\n語", nil},
+		{"TestMixed", `{{ "hello 日本語" | wrapWidth 8 }}`, "hello\n日本語", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestWordWrapGraphemes(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | wordWrapGraphemes 10 "\n" true }}`, "", nil},
+		{"TestNewLine", `{{ .V | wordWrapGraphemes 8 "<br>" false }}`, "Cre\u0300me<br>bru\u0302le\u0301e<br>au cafe\u0301", map[string]any{"V": "Cre\u0300me bru\u0302le\u0301e au cafe\u0301"}},
+		{"TestLongWordKept", `{{ "a 🇫🇷🇩🇪🇮🇹 b" | wordWrapGraphemes 2 "\n" false }}`, "a\n🇫🇷🇩🇪🇮🇹\nb", nil},
+		{"TestLongWordWrapped", `{{ "a 🇫🇷🇩🇪🇮🇹 b" | wordWrapGraphemes 2 "\n" true }}`, "a\n🇫🇷🇩🇪\n🇮🇹\nb", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestWordWrapWidth(t *testing.T) {
+	var tests = testCases{
+		{"TestLongWordKept", `{{ "日本語 のテキスト" | wordWrapWidth 6 "\n" false }}`, "日本語\nのテキスト", nil},
+		{"TestLongWordWrapped", `{{ "日本語 のテキスト" | wordWrapWidth 6 "\n" true }}`, "日本語\nのテキ\nスト", nil},
+		{"TestNewLine", `{{ "hello 日本語" | wordWrapWidth 8 "<br>" true }}`, "hello<br>日本語", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestPadLeft(t *testing.T) {
+	var tests = testCases{
+		{"TestASCII", `{{ "foo" | padLeft 5 }}`, "  foo", nil},
+		{"TestCJK", `{{ "日本" | padLeft 6 }}`, "  日本", nil},
+		{"TestWider", `{{ "日本語" | padLeft 4 }}`, "日本語", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestPadRight(t *testing.T) {
+	var tests = testCases{
+		{"TestASCII", `{{ "foo" | padRight 5 }}`, "foo  ", nil},
+		{"TestCJK", `{{ "日本" | padRight 6 }}`, "日本  ", nil},
+		{"TestCombiningMarks", `{{ .V | padRight 3 }}`, "e\u0301  ", map[string]any{"V": "e\u0301"}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestCenter(t *testing.T) {
+	var tests = testCases{
+		{"TestEven", `{{ "ab" | center 6 }}`, "  ab  ", nil},
+		{"TestOdd", `{{ "日本" | center 9 }}`, "  日本   ", nil},
+		{"TestWider", `{{ "foobar" | center 3 }}`, "foobar", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestSlugify(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | slugify }}`, "", nil},
+		{"TestAccents", `{{ "Crème Brûlée — Menu #2" | slugify }}`, "creme-brulee-menu-2", nil},
+		{"TestDecomposedAccents", `{{ .V | slugify }}`, "cafe", map[string]any{"V": "Café"}},
+		{"TestPunctuation", `{{ "  Hello, World!!  " | slugify }}`, "hello-world", nil},
+		{"TestSpecialLatin", `{{ "Straße Øresund Łódź" | slugify }}`, "strasse-oresund-lodz", nil},
+		{"TestCyrillic", `{{ "Привет, мир" | slugify }}`, "privet-mir", nil},
+		{"TestCyrillicWithBreve", `{{ "Йога и ёлка" | slugify }}`, "yoga-i-yolka", nil},
+		{"TestGreek", `{{ "Καλημέρα κόσμε" | slugify }}`, "kalimera-kosme", nil},
+		{"TestUnsupportedScript", `{{ "日本 Japan" | slugify }}`, "japan", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestSlugifyWith(t *testing.T) {
+	var tests = testCases{
+		{"TestSeparator", `{{ "Crème Brûlée" | slugifyWith (dict "separator" "_") }}`, "creme_brulee", nil},
+		{"TestEmptySeparator", `{{ "Crème Brûlée" | slugifyWith (dict "separator" "") }}`, "cremebrulee", nil},
+		{"TestKeepCase", `{{ "Crème Brûlée" | slugifyWith (dict "lowercase" false) }}`, "Creme-Brulee", nil},
+		{"TestKeepCaseTransliterated", `{{ "Ελληνικά Νέα" | slugifyWith (dict "lowercase" false "separator" ".") }}`, "Ellinika.Nea", nil},
+		{"TestAllowed", `{{ "Release Notes v1.2" | slugifyWith (dict "allowed" ".") }}`, "release-notes-v1.2", nil},
+		{"TestMaxLength", `{{ "Release Notes v1.2" | slugifyWith (dict "allowed" "." "maxLength" 16) }}`, "release-notes-v1", nil},
+		{"TestMaxLengthTrimsSeparator", `{{ "Release Notes" | slugifyWith (dict "maxLength" 8) }}`, "release", nil},
+		{"TestInvalidOption", `{{ "Release Notes" | slugifyWith (dict "max" 8) }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustSlugifyWith(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "Crème Brûlée" | mustSlugifyWith (dict "separator" ".") }}`, "creme.brulee", nil}, ""},
+		{testCase{"TestUnknownOption", `{{ "Crème" | mustSlugifyWith (dict "max" 8) }}`, "", nil}, "unknown slug option: max"},
+		{testCase{"TestInvalidMaxLength", `{{ "Crème" | mustSlugifyWith (dict "maxLength" "ten") }}`, "", nil}, "invalid slug maxLength"},
+		{testCase{"TestInvalidLowercase", `{{ "Crème" | mustSlugifyWith (dict "lowercase" "maybe") }}`, "", nil}, "invalid slug lowercase"},
+		{testCase{"TestInvalidType", `{{ "Crème" | mustSlugifyWith "_" }}`, "", nil}, "cannot use string as slug options"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestPluralize(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | pluralize }}`, "", nil},
+		{"TestRegular", `{{ "cat" | pluralize }}`, "cats", nil},
+		{"TestAlreadyPlural", `{{ "cats" | pluralize }}`, "cats", nil},
+		{"TestSibilant", `{{ "box" | pluralize }}`, "boxes", nil},
+		{"TestConsonantY", `{{ "category" | pluralize }}`, "categories", nil},
+		{"TestVowelY", `{{ "day" | pluralize }}`, "days", nil},
+		{"TestFe", `{{ "wife" | pluralize }}`, "wives", nil},
+		{"TestIndex", `{{ "index" | pluralize }}`, "indices", nil},
+		{"TestMatrix", `{{ "matrix" | pluralize }}`, "matrices", nil},
+		{"TestIrregular", `{{ "person" | pluralize }}`, "people", nil},
+		{"TestIrregularAlreadyPlural", `{{ "people" | pluralize }}`, "people", nil},
+		{"TestUncountable", `{{ "sheep" | pluralize }}`, "sheep", nil},
+		{"TestCapitalized", `{{ "Child" | pluralize }}`, "Children", nil},
+		{"TestUppercase", `{{ "MOUSE" | pluralize }}`, "MICE", nil},
+		{"TestLastWord", `{{ "user account" | pluralize }}`, "user accounts", nil},
+		{"TestSnakeCase", `{{ "service_status" | pluralize }}`, "service_statuses", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestSingularize(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | singularize }}`, "", nil},
+		{"TestRegular", `{{ "cats" | singularize }}`, "cat", nil},
+		{"TestAlreadySingular", `{{ "cat" | singularize }}`, "cat", nil},
+		{"TestSibilant", `{{ "boxes" | singularize }}`, "box", nil},
+		{"TestIes", `{{ "Categories" | singularize }}`, "Category", nil},
+		{"TestVes", `{{ "wolves" | singularize }}`, "wolf", nil},
+		{"TestIndices", `{{ "indices" | singularize }}`, "index", nil},
+		{"TestAnalyses", `{{ "analyses" | singularize }}`, "analysis", nil},
+		{"TestIrregular", `{{ "people" | singularize }}`, "person", nil},
+		{"TestStatus", `{{ "status" | singularize }}`, "status", nil},
+		{"TestUncountable", `{{ "news" | singularize }}`, "news", nil},
+		{"TestLastWord", `{{ "user-accounts" | singularize }}`, "user-account", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestInflectionWithCustomRules(t *testing.T) {
+	var tests = testCases{
+		{"TestPluralRule", `{{ "vertex" | pluralize }}`, "vertexes", nil},
+		{"TestSingularRule", `{{ "vertexes" | singularize }}`, "vertex", nil},
+		{"TestIrregular", `{{ "octopus" | pluralize }}`, "octopodes", nil},
+		{"TestIrregularOverridesDefault", `{{ "person" | pluralize }}`, "persons", nil},
+		{"TestUncountable", `{{ "firmware" | pluralize }}`, "firmware", nil},
+		{"TestDefaultRules", `{{ "index" | pluralize }}`, "indices", nil},
+	}
+
+	handler := NewFunctionHandler(
+		WithPluralRule(`(vertex)$`, "${1}es"),
+		WithSingularRule(`(vertex)es$`, "${1}"),
+		WithIrregular("octopus", "octopodes"),
+		WithIrregular("person", "persons"),
+		WithUncountable("firmware"),
+	)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmplResponse, err := runTemplate(t, handler, test.input, test.data)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, tmplResponse)
+		})
+	}
+}
+
+func TestPluralizeCount(t *testing.T) {
+	var tests = testCases{
+		{"TestZero", `{{ "person" | pluralizeCount 0 }}`, "people", nil},
+		{"TestOne", `{{ "person" | pluralizeCount 1 }}`, "person", nil},
+		{"TestMinusOne", `{{ "person" | pluralizeCount -1 }}`, "person", nil},
+		{"TestOneEndingInS", `{{ "bonus" | pluralizeCount 1 }}`, "bonus", nil},
+		{"TestOneEndingInAs", `{{ "canvas" | pluralizeCount 1 }}`, "canvas", nil},
+		{"TestManyEndingInS", `{{ "status" | pluralizeCount 2 }}`, "statuses", nil},
+		{"TestMany", `{{ "file" | pluralizeCount 3 }}`, "files", nil},
+		{"TestFraction", `{{ "item" | pluralizeCount 1.5 }}`, "items", nil},
+		{"TestFloatOne", `{{ "item" | pluralizeCount 1.0 }}`, "item", nil},
+		{"TestString", `{{ "file" | pluralizeCount "1" }}`, "file", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestOrdinalize(t *testing.T) {
+	var tests = testCases{
+		{"TestZero", `{{ 0 | ordinalize }}`, "0th", nil},
+		{"TestFirst", `{{ 1 | ordinalize }}`, "1st", nil},
+		{"TestSecond", `{{ 2 | ordinalize }}`, "2nd", nil},
+		{"TestThird", `{{ 3 | ordinalize }}`, "3rd", nil},
+		{"TestFourth", `{{ 4 | ordinalize }}`, "4th", nil},
+		{"TestEleventh", `{{ 11 | ordinalize }}`, "11th", nil},
+		{"TestTwelfth", `{{ 112 | ordinalize }}`, "112th", nil},
+		{"TestTwentyThird", `{{ 23 | ordinalize }}`, "23rd", nil},
+		{"TestHundredFirst", `{{ 101 | ordinalize }}`, "101st", nil},
+		{"TestNegative", `{{ -21 | ordinalize }}`, "-21st", nil},
+		{"TestString", `{{ "42" | ordinalize }}`, "42nd", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestNumberToWords(t *testing.T) {
+	var tests = testCases{
+		{"TestZero", `{{ 0 | numberToWords }}`, "zero", nil},
+		{"TestSmall", `{{ 13 | numberToWords }}`, "thirteen", nil},
+		{"TestTens", `{{ 40 | numberToWords }}`, "forty", nil},
+		{"TestCompound", `{{ 42 | numberToWords }}`, "forty-two", nil},
+		{"TestHundreds", `{{ 120 | numberToWords }}`, "one hundred twenty", nil},
+		{"TestThousands", `{{ 1042 | numberToWords }}`, "one thousand forty-two", nil},
+		{"TestMillions", `{{ 2000300 | numberToWords }}`, "two million three hundred", nil},
+		{"TestNegative", `{{ -7 | numberToWords }}`, "minus seven", nil},
+		{"TestMinInt64", `{{ .V | numberToWords }}`, "minus nine quintillion two hundred twenty-three quadrillion three hundred seventy-two trillion thirty-six billion eight hundred fifty-four million seven hundred seventy-five thousand eight hundred eight", map[string]any{"V": int64(-9223372036854775808)}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestHumanJoin(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ list | humanJoin }}`, "", nil},
+		{"TestOne", `{{ list "a" | humanJoin }}`, "a", nil},
+		{"TestTwo", `{{ list "a" "b" | humanJoin }}`, "a and b", nil},
+		{"TestThree", `{{ list "a" "b" "c" | humanJoin }}`, "a, b and c", nil},
+		{"TestNumbers", `{{ list 1 2 3 | humanJoin }}`, "1, 2 and 3", nil},
+		{"TestWith", `{{ list "a" "b" "c" | humanJoinWith "or" }}`, "a, b or c", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ levenshteinDistance "" "" }}`, "0", nil},
+		{"TestEmptyFirst", `{{ levenshteinDistance "" "abc" }}`, "3", nil},
+		{"TestEqual", `{{ levenshteinDistance "abc" "abc" }}`, "0", nil},
+		{"TestKitten", `{{ levenshteinDistance "kitten" "sitting" }}`, "3", nil},
+		{"TestTransposition", `{{ levenshteinDistance "ca" "ac" }}`, "2", nil},
+		{"TestUnicode", `{{ levenshteinDistance "café" "cafe" }}`, "1", nil},
+		{"TestCJK", `{{ levenshteinDistance "日本語" "日本" }}`, "1", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestLevenshteinSimilarity(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ levenshteinSimilarity "" "" }}`, "1", nil},
+		{"TestEqual", `{{ levenshteinSimilarity "abc" "abc" }}`, "1", nil},
+		{"TestDifferent", `{{ levenshteinSimilarity "abc" "xyz" }}`, "0", nil},
+		{"TestSimilar", `{{ levenshteinSimilarity "staging" "stageing" }}`, "0.875", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestDamerauLevenshteinDistance(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ damerauLevenshteinDistance "" "" }}`, "0", nil},
+		{"TestEmptySecond", `{{ damerauLevenshteinDistance "abc" "" }}`, "3", nil},
+		{"TestTransposition", `{{ damerauLevenshteinDistance "prodcution" "production" }}`, "1", nil},
+		{"TestUnrestrictedTransposition", `{{ damerauLevenshteinDistance "ca" "abc" }}`, "2", nil},
+		{"TestKitten", `{{ damerauLevenshteinDistance "kitten" "sitting" }}`, "3", nil},
+		{"TestUnicode", `{{ damerauLevenshteinDistance "éa" "aé" }}`, "1", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestDamerauLevenshteinSimilarity(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ damerauLevenshteinSimilarity "" "" }}`, "1", nil},
+		{"TestTransposition", `{{ damerauLevenshteinSimilarity "prodcution" "production" }}`, "0.9", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestJaroWinklerSimilarity(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ jaroWinklerSimilarity "" "" }}`, "1", nil},
+		{"TestOneEmpty", `{{ jaroWinklerSimilarity "abc" "" }}`, "0", nil},
+		{"TestNoMatch", `{{ jaroWinklerSimilarity "abc" "xyz" }}`, "0", nil},
+		{"TestEqual", `{{ jaroWinklerSimilarity "abc" "abc" }}`, "1", nil},
+		{"TestMartha", `{{ round (jaroWinklerSimilarity "MARTHA" "MARHTA") 4 }}`, "0.9611", nil},
+		{"TestDwayne", `{{ round (jaroWinklerSimilarity "DWAYNE" "DUANE") 4 }}`, "0.84", nil},
+		{"TestDixon", `{{ round (jaroWinklerSimilarity "DIXON" "DICKSONX") 4 }}`, "0.8133", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestJaroWinklerDistance(t *testing.T) {
+	var tests = testCases{
+		{"TestEqual", `{{ jaroWinklerDistance "hello" "hello" }}`, "0", nil},
+		{"TestNoMatch", `{{ jaroWinklerDistance "abc" "xyz" }}`, "1", nil},
+		{"TestMartha", `{{ round (jaroWinklerDistance "MARTHA" "MARHTA") 4 }}`, "0.0389", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestSoundex(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | soundex }}`, "", nil},
+		{"TestNoLetters", `{{ "123" | soundex }}`, "", nil},
+		{"TestRobert", `{{ "Robert" | soundex }}`, "R163", nil},
+		{"TestRupert", `{{ "Rupert" | soundex }}`, "R163", nil},
+		{"TestRubin", `{{ "Rubin" | soundex }}`, "R150", nil},
+		{"TestAshcraft", `{{ "Ashcraft" | soundex }}`, "A261", nil},
+		{"TestTymczak", `{{ "Tymczak" | soundex }}`, "T522", nil},
+		{"TestPfister", `{{ "Pfister" | soundex }}`, "P236", nil},
+		{"TestHoneyman", `{{ "Honeyman" | soundex }}`, "H555", nil},
+		{"TestDiacritics", `{{ "Müller" | soundex }}`, "M460", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMetaphone(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | metaphone }}`, "", nil},
+		{"TestSmith", `{{ "Smith" | metaphone }}`, "SM0", nil},
+		{"TestKnight", `{{ "Knight" | metaphone }}`, "NT", nil},
+		{"TestWright", `{{ "Wright" | metaphone }}`, "RT", nil},
+		{"TestXavier", `{{ "Xavier" | metaphone }}`, "SFR", nil},
+		{"TestPhone", `{{ "phone" | metaphone }}`, "FN", nil},
+		{"TestDumb", `{{ "dumb" | metaphone }}`, "TM", nil},
+		{"TestScience", `{{ "science" | metaphone }}`, "SNS", nil},
+		{"TestJudge", `{{ "judge" | metaphone }}`, "JJ", nil},
+		{"TestNation", `{{ "nation" | metaphone }}`, "NXN", nil},
+		{"TestCharacter", `{{ "character" | metaphone }}`, "XRKTR", nil},
+		{"TestDiacritics", `{{ "Müller" | metaphone }}`, "MLR", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestClosestMatch(t *testing.T) {
+	var tests = testCases{
+		{"TestTypo", `{{ "prodution" | closestMatch 0.8 (list "production" "staging") }}`, "production", nil},
+		{"TestTransposition", `{{ "stagign" | closestMatch 0.7 (list "production" "staging") }}`, "staging", nil},
+		{"TestBelowThreshold", `{{ "dev" | closestMatch 0.8 (list "production" "staging") }}`, "", nil},
+		{"TestIntegerThreshold", `{{ "staging" | closestMatch 1 (list "production" "staging") }}`, "staging", nil},
+		{"TestEmptyCandidates", `{{ "staging" | closestMatch 0.5 (list) }}`, "", nil},
+		{"TestFromVariable", `{{ .V | closestMatch 0.5 .C }}`, "eu-west-1", map[string]any{"V": "eu-wset-1", "C": []string{"us-east-1", "eu-west-1"}}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	var tests = testCases{
+		{"TestIdentical", `{{ unifiedDiff "a\nb\n" "a\nb\n" }}`, "", nil},
+		{"TestBothEmpty", `{{ unifiedDiff "" "" }}`, "", nil},
+		{"TestChangedLine", `{{ "replicas: 3\n" | unifiedDiff "replicas: 2\n" }}`, "--- a\n+++ b\n@@ -1 +1 @@\n-replicas: 2\n+replicas: 3", nil},
+		{"TestFromEmpty", `{{ unifiedDiff "" "a\nb\n" }}`, "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b", nil},
+		{"TestToEmpty", `{{ unifiedDiff "a\nb\n" "" }}`, "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-a\n-b", nil},
+		{"TestContext", `{{ unifiedDiff "1\n2\n3\n4\n5\n6\n7\n8\n9\n" "1\n2\n3\n4\nfive\n6\n7\n8\n9\n" }}`, "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8", nil},
+		{"TestSeparateHunks", `{{ unifiedDiff "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n" "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n" }}`, "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten", nil},
+		{"TestMergedHunks", `{{ unifiedDiff "1\n2\n3\n4\n5\n6\n7\n8\n" "one\n2\n3\n4\n5\n6\n7\neight\n" }}`, "--- a\n+++ b\n@@ -1,8 +1,8 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+eight", nil},
+		{"TestMissingNewline", `{{ unifiedDiff "a\nb\n" "a\nb" }}`, "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file", nil},
+		{"TestFromVariable", `{{ unifiedDiff .Before .After }}`, "--- a\n+++ b\n@@ -1,2 +1,3 @@\n name: app\n+image: app:v2\n port: 80", map[string]any{"Before": "name: app\nport: 80\n", "After": "name: app\nimage: app:v2\nport: 80\n"}},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestUnifiedDiffWith(t *testing.T) {
+	var tests = testCases{
+		{"TestLabels", `{{ unifiedDiffWith (dict "fromLabel" "live" "toLabel" "desired") "a\n" "b\n" }}`, "--- live\n+++ desired\n@@ -1 +1 @@\n-a\n+b", nil},
+		{"TestNoContext", `{{ unifiedDiffWith (dict "context" 0) "a\nb\nc\n" "a\nB\nc\n" }}`, "--- a\n+++ b\n@@ -2 +2 @@\n-b\n+B", nil},
+		{"TestNoContextInsertion", `{{ unifiedDiffWith (dict "context" 0) "a\nc\n" "a\nb\nc\n" }}`, "--- a\n+++ b\n@@ -1,0 +2 @@\n+b", nil},
+		{"TestOneLineContext", `{{ unifiedDiffWith (dict "context" 1) "1\n2\n3\n4\n5\n" "1\n2\nthree\n4\n5\n" }}`, "--- a\n+++ b\n@@ -2,3 +2,3 @@\n 2\n-3\n+three\n 4", nil},
+		{"TestInvalidOption", `{{ unifiedDiffWith (dict "color" true) "a" "b" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustUnifiedDiffWith(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValidOptions", `{{ mustUnifiedDiffWith (dict "context" 0) "a\nb\n" "a\nc\n" }}`, "--- a\n+++ b\n@@ -2 +2 @@\n-b\n+c", nil}, ""},
+		{testCase{"TestUnknownOption", `{{ mustUnifiedDiffWith (dict "color" true) "a" "b" }}`, "", nil}, "unknown diff option: color"},
+		{testCase{"TestInvalidContext", `{{ mustUnifiedDiffWith (dict "context" "many") "a" "b" }}`, "", nil}, "invalid diff context"},
+		{testCase{"TestNegativeContext", `{{ mustUnifiedDiffWith (dict "context" -1) "a" "b" }}`, "", nil}, "invalid diff context: -1 is negative"},
+		{testCase{"TestInvalidOptions", `{{ mustUnifiedDiffWith 3 "a" "b" }}`, "", nil}, "cannot use int as diff options"},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestLineDiff(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ lineDiff "" "" }}`, "[]", nil},
+		{"TestRange", `{{ range lineDiff "a\nb" "a\nc" }}{{ .op }}:{{ .line }} {{ end }}`, "equal:a delete:b insert:c ", nil},
+		{"TestInsertion", `{{ range lineDiff "a\nc\n" "a\nb\nc\n" }}{{ .op }}:{{ .line }} {{ end }}`, "equal:a insert:b equal:c ", nil},
+		{"TestDeletion", `{{ range lineDiff "a\nb\nc\n" "a\nc\n" }}{{ .op }}:{{ .line }} {{ end }}`, "equal:a delete:b equal:c ", nil},
+		{"TestCount", `{{ lineDiff "a\nb\nc\n" "x\ny\n" | len }}`, "5", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestLineDiffLarge(t *testing.T) {
+	var from, to, changed strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&from, "from %d\n", i)
+		fmt.Fprintf(&to, "to %d\n", i)
+		if i%1000 == 500 {
+			fmt.Fprintf(&changed, "changed %d\n", i)
+		} else {
+			fmt.Fprintf(&changed, "from %d\n", i)
+		}
+	}
+
+	handler := NewFunctionHandler()
+	countOps := func(edits []map[string]any) map[any]int {
+		counts := make(map[any]int)
+		for _, edit := range edits {
+			counts[edit["op"]]++
+		}
+		return counts
+	}
+
+	counts := countOps(handler.LineDiff(from.String(), to.String()))
+	assert.Equal(t, map[any]int{"delete": 5000, "insert": 5000}, counts)
+
+	counts = countOps(handler.LineDiff(from.String(), changed.String()))
+	assert.Equal(t, map[any]int{"equal": 4995, "delete": 5, "insert": 5}, counts)
+}
+
+func TestAnsiStyle(t *testing.T) {
+	var tests = testCases{
+		{"TestEmptySpec", `{{ "plain" | ansiStyle "" }}`, "plain", nil},
+		{"TestAttribute", `{{ "title" | ansiStyle "bold" }}`, "\x1b[1mtitle\x1b[0m", nil},
+		{"TestAttributes", `{{ "title" | ansiStyle "bold italic underline" }}`, "\x1b[1;3;4mtitle\x1b[0m", nil},
+		{"TestForegroundAndBackground", `{{ "FAIL" | ansiStyle "bold white on red" }}`, "\x1b[1;37;41mFAIL\x1b[0m", nil},
+		{"TestBrightColor", `{{ "ok" | ansiStyle "bright-green" }}`, "\x1b[92mok\x1b[0m", nil},
+		{"Test256Colors", `{{ "warn" | ansiStyle "208 on 236" }}`, "\x1b[38;5;208;48;5;236mwarn\x1b[0m", nil},
+		{"TestTruecolor", `{{ "done" | ansiStyle "italic #00ff00" }}`, "\x1b[3;38;2;0;255;0mdone\x1b[0m", nil},
+		{"TestShortHex", `{{ "done" | ansiStyle "on #f80" }}`, "\x1b[48;2;255;136;0mdone\x1b[0m", nil},
+		{"TestNested", `{{ print "a" ("b" | ansiStyle "bold") "c" | ansiStyle "red" }}`, "\x1b[31ma\x1b[1mb\x1b[0m\x1b[31mc\x1b[0m", nil},
+		{"TestInvalidSpec", `{{ "x" | ansiStyle "purple" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestAnsiColor(t *testing.T) {
+	var tests = testCases{
+		{"TestNamed", `{{ "ok" | ansiColor "green" }}`, "\x1b[32mok\x1b[0m", nil},
+		{"TestCamelCase", `{{ "ok" | ansiColor "brightBlue" }}`, "\x1b[94mok\x1b[0m", nil},
+		{"TestGray", `{{ "dim" | ansiColor "gray" }}`, "\x1b[90mdim\x1b[0m", nil},
+		{"TestIndex", `{{ "warn" | ansiColor 208 }}`, "\x1b[38;5;208mwarn\x1b[0m", nil},
+		{"TestHex", `{{ "x" | ansiColor "#FF8800" }}`, "\x1b[38;2;255;136;0mx\x1b[0m", nil},
+		{"TestInvalid", `{{ "x" | ansiColor "purple" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestAnsiBgColor(t *testing.T) {
+	var tests = testCases{
+		{"TestNamed", `{{ "note" | ansiBgColor "blue" }}`, "\x1b[44mnote\x1b[0m", nil},
+		{"TestBright", `{{ "note" | ansiBgColor "brightYellow" }}`, "\x1b[103mnote\x1b[0m", nil},
+		{"TestIndex", `{{ "note" | ansiBgColor 17 }}`, "\x1b[48;5;17mnote\x1b[0m", nil},
+		{"TestHex", `{{ "note" | ansiBgColor "#003366" }}`, "\x1b[48;2;0;51;102mnote\x1b[0m", nil},
+		{"TestInvalid", `{{ "x" | ansiBgColor "#zzzzzz" }}`, "", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestMustAnsiStyle(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "x" | mustAnsiStyle "bold red" }}`, "\x1b[1;31mx\x1b[0m", nil}, ""},
+		{testCase{"TestUnknownColor", `{{ "x" | mustAnsiStyle "bold purple" }}`, "", nil}, `unknown ANSI color: "purple"`},
+		{testCase{"TestAttributeAsBackground", `{{ "x" | mustAnsiStyle "on bold" }}`, "", nil}, `unknown ANSI color: "bold"`},
+		{testCase{"TestMissingBackground", `{{ "x" | mustAnsiStyle "red on" }}`, "", nil}, `missing background color in ANSI style: "red on"`},
+		{testCase{"TestInvalidIndex", `{{ "x" | mustAnsiStyle "256" }}`, "", nil}, `invalid 256-color index: "256"`},
+		{testCase{"TestInvalidHex", `{{ "x" | mustAnsiStyle "#12345" }}`, "", nil}, `invalid hex color: "#12345"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustAnsiColor(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "ok" | mustAnsiColor "brightGreen" }}`, "\x1b[92mok\x1b[0m", nil}, ""},
+		{testCase{"TestInvalid", `{{ "ok" | mustAnsiColor "bold" }}`, "", nil}, `unknown ANSI color: "bold"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestMustAnsiBgColor(t *testing.T) {
+	var tests = mustTestCases{
+		{testCase{"TestValid", `{{ "note" | mustAnsiBgColor "blue" }}`, "\x1b[44mnote\x1b[0m", nil}, ""},
+		{testCase{"TestInvalid", `{{ "note" | mustAnsiBgColor 300 }}`, "", nil}, `invalid 256-color index: "300"`},
+	}
+
+	runMustTestCases(t, tests)
+}
+
+func TestAnsiNoColor(t *testing.T) {
+	handler := NewFunctionHandler(WithEnvironment(map[string]string{"NO_COLOR": "1"}))
+
+	var tests = testCases{
+		{"TestStyle", `{{ "FAIL" | ansiStyle "bold red" }}`, "FAIL", nil},
+		{"TestColor", `{{ "ok" | ansiColor "green" }}`, "ok", nil},
+		{"TestBgColor", `{{ "note" | ansiBgColor "blue" }}`, "note", nil},
+		{"TestInvalidSpec", `{{ "x" | ansiStyle "purple" }}`, "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := runTemplate(t, handler, test.input, test.data)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+
+	handler = NewFunctionHandler(WithEnvironment(map[string]string{"NO_COLOR": ""}))
+	result, err := runTemplate(t, handler, `{{ "ok" | ansiColor "green" }}`, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "\x1b[32mok\x1b[0m", result)
+}
+
+func TestStripAnsi(t *testing.T) {
+	var tests = testCases{
+		{"TestEmpty", `{{ "" | stripAnsi }}`, "", nil},
+		{"TestPlain", `{{ "plain" | stripAnsi }}`, "plain", nil},
+		{"TestSGR", `{{ "\x1b[1;31mFAIL\x1b[0m" | stripAnsi }}`, "FAIL", nil},
+		{"TestCursor", `{{ "\x1b[2K\x1b[1Gdone" | stripAnsi }}`, "done", nil},
+		{"TestHyperlink", `{{ "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\a" | stripAnsi }}`, "link", nil},
+		{"TestTwoByteEscape", `{{ "a\x1bMb" | stripAnsi }}`, "ab", nil},
+		{"TestUnterminated", `{{ "a\x1b[31" | stripAnsi }}`, "a\x1b[31", nil},
+		{"TestStyled", `{{ "ok" | ansiColor "green" | stripAnsi }}`, "ok", nil},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestAnsiAwareFunctions(t *testing.T) {
+	var tests = testCases{
+		{"TestDisplayWidth", `{{ "日本" | ansiColor "red" | displayWidth }}`, "4", nil},
+		{"TestGraphemeCount", `{{ "abc" | ansiStyle "bold" | graphemeCount }}`, "3", nil},
+		{"TestTrunc", `{{ "hello" | ansiColor "red" | trunc 3 }}`, "\x1b[31mhel\x1b[0m", nil},
+		{"TestTruncNegative", `{{ "hello" | ansiColor "red" | trunc -3 }}`, "\x1b[31mllo\x1b[0m", nil},
+		{"TestTruncLonger", `{{ "hi" | ansiColor "red" | trunc 5 }}`, "\x1b[31mhi\x1b[0m", nil},
+		{"TestTruncWidth", `{{ "日本語" | ansiColor "red" | truncWidth 4 }}`, "\x1b[31m日本\x1b[0m", nil},
+		{"TestTruncGraphemesNegative", `{{ "hello" | ansiColor "red" | truncGraphemes -2 }}`, "\x1b[31mlo\x1b[0m", nil},
+		{"TestEllipsisWidth", `{{ "hello world" | ansiColor "red" | ellipsisWidth 5 }}`, "\x1b[31mhe\x1b[0m...", nil},
+		{"TestEllipsis", `{{ "abcdefghijkl" | ansiColor "red" | ellipsis 6 }}`, "\x1b[31mabc\x1b[0m...", nil},
+		{"TestEllipsisShorter", `{{ "abc" | ansiColor "red" | ellipsis 6 }}`, "\x1b[31mabc\x1b[0m", nil},
+		{"TestEllipsisBoth", `{{ "foooboooooo" | ansiColor "red" | ellipsisBoth 4 9 }}`, "...\x1b[31mboo\x1b[0m...", nil},
+		{"TestEllipsisMultibyte", `{{ "crème brûlée" | ansiColor "red" | ellipsis 7 }}`, "\x1b[31mcrèm\x1b[0m...", nil},
+		{"TestSubstr", `{{ "abcdefghijkl" | ansiColor "red" | substr 0 4 }}`, "\x1b[31mabcd\x1b[0m", nil},
+		{"TestSubstrNegative", `{{ "hello" | ansiColor "red" | substr -3 -1 }}`, "\x1b[31mll\x1b[0m", nil},
+		{"TestSubstrInvalidRange", `{{ "hello" | ansiColor "red" | substr 3 1 }}`, "\x1b[31m\x1b[0m", nil},
+		{"TestSubstrWidth", `{{ "hello" | ansiColor "red" | substrWidth 1 3 }}`, "\x1b[31mel\x1b[0m", nil},
+		{"TestWrap", `{{ "hi yo" | ansiColor "red" | wrap 3 }}`, "\x1b[31mhi\nyo\x1b[0m", nil},
+		{"TestWrapWith", `{{ "hello" | ansiColor "red" | wrapWith 3 "\n" }}`, "\x1b[31mhel\nlo\x1b[0m", nil},
+		{"TestWrapWidth", `{{ "hello world" | ansiColor "red" | wrapWidth 3 }}`, "\x1b[31mhel\nlo\nwor\nld\x1b[0m", nil},
+		{"TestPadLeft", `{{ "ab" | ansiColor "red" | padLeft 4 }}`, "  \x1b[31mab\x1b[0m", nil},
+	}
+
+	runTestCases(t, tests)
+}